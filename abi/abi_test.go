@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/miniBamboo/luckyshare/abi"
 	"github.com/miniBamboo/luckyshare/luckyshare"
 	"github.com/miniBamboo/luckyshare/sharer/gen"
@@ -84,3 +85,48 @@ func TestABI(t *testing.T) {
 
 	}
 }
+
+func TestEventArgs(t *testing.T) {
+	movedABI, err := abi.New([]byte(`[{"anonymous":false,"inputs":[
+		{"indexed":true,"name":"from","type":"address"},
+		{"indexed":true,"name":"delta","type":"int8"},
+		{"indexed":true,"name":"memo","type":"string"},
+		{"indexed":false,"name":"amount","type":"uint256"}
+	],"name":"Moved","type":"event"}]`))
+	assert.Nil(t, err)
+	event, _ := movedABI.EventByName("Moved")
+
+	assert.Equal(t, []abi.EventArg{
+		{Name: "from", Type: "address", Indexed: true},
+		{Name: "delta", Type: "int8", Indexed: true},
+		{Name: "memo", Type: "string", Indexed: true},
+		{Name: "amount", Type: "uint256", Indexed: false},
+	}, event.Args())
+
+	from := luckyshare.BytesToAddress([]byte("from"))
+	i1, t1, err := event.EncodeTopic("from", from.String())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, i1)
+	i2, t2, err := event.EncodeTopic("delta", "-2")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, i2)
+	i3, t3, err := event.EncodeTopic("memo", "hello")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, i3)
+	assert.Equal(t, luckyshare.Bytes32(crypto.Keccak256Hash([]byte("hello"))), t3)
+
+	_, _, err = event.EncodeTopic("delta", "128")
+	assert.NotNil(t, err, "out of range")
+	_, _, err = event.EncodeTopic("amount", "1")
+	assert.NotNil(t, err, "not indexed")
+
+	data, err := event.Encode(big.NewInt(100))
+	assert.Nil(t, err)
+
+	args, err := event.DecodeArgs([]luckyshare.Bytes32{event.ID(), t1, t2, t3}, data)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{common.Address(from), int8(-2), t3, big.NewInt(100)}, args)
+
+	_, err = event.DecodeArgs([]luckyshare.Bytes32{event.ID(), t1}, data)
+	assert.NotNil(t, err, "insufficient topics")
+}
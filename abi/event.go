@@ -6,10 +6,24 @@
 package abi
 
 import (
+	"errors"
+	"math/big"
+	"strconv"
+
 	ethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/miniBamboo/luckyshare/luckyshare"
 )
 
+// EventArg describes an arg of event.
+type EventArg struct {
+	Name    string
+	Type    string
+	Indexed bool
+}
+
 // Event see abi.Event in go-ethereum.
 type Event struct {
 	id                 luckyshare.Bytes32
@@ -41,6 +55,11 @@ func (e *Event) Name() string {
 	return e.event.Name
 }
 
+// Anonymous returns whether the event is anonymous, whose id is not logged in topics.
+func (e *Event) Anonymous() bool {
+	return e.event.Anonymous
+}
+
 // Encode encodes args to data.
 func (e *Event) Encode(args ...interface{}) ([]byte, error) {
 	return e.argsWithoutIndexed.Pack(args...)
@@ -50,3 +69,138 @@ func (e *Event) Encode(args ...interface{}) ([]byte, error) {
 func (e *Event) Decode(data []byte, v interface{}) error {
 	return e.argsWithoutIndexed.Unpack(v, data)
 }
+
+// Args returns all args of the event, in the order of declaration.
+func (e *Event) Args() []EventArg {
+	args := make([]EventArg, 0, len(e.event.Inputs))
+	for _, arg := range e.event.Inputs {
+		args = append(args, EventArg{arg.Name, arg.Type.String(), arg.Indexed})
+	}
+	return args
+}
+
+// DecodeArgs decodes values of all args, indexed ones from topics and others from data, in the order of declaration.
+// Topics should be in the form of the event log, which leads with the event id if not anonymous.
+// Indexed args of dynamic types are decoded as bytes32 hash, since only the hash is kept in topics.
+func (e *Event) DecodeArgs(topics []luckyshare.Bytes32, data []byte) ([]interface{}, error) {
+	values, err := e.argsWithoutIndexed.UnpackValues(data)
+	if err != nil {
+		return nil, err
+	}
+
+	topicIndex := 1
+	if e.event.Anonymous {
+		topicIndex = 0
+	}
+	args := make([]interface{}, 0, len(e.event.Inputs))
+	for _, arg := range e.event.Inputs {
+		if !arg.Indexed {
+			args = append(args, values[0])
+			values = values[1:]
+			continue
+		}
+		if topicIndex >= len(topics) {
+			return nil, errors.New("insufficient topics")
+		}
+		topic := topics[topicIndex]
+		topicIndex++
+		if isHashedType(arg.Type) {
+			args = append(args, topic)
+			continue
+		}
+		v, err := ethabi.Arguments{{Type: arg.Type}}.UnpackValues(topic[:])
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v[0])
+	}
+	return args, nil
+}
+
+// EncodeTopic encodes the value of the named indexed arg into topic, along with the index of the topic.
+// The value is in text form, decimal or hex for numbers, hex for addresses and bytes, and 'true' or 'false' for bools.
+func (e *Event) EncodeTopic(name string, value string) (int, luckyshare.Bytes32, error) {
+	index := 1
+	if e.event.Anonymous {
+		index = 0
+	}
+	for _, arg := range e.event.Inputs {
+		if !arg.Indexed {
+			continue
+		}
+		if arg.Name == name {
+			topic, err := encodeTopic(arg.Type, value)
+			if err != nil {
+				return 0, luckyshare.Bytes32{}, err
+			}
+			return index, topic, nil
+		}
+		index++
+	}
+	return 0, luckyshare.Bytes32{}, errors.New("indexed arg not found: " + name)
+}
+
+func isHashedType(t ethabi.Type) bool {
+	switch t.T {
+	case ethabi.StringTy, ethabi.BytesTy, ethabi.SliceTy, ethabi.ArrayTy:
+		return true
+	}
+	return false
+}
+
+func encodeTopic(t ethabi.Type, value string) (topic luckyshare.Bytes32, err error) {
+	switch t.T {
+	case ethabi.AddressTy:
+		addr, err := luckyshare.ParseAddress(value)
+		if err != nil {
+			return topic, err
+		}
+		return luckyshare.BytesToBytes32(addr[:]), nil
+	case ethabi.UintTy, ethabi.IntTy:
+		n, ok := new(big.Int).SetString(value, 0)
+		if !ok {
+			return topic, errors.New("invalid number")
+		}
+		bits := n.BitLen()
+		if t.T == ethabi.IntTy {
+			if n.Sign() < 0 {
+				bits = new(big.Int).Not(n).BitLen()
+			}
+			bits++
+		} else if n.Sign() < 0 {
+			bits = t.Size + 1
+		}
+		if bits > t.Size {
+			return topic, errors.New("number out of range of " + t.String())
+		}
+		return luckyshare.BytesToBytes32(math.U256Bytes(n)), nil
+	case ethabi.BoolTy:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return topic, err
+		}
+		if b {
+			topic[31] = 1
+		}
+		return topic, nil
+	case ethabi.FixedBytesTy:
+		b, err := hexutil.Decode(value)
+		if err != nil {
+			return topic, err
+		}
+		if len(b) != t.Size {
+			return topic, errors.New("length mismatch of " + t.String())
+		}
+		copy(topic[:], b)
+		return topic, nil
+	case ethabi.StringTy:
+		return luckyshare.Bytes32(crypto.Keccak256Hash([]byte(value))), nil
+	case ethabi.BytesTy:
+		b, err := hexutil.Decode(value)
+		if err != nil {
+			return topic, err
+		}
+		return luckyshare.Bytes32(crypto.Keccak256Hash(b)), nil
+	}
+	return topic, errors.New("unsupported indexed type " + t.String())
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package abis
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/abi"
+	"github.com/miniBamboo/luckyshare/api/utils"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/sharer"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxRegistered limits the count of registered ABIs.
+	maxRegistered = 1000
+	// maxABISize limits the size of a registered ABI.
+	maxABISize = 256 * 1024
+)
+
+// ABIs is the registry of contract ABIs, to decode events emitted by contracts.
+// ABIs of sharer contracts are built in, and others can be registered via API if writable.
+// Registered ABIs are kept in memory only.
+type ABIs struct {
+	builtins   map[luckyshare.Address]*abi.ABI
+	registered map[luckyshare.Address]json.RawMessage
+	decoded    map[luckyshare.Address]*abi.ABI
+	writable   bool
+	lock       sync.RWMutex
+}
+
+// New creates the registry. The registering API is mounted only if writable,
+// since requests are not authenticated and registered ABIs are shared by all API users.
+func New(writable bool) *ABIs {
+	builtins := make(map[luckyshare.Address]*abi.ABI)
+	for _, c := range []struct {
+		addr luckyshare.Address
+		abi  *abi.ABI
+	}{
+		{sharer.Params.Address, sharer.Params.ABI},
+		{sharer.Authority.Address, sharer.Authority.ABI},
+		{sharer.Energy.Address, sharer.Energy.ABI},
+		{sharer.Executor.Address, sharer.Executor.ABI},
+		{sharer.Extension.Address, sharer.Extension.V2.ABI},
+		{sharer.Staker.Address, sharer.Staker.ABI},
+		{sharer.Scheduler.Address, sharer.Scheduler.ABI},
+	} {
+		builtins[c.addr] = c.abi
+	}
+	return &ABIs{
+		builtins:   builtins,
+		registered: make(map[luckyshare.Address]json.RawMessage),
+		decoded:    make(map[luckyshare.Address]*abi.ABI),
+		writable:   writable,
+	}
+}
+
+func (a *ABIs) contractABI(addr luckyshare.Address) *abi.ABI {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	if contractABI, ok := a.decoded[addr]; ok {
+		return contractABI
+	}
+	return a.builtins[addr]
+}
+
+// EventByID finds the event with the given id emitted by the contract at addr.
+// The extra ABI, if not nil, takes precedence over ABIs in the registry.
+// Prototype events are emitted for any account, so they are found regardless of the address.
+func (a *ABIs) EventByID(extra *abi.ABI, addr luckyshare.Address, id luckyshare.Bytes32) (*abi.Event, bool) {
+	if extra != nil {
+		if ev, found := extra.EventByID(id); found {
+			return ev, true
+		}
+	}
+	if contractABI := a.contractABI(addr); contractABI != nil {
+		if ev, found := contractABI.EventByID(id); found {
+			return ev, true
+		}
+	}
+	return sharer.Prototype.Events().EventByID(id)
+}
+
+// EventByName finds the event with the given name emitted by the contract at addr.
+// The extra ABI, if not nil, takes precedence over ABIs in the registry.
+func (a *ABIs) EventByName(extra *abi.ABI, addr *luckyshare.Address, name string) (*abi.Event, bool) {
+	if extra != nil {
+		if ev, found := extra.EventByName(name); found {
+			return ev, true
+		}
+	}
+	if addr != nil {
+		if contractABI := a.contractABI(*addr); contractABI != nil {
+			if ev, found := contractABI.EventByName(name); found {
+				return ev, true
+			}
+		}
+	}
+	return sharer.Prototype.Events().EventByName(name)
+}
+
+// Decode decodes the event if its ABI is found, otherwise nil returned.
+func (a *ABIs) Decode(extra *abi.ABI, addr luckyshare.Address, topics []luckyshare.Bytes32, data []byte) *DecodedEvent {
+	if len(topics) == 0 {
+		return nil
+	}
+	ev, found := a.EventByID(extra, addr, topics[0])
+	if !found {
+		return nil
+	}
+	values, err := ev.DecodeArgs(topics, data)
+	if err != nil {
+		// the event may be emitted with a different layout but the same id
+		return nil
+	}
+	return newDecodedEvent(ev, values)
+}
+
+// Register registers the ABI of the contract at addr.
+func (a *ABIs) Register(addr luckyshare.Address, data []byte) error {
+	contractABI, err := abi.New(data)
+	if err != nil {
+		return err
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if _, ok := a.registered[addr]; !ok && len(a.registered) >= maxRegistered {
+		return errors.New("too many registered ABIs")
+	}
+	a.registered[addr] = append(json.RawMessage(nil), data...)
+	a.decoded[addr] = contractABI
+	return nil
+}
+
+// Unregister removes the registered ABI of the contract at addr.
+func (a *ABIs) Unregister(addr luckyshare.Address) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	delete(a.registered, addr)
+	delete(a.decoded, addr)
+}
+
+func (a *ABIs) handleGetABI(w http.ResponseWriter, req *http.Request) error {
+	addr, err := luckyshare.ParseAddress(mux.Vars(req)["address"])
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "address"))
+	}
+	a.lock.RLock()
+	data, ok := a.registered[addr]
+	a.lock.RUnlock()
+	if !ok {
+		return utils.HTTPError(errors.New("not found"), http.StatusNotFound)
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_, err = w.Write(data)
+	return err
+}
+
+func (a *ABIs) handlePutABI(w http.ResponseWriter, req *http.Request) error {
+	addr, err := luckyshare.ParseAddress(mux.Vars(req)["address"])
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "address"))
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxABISize))
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	if err := a.Register(addr, data); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	return utils.WriteJSON(w, nil)
+}
+
+func (a *ABIs) handleDeleteABI(w http.ResponseWriter, req *http.Request) error {
+	addr, err := luckyshare.ParseAddress(mux.Vars(req)["address"])
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "address"))
+	}
+	a.Unregister(addr)
+	return utils.WriteJSON(w, nil)
+}
+
+func (a *ABIs) Mount(root *mux.Router, pathPrefix string) {
+	sub := root.PathPrefix(pathPrefix).Subrouter()
+
+	sub.Path("/{address}").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetABI))
+	if a.writable {
+		sub.Path("/{address}").Methods(http.MethodPut).HandlerFunc(utils.WrapHandlerFunc(a.handlePutABI))
+		sub.Path("/{address}").Methods(http.MethodDelete).HandlerFunc(utils.WrapHandlerFunc(a.handleDeleteABI))
+	}
+}
+
+// ResolveTopics fills topics with the id of the event and values of its indexed args given by name.
+// It fails if any topic is already set with a different value.
+func ResolveTopics(topics *[5]*luckyshare.Bytes32, ev *abi.Event, args map[string]string) error {
+	set := func(i int, topic luckyshare.Bytes32) error {
+		if i >= len(topics) {
+			return errors.Errorf("topic%d out of range", i)
+		}
+		if topics[i] != nil && *topics[i] != topic {
+			return errors.Errorf("topic%d conflicts with event %s", i, ev.Name())
+		}
+		topics[i] = &topic
+		return nil
+	}
+	if !ev.Anonymous() {
+		if err := set(0, ev.ID()); err != nil {
+			return err
+		}
+	}
+	for name, value := range args {
+		i, topic, err := ev.EncodeTopic(name, value)
+		if err != nil {
+			return errors.WithMessage(err, "args."+name)
+		}
+		if err := set(i, topic); err != nil {
+			return err
+		}
+	}
+	return nil
+}
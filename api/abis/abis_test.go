@@ -0,0 +1,140 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package abis_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/api/abis"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/sharer"
+	"github.com/stretchr/testify/assert"
+)
+
+const tokenABI = `[{"anonymous":false,"inputs":[{"indexed":true,"name":"_from","type":"address"},{"indexed":true,"name":"_to","type":"address"},{"indexed":false,"name":"_value","type":"uint256"}],"name":"Transfer","type":"event"}]`
+
+var (
+	token = luckyshare.BytesToAddress([]byte("token"))
+	from  = luckyshare.BytesToAddress([]byte("from"))
+	to    = luckyshare.BytesToAddress([]byte("to"))
+)
+
+func TestDecode(t *testing.T) {
+	registry := abis.New(false)
+
+	transferEvent, _ := sharer.Energy.ABI.EventByName("Transfer")
+	data, err := transferEvent.Encode(big.NewInt(100))
+	assert.Nil(t, err)
+	topics := []luckyshare.Bytes32{
+		transferEvent.ID(),
+		luckyshare.BytesToBytes32(from[:]),
+		luckyshare.BytesToBytes32(to[:]),
+	}
+
+	// built in
+	decoded := registry.Decode(nil, sharer.Energy.Address, topics, data)
+	assert.Equal(t, &abis.DecodedEvent{
+		Name: "Transfer",
+		Args: map[string]interface{}{
+			"_from":  from,
+			"_to":    to,
+			"_value": (*math.HexOrDecimal256)(big.NewInt(100)),
+		},
+	}, decoded)
+
+	// unknown contract
+	assert.Nil(t, registry.Decode(nil, token, topics, data))
+
+	assert.Nil(t, registry.Register(token, []byte(tokenABI)))
+	assert.NotNil(t, registry.Decode(nil, token, topics, data))
+
+	// insufficient topics
+	assert.Nil(t, registry.Decode(nil, token, topics[:2], data))
+
+	registry.Unregister(token)
+	assert.Nil(t, registry.Decode(nil, token, topics, data))
+}
+
+func TestResolveTopics(t *testing.T) {
+	registry := abis.New(false)
+
+	_, found := registry.EventByName(nil, &token, "Transfer")
+	assert.False(t, found)
+
+	assert.Nil(t, registry.Register(token, []byte(tokenABI)))
+	ev, found := registry.EventByName(nil, &token, "Transfer")
+	assert.True(t, found)
+
+	var topics [5]*luckyshare.Bytes32
+	assert.Nil(t, abis.ResolveTopics(&topics, ev, map[string]string{"_to": to.String()}))
+	id := ev.ID()
+	toTopic := luckyshare.BytesToBytes32(to[:])
+	assert.Equal(t, [5]*luckyshare.Bytes32{&id, nil, &toTopic}, topics)
+
+	assert.NotNil(t, abis.ResolveTopics(&topics, ev, map[string]string{"_to": from.String()}), "conflicts")
+	assert.NotNil(t, abis.ResolveTopics(&topics, ev, map[string]string{"_value": "1"}), "not indexed")
+
+	// prototype events found for any address
+	_, found = registry.EventByName(nil, &token, "$User")
+	assert.True(t, found)
+}
+
+func TestHandlers(t *testing.T) {
+	router := mux.NewRouter()
+	abis.New(true).Mount(router, "/abis")
+	abis.New(false).Mount(router, "/readonly")
+	ts := httptest.NewServer(router)
+	defer ts.Close()
+
+	do := func(method, url string, body []byte) ([]byte, int) {
+		req, err := http.NewRequest(method, ts.URL+url, bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		r, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r, res.StatusCode
+	}
+
+	_, statusCode := do(http.MethodGet, "/abis/"+token.String(), nil)
+	assert.Equal(t, http.StatusNotFound, statusCode)
+
+	_, statusCode = do(http.MethodPut, "/abis/"+token.String(), []byte("invalid"))
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+
+	_, statusCode = do(http.MethodPut, "/abis/"+token.String(), []byte(tokenABI))
+	assert.Equal(t, http.StatusOK, statusCode)
+
+	res, statusCode := do(http.MethodGet, "/abis/"+token.String(), nil)
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, tokenABI, string(res))
+
+	_, statusCode = do(http.MethodDelete, "/abis/"+token.String(), nil)
+	assert.Equal(t, http.StatusOK, statusCode)
+
+	_, statusCode = do(http.MethodGet, "/abis/"+token.String(), nil)
+	assert.Equal(t, http.StatusNotFound, statusCode)
+
+	// not writable
+	_, statusCode = do(http.MethodPut, "/readonly/"+token.String(), []byte(tokenABI))
+	assert.Equal(t, http.StatusMethodNotAllowed, statusCode)
+	_, statusCode = do(http.MethodDelete, "/readonly/"+token.String(), nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, statusCode)
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package abis
+
+import (
+	"math/big"
+	"reflect"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/miniBamboo/luckyshare/abi"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+)
+
+// DecodedEvent event decoded with ABI.
+type DecodedEvent struct {
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args"`
+}
+
+func newDecodedEvent(ev *abi.Event, values []interface{}) *DecodedEvent {
+	decoded := &DecodedEvent{
+		Name: ev.Name(),
+		Args: make(map[string]interface{}, len(values)),
+	}
+	for i, arg := range ev.Args() {
+		name := arg.Name
+		if name == "" {
+			// unnamed args are keyed by position
+			name = strconv.Itoa(i)
+		}
+		decoded.Args[name] = formatValue(values[i])
+	}
+	return decoded
+}
+
+// formatValue converts decoded value into json friendly form.
+// Numbers beyond 64 bits and bytes are presented with hex string.
+func formatValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *big.Int:
+		return (*math.HexOrDecimal256)(v)
+	case common.Address:
+		return luckyshare.Address(v)
+	case luckyshare.Bytes32:
+		return v
+	case []byte:
+		return hexutil.Bytes(v)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			// fixed bytes
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return hexutil.Bytes(b)
+		}
+		fallthrough
+	case reflect.Slice:
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = formatValue(rv.Index(i).Interface())
+		}
+		return list
+	case reflect.Struct:
+		// tuple
+		fields := make(map[string]interface{}, rv.NumField())
+		for i := 0; i < rv.NumField(); i++ {
+			fields[rv.Type().Field(i).Name] = formatValue(rv.Field(i).Interface())
+		}
+		return fields
+	}
+	return v
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/miniBamboo/luckyshare/api/utils"
 	"github.com/miniBamboo/luckyshare/block"
 	"github.com/miniBamboo/luckyshare/chain"
+	"github.com/miniBamboo/luckyshare/logdb"
 	"github.com/miniBamboo/luckyshare/luckyshare"
 	"github.com/miniBamboo/luckyshare/runtime"
 	"github.com/miniBamboo/luckyshare/state"
@@ -31,6 +32,8 @@ type Accounts struct {
 	stater       *state.Stater
 	callGasLimit uint64
 	forkConfig   luckyshare.ForkConfig
+	logDB        *logdb.LogDB
+	skipLogs     bool
 }
 
 func New(
@@ -38,12 +41,16 @@ func New(
 	stater *state.Stater,
 	callGasLimit uint64,
 	forkConfig luckyshare.ForkConfig,
+	logDB *logdb.LogDB,
+	skipLogs bool,
 ) *Accounts {
 	return &Accounts{
 		repo,
 		stater,
 		callGasLimit,
 		forkConfig,
+		logDB,
+		skipLogs,
 	}
 }
 
@@ -201,6 +208,10 @@ func (a *Accounts) batchCall(ctx context.Context, batchCallData *BatchCallData,
 		return nil, err
 	}
 	state := a.stater.NewState(header.StateRoot())
+	// apply the before process hook as the next block does, so that calls see the same base gas price
+	if err := runtime.UpdateBaseGasPrice(state, header, a.forkConfig); err != nil {
+		return nil, err
+	}
 
 	signer, _ := header.Signer()
 	rt := runtime.New(a.repo.NewChain(header.ParentID()), state,
@@ -215,8 +226,9 @@ func (a *Accounts) batchCall(ctx context.Context, batchCallData *BatchCallData,
 		a.forkConfig)
 	results = make(BatchCallResults, 0)
 	resultCh := make(chan interface{}, 1)
+	prepare := rt.PrepareClauses(txCtx)
 	for i, clause := range clauses {
-		exec, interrupt := rt.PrepareClause(clause, uint32(i), gas, txCtx)
+		exec, interrupt := prepare(clause, uint32(i), gas)
 		go func() {
 			out, _, err := exec()
 			if err != nil {
@@ -353,6 +365,15 @@ func (a *Accounts) Mount(root *mux.Router, pathPrefix string) {
 	sub.Path("/{address}").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetAccount))
 	sub.Path("/{address}/code").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetCode))
 	sub.Path("/{address}/storage/{key}").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(a.handleGetStorage))
+	sub.Path("/{address}/prototype").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetPrototype))
+	if !a.skipLogs {
+		// users and sponsors are enumerated from logs
+		sub.Path("/{address}/prototype/users").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetPrototypeUsers))
+		sub.Path("/{address}/prototype/sponsors").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetPrototypeSponsors))
+	}
+	sub.Path("/{address}/tokens").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetTokens))
+	sub.Path("/{address}/tokens/transfers").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetTokenTransfers))
+	sub.Path("/{address}/transactions").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(a.handleGetTransactions))
 	sub.Path("").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(a.handleCallContract))
 	sub.Path("/{address}").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(a.handleCallContract))
 
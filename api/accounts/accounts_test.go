@@ -24,9 +24,11 @@ import (
 	"github.com/miniBamboo/luckyshare/api/accounts"
 	"github.com/miniBamboo/luckyshare/chain"
 	"github.com/miniBamboo/luckyshare/genesis"
+	"github.com/miniBamboo/luckyshare/logdb"
 	"github.com/miniBamboo/luckyshare/luckyshare"
 	"github.com/miniBamboo/luckyshare/muxdb"
 	"github.com/miniBamboo/luckyshare/packer"
+	"github.com/miniBamboo/luckyshare/sharer"
 	"github.com/miniBamboo/luckyshare/state"
 	"github.com/miniBamboo/luckyshare/tx"
 	"github.com/stretchr/testify/assert"
@@ -108,6 +110,7 @@ func TestAccount(t *testing.T) {
 	deployContractWithCall(t)
 	callContract(t)
 	batchCall(t)
+	getPrototype(t)
 }
 
 func getAccount(t *testing.T) {
@@ -183,11 +186,15 @@ func initAccountServer(t *testing.T) {
 		t.Fatal(err)
 	}
 	repo, _ := chain.NewRepository(db, b)
+	logDB, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
 	claTransfer := tx.NewClause(&addr).WithValue(value)
 	claDeploy := tx.NewClause(nil).WithData(bytecode)
 	transaction := buildTxWithClauses(t, repo.ChainTag(), claTransfer, claDeploy)
 	contractAddr = luckyshare.CreateContractAddress(transaction.ID(), 1, 0)
-	packTx(repo, stater, transaction, t)
+	packTx(repo, stater, logDB, transaction, t)
 
 	method := "set"
 	abi, err := ABI.New([]byte(abiJSON))
@@ -198,10 +205,30 @@ func initAccountServer(t *testing.T) {
 	}
 	claCall := tx.NewClause(&contractAddr).WithData(input)
 	transactionCall := buildTxWithClauses(t, repo.ChainTag(), claCall)
-	packTx(repo, stater, transactionCall, t)
+	packTx(repo, stater, logDB, transactionCall, t)
+
+	// the deployer is the master of contract
+	var protoClauses []*tx.Clause
+	for _, c := range []struct {
+		method string
+		args   []interface{}
+	}{
+		{"setCreditPlan", []interface{}{contractAddr, big.NewInt(1000), big.NewInt(10)}},
+		{"addUser", []interface{}{contractAddr, addr}},
+		{"sponsor", []interface{}{contractAddr}},
+		{"selectSponsor", []interface{}{contractAddr, genesis.DevAccounts()[0].Address}},
+	} {
+		m, _ := sharer.Prototype.ABI.MethodByName(c.method)
+		input, err := m.EncodeInput(c.args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		protoClauses = append(protoClauses, tx.NewClause(&sharer.Prototype.Address).WithData(input))
+	}
+	packTx(repo, stater, logDB, buildTxWithClauses(t, repo.ChainTag(), protoClauses...), t)
 
 	router := mux.NewRouter()
-	accounts.New(repo, stater, math.MaxUint64, luckyshare.NoFork).Mount(router, "/accounts")
+	accounts.New(repo, stater, math.MaxUint64, luckyshare.NoFork, logDB, false).Mount(router, "/accounts")
 	ts = httptest.NewServer(router)
 }
 
@@ -222,7 +249,7 @@ func buildTxWithClauses(t *testing.T, chaiTag byte, clauses ...*tx.Clause) *tx.T
 	return transaction.WithSignature(sig)
 }
 
-func packTx(repo *chain.Repository, stater *state.Stater, transaction *tx.Transaction, t *testing.T) {
+func packTx(repo *chain.Repository, stater *state.Stater, logDB *logdb.LogDB, transaction *tx.Transaction, t *testing.T) {
 	b := repo.BestBlock()
 	packer := packer.New(repo, stater, genesis.DevAccounts()[0].Address, &genesis.DevAccounts()[0].Address, luckyshare.NoFork)
 	flow, err := packer.Schedule(b.Header(), uint64(time.Now().Unix()))
@@ -243,6 +270,11 @@ func packTx(repo *chain.Repository, stater *state.Stater, transaction *tx.Transa
 	if err := repo.SetBestBlockID(b.Header().ID()); err != nil {
 		t.Fatal(err)
 	}
+	if err := logDB.Log(func(w *logdb.Writer) error {
+		return w.Write(b, receipts)
+	}); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func deployContractWithCall(t *testing.T) {
@@ -271,6 +303,66 @@ func deployContractWithCall(t *testing.T) {
 
 }
 
+func getPrototype(t *testing.T) {
+	_, statusCode := httpGet(t, ts.URL+"/accounts/"+invalidAddr+"/prototype")
+	assert.Equal(t, http.StatusBadRequest, statusCode, "bad address")
+
+	_, statusCode = httpGet(t, ts.URL+"/accounts/"+contractAddr.String()+"/prototype?user="+invalidAddr)
+	assert.Equal(t, http.StatusBadRequest, statusCode, "bad user")
+
+	res, statusCode := httpGet(t, ts.URL+"/accounts/"+contractAddr.String()+"/prototype?user="+addr.String())
+	assert.Equal(t, http.StatusOK, statusCode)
+	var proto accounts.Prototype
+	if err := json.Unmarshal(res, &proto); err != nil {
+		t.Fatal(err)
+	}
+	master := genesis.DevAccounts()[0].Address
+	assert.Equal(t, &master, proto.Master)
+	assert.Equal(t, (*math.HexOrDecimal256)(big.NewInt(1000)), proto.CreditPlan.Credit)
+	assert.Equal(t, (*math.HexOrDecimal256)(big.NewInt(10)), proto.CreditPlan.RecoveryRate)
+	assert.Equal(t, &master, proto.CurrentSponsor)
+	assert.Equal(t, []luckyshare.Address{master}, proto.Sponsors)
+	assert.Equal(t, &accounts.PrototypeUser{Address: addr, IsUser: true, Credit: (*math.HexOrDecimal256)(big.NewInt(1000))}, proto.User)
+
+	// before prototype settings
+	res, statusCode = httpGet(t, ts.URL+"/accounts/"+contractAddr.String()+"/prototype?revision=1")
+	assert.Equal(t, http.StatusOK, statusCode)
+	proto = accounts.Prototype{}
+	if err := json.Unmarshal(res, &proto); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, proto.CurrentSponsor)
+	assert.Empty(t, proto.Sponsors)
+	assert.Nil(t, proto.User)
+
+	res, statusCode = httpGet(t, ts.URL+"/accounts/"+contractAddr.String()+"/prototype/users")
+	assert.Equal(t, http.StatusOK, statusCode)
+	var users []*accounts.PrototypeUser
+	if err := json.Unmarshal(res, &users); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*accounts.PrototypeUser{{Address: addr, IsUser: true, Credit: (*math.HexOrDecimal256)(big.NewInt(1000))}}, users)
+
+	res, statusCode = httpGet(t, ts.URL+"/accounts/"+contractAddr.String()+"/prototype/users?offset=1")
+	assert.Equal(t, http.StatusOK, statusCode)
+	users = nil
+	if err := json.Unmarshal(res, &users); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, users)
+
+	_, statusCode = httpGet(t, ts.URL+"/accounts/"+contractAddr.String()+"/prototype/users?limit=1001")
+	assert.Equal(t, http.StatusBadRequest, statusCode, "limit exceeded")
+
+	res, statusCode = httpGet(t, ts.URL+"/accounts/"+contractAddr.String()+"/prototype/sponsors?limit=1")
+	assert.Equal(t, http.StatusOK, statusCode)
+	var sponsors []luckyshare.Address
+	if err := json.Unmarshal(res, &sponsors); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []luckyshare.Address{master}, sponsors)
+}
+
 func callContract(t *testing.T) {
 	res, statusCode := httpPost(t, ts.URL+"/accounts/"+invalidAddr, nil)
 	assert.Equal(t, http.StatusBadRequest, statusCode, "invalid address")
@@ -0,0 +1,231 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package accounts
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/api/utils"
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/logdb"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/sharer/prototype"
+	"github.com/pkg/errors"
+)
+
+var (
+	prototypeUserEvent, _    = sharer.Prototype.Events().EventByName("$User")
+	prototypeSponsorEvent, _ = sharer.Prototype.Events().EventByName("$Sponsor")
+)
+
+// bounds the scan of prototype logs for users or sponsors of an account.
+const maxPrototypeLogs = 10000
+
+// prototypeCandidates returns addresses indexed by prototype events of the given type emitted for self
+// up to the given block, in the order of first appearance.
+// Users and sponsors are stored under hashed keys, so the events are the only way to enumerate them,
+// and each candidate should be verified against the state. Only the first maxPrototypeLogs events are scanned.
+func (a *Accounts) prototypeCandidates(ctx context.Context, self luckyshare.Address, eventID luckyshare.Bytes32, blockNum uint32) ([]luckyshare.Address, error) {
+	events, err := a.logDB.FilterEvents(ctx, &logdb.EventFilter{
+		CriteriaSet: []*logdb.EventCriteria{{
+			Address: &self,
+			Topics:  [5]*luckyshare.Bytes32{&eventID},
+		}},
+		Range:   &logdb.Range{From: 0, To: blockNum},
+		Options: &logdb.Options{Limit: maxPrototypeLogs},
+		Order:   logdb.ASC,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var (
+		candidates []luckyshare.Address
+		seen       = make(map[luckyshare.Address]bool)
+	)
+	for _, ev := range events {
+		if ev.Topics[1] == nil {
+			continue
+		}
+		addr := luckyshare.BytesToAddress(ev.Topics[1].Bytes())
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		candidates = append(candidates, addr)
+	}
+	return candidates, nil
+}
+
+func (a *Accounts) sponsors(ctx context.Context, binding *prototype.Binding, self luckyshare.Address, header *block.Header) ([]luckyshare.Address, error) {
+	candidates, err := a.prototypeCandidates(ctx, self, prototypeSponsorEvent.ID(), header.Number())
+	if err != nil {
+		return nil, err
+	}
+	sponsors := []luckyshare.Address{}
+	for _, addr := range candidates {
+		isSponsor, err := binding.IsSponsor(addr)
+		if err != nil {
+			return nil, err
+		}
+		if isSponsor {
+			sponsors = append(sponsors, addr)
+		}
+	}
+	return sponsors, nil
+}
+
+func (a *Accounts) users(ctx context.Context, binding *prototype.Binding, self luckyshare.Address, header *block.Header) ([]*PrototypeUser, error) {
+	candidates, err := a.prototypeCandidates(ctx, self, prototypeUserEvent.ID(), header.Number())
+	if err != nil {
+		return nil, err
+	}
+	users := []*PrototypeUser{}
+	for _, addr := range candidates {
+		user, err := getPrototypeUser(binding, addr, header)
+		if err != nil {
+			return nil, err
+		}
+		if user.IsUser {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func getPrototypeUser(binding *prototype.Binding, addr luckyshare.Address, header *block.Header) (*PrototypeUser, error) {
+	isUser, err := binding.IsUser(addr)
+	if err != nil {
+		return nil, err
+	}
+	credit, err := binding.UserCredit(addr, header.Timestamp())
+	if err != nil {
+		return nil, err
+	}
+	return &PrototypeUser{
+		Address: addr,
+		IsUser:  isUser,
+		Credit:  (*math.HexOrDecimal256)(credit),
+	}, nil
+}
+
+func (a *Accounts) handleGetPrototype(w http.ResponseWriter, req *http.Request) error {
+	self, err := luckyshare.ParseAddress(mux.Vars(req)["address"])
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "address"))
+	}
+	var user *luckyshare.Address
+	if s := req.URL.Query().Get("user"); s != "" {
+		addr, err := luckyshare.ParseAddress(s)
+		if err != nil {
+			return utils.BadRequest(errors.WithMessage(err, "user"))
+		}
+		user = &addr
+	}
+	h, err := a.handleRevision(req.URL.Query().Get("revision"))
+	if err != nil {
+		return err
+	}
+
+	state := a.stater.NewState(h.StateRoot())
+	binding := sharer.Prototype.Native(state).Bind(self)
+
+	master, err := state.GetMaster(self)
+	if err != nil {
+		return err
+	}
+	credit, recoveryRate, err := binding.CreditPlan()
+	if err != nil {
+		return err
+	}
+	currentSponsor, err := binding.CurrentSponsor()
+	if err != nil {
+		return err
+	}
+
+	proto := &Prototype{
+		CreditPlan: CreditPlan{
+			Credit:       (*math.HexOrDecimal256)(credit),
+			RecoveryRate: (*math.HexOrDecimal256)(recoveryRate),
+		},
+	}
+	// sponsors are enumerated from logs
+	if !a.skipLogs {
+		if proto.Sponsors, err = a.sponsors(req.Context(), binding, self, h); err != nil {
+			return err
+		}
+	}
+	if !master.IsZero() {
+		proto.Master = &master
+	}
+	if !currentSponsor.IsZero() {
+		proto.CurrentSponsor = &currentSponsor
+	}
+	if user != nil {
+		if proto.User, err = getPrototypeUser(binding, *user, h); err != nil {
+			return err
+		}
+	}
+	return utils.WriteJSON(w, proto)
+}
+
+func (a *Accounts) handleGetPrototypeUsers(w http.ResponseWriter, req *http.Request) error {
+	self, err := luckyshare.ParseAddress(mux.Vars(req)["address"])
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "address"))
+	}
+	offset, limit, err := utils.ParsePage(req)
+	if err != nil {
+		return err
+	}
+	h, err := a.handleRevision(req.URL.Query().Get("revision"))
+	if err != nil {
+		return err
+	}
+	binding := sharer.Prototype.Native(a.stater.NewState(h.StateRoot())).Bind(self)
+	users, err := a.users(req.Context(), binding, self, h)
+	if err != nil {
+		return err
+	}
+	from, to := pageBounds(len(users), offset, limit)
+	return utils.WriteJSON(w, users[from:to])
+}
+
+func (a *Accounts) handleGetPrototypeSponsors(w http.ResponseWriter, req *http.Request) error {
+	self, err := luckyshare.ParseAddress(mux.Vars(req)["address"])
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "address"))
+	}
+	offset, limit, err := utils.ParsePage(req)
+	if err != nil {
+		return err
+	}
+	h, err := a.handleRevision(req.URL.Query().Get("revision"))
+	if err != nil {
+		return err
+	}
+	binding := sharer.Prototype.Native(a.stater.NewState(h.StateRoot())).Bind(self)
+	sponsors, err := a.sponsors(req.Context(), binding, self, h)
+	if err != nil {
+		return err
+	}
+	from, to := pageBounds(len(sponsors), offset, limit)
+	return utils.WriteJSON(w, sponsors[from:to])
+}
+
+func pageBounds(n int, offset, limit uint64) (int, int) {
+	if offset >= uint64(n) {
+		return n, n
+	}
+	to := offset + limit
+	if to < offset || to > uint64(n) {
+		to = uint64(n)
+	}
+	return int(offset), int(to)
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package accounts
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/api/events"
+	"github.com/miniBamboo/luckyshare/api/tokens"
+	"github.com/miniBamboo/luckyshare/api/utils"
+	"github.com/miniBamboo/luckyshare/logdb"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/pkg/errors"
+)
+
+func (a *Accounts) handleGetTokens(w http.ResponseWriter, req *http.Request) error {
+	holder, err := luckyshare.ParseAddress(mux.Vars(req)["address"])
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "address"))
+	}
+	offset, limit, err := utils.ParsePage(req)
+	if err != nil {
+		return err
+	}
+	balances, err := a.logDB.HolderTokens(req.Context(), holder, offset, limit)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, tokens.ConvertBalances(balances))
+}
+
+func (a *Accounts) handleGetTokenTransfers(w http.ResponseWriter, req *http.Request) error {
+	holder, err := luckyshare.ParseAddress(mux.Vars(req)["address"])
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "address"))
+	}
+	var token *luckyshare.Address
+	if s := req.URL.Query().Get("token"); s != "" {
+		addr, err := luckyshare.ParseAddress(s)
+		if err != nil {
+			return utils.BadRequest(errors.WithMessage(err, "token"))
+		}
+		token = &addr
+	}
+	options, order, err := events.ParseOptions(req)
+	if err != nil {
+		return err
+	}
+	transfers, err := a.logDB.FilterTokenTransfers(req.Context(), &logdb.TokenTransferFilter{
+		CriteriaSet: tokens.HolderCriteriaSet(token, holder),
+		Options:     options,
+		Order:       order,
+	})
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, tokens.ConvertTransfers(transfers))
+}
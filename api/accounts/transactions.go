@@ -0,0 +1,103 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package accounts
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/api/events"
+	"github.com/miniBamboo/luckyshare/api/transactions"
+	"github.com/miniBamboo/luckyshare/api/utils"
+	"github.com/miniBamboo/luckyshare/logdb"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/pkg/errors"
+)
+
+// directions of transactions to an account, in the order of activity flags.
+var txDirections = []struct {
+	name string
+	flag uint32
+}{
+	{"sent", logdb.ActivityOrigin},
+	{"sponsored", logdb.ActivityGasPayer},
+	{"received", logdb.ActivityRecipient},
+}
+
+// parseDirections parses comma separated directions into activity flags.
+func parseDirections(s string) (flags uint32, err error) {
+	for _, name := range splitAndTrim(s) {
+		found := false
+		for _, d := range txDirections {
+			if d.name == name {
+				flags |= d.flag
+				found = true
+			}
+		}
+		if !found {
+			return 0, errors.New("unknown direction: " + name)
+		}
+	}
+	return flags, nil
+}
+
+func convertDirections(flags uint32) []string {
+	directions := []string{}
+	for _, d := range txDirections {
+		if flags&d.flag != 0 {
+			directions = append(directions, d.name)
+		}
+	}
+	return directions
+}
+
+func splitAndTrim(s string) (parts []string) {
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return
+}
+
+func (a *Accounts) handleGetTransactions(w http.ResponseWriter, req *http.Request) error {
+	addr, err := luckyshare.ParseAddress(mux.Vars(req)["address"])
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "address"))
+	}
+	flags, err := parseDirections(req.URL.Query().Get("direction"))
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "direction"))
+	}
+	options, order, err := events.ParseOptions(req)
+	if err != nil {
+		return err
+	}
+	activities, err := a.logDB.FilterActivities(req.Context(), &logdb.ActivityFilter{
+		Address: addr,
+		Flags:   flags,
+		Options: options,
+		Order:   order,
+	})
+	if err != nil {
+		return err
+	}
+	txs := make([]*AccountTx, len(activities))
+	for i, act := range activities {
+		txs[i] = &AccountTx{
+			TxID:       act.TxID,
+			Directions: convertDirections(act.Flags),
+			Meta: transactions.TxMeta{
+				BlockID:        act.BlockID,
+				BlockNumber:    act.BlockNumber,
+				BlockTimestamp: act.BlockTime,
+			},
+			Cursor: act.Cursor(),
+		}
+	}
+	return utils.WriteJSON(w, txs)
+}
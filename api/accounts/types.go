@@ -9,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/miniBamboo/luckyshare/api/transactions"
+	"github.com/miniBamboo/luckyshare/logdb"
 	"github.com/miniBamboo/luckyshare/luckyshare"
 	"github.com/miniBamboo/luckyshare/runtime"
 )
@@ -105,3 +106,33 @@ type BatchCallData struct {
 }
 
 type BatchCallResults []*CallResult
+
+//Prototype prototype properties of an account
+type Prototype struct {
+	Master         *luckyshare.Address  `json:"master"`
+	CreditPlan     CreditPlan           `json:"creditPlan"`
+	CurrentSponsor *luckyshare.Address  `json:"currentSponsor"`
+	Sponsors       []luckyshare.Address `json:"sponsors,omitempty"`
+	User           *PrototypeUser       `json:"user,omitempty"`
+}
+
+//CreditPlan credit plan for users of an account
+type CreditPlan struct {
+	Credit       *math.HexOrDecimal256 `json:"credit"`
+	RecoveryRate *math.HexOrDecimal256 `json:"recoveryRate"`
+}
+
+//PrototypeUser a user of an account with its remaining credit
+type PrototypeUser struct {
+	Address luckyshare.Address    `json:"address"`
+	IsUser  bool                  `json:"isUser"`
+	Credit  *math.HexOrDecimal256 `json:"credit"`
+}
+
+//AccountTx a transaction related to an account
+type AccountTx struct {
+	TxID       luckyshare.Bytes32  `json:"txID"`
+	Directions []string            `json:"directions"`
+	Meta       transactions.TxMeta `json:"meta"`
+	Cursor     *logdb.Cursor       `json:"cursor"`
+}
@@ -13,13 +13,18 @@ import (
 	assetfs "github.com/elazarl/go-bindata-assetfs"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/api/abis"
 	"github.com/miniBamboo/luckyshare/api/accounts"
 	"github.com/miniBamboo/luckyshare/api/blocks"
+	"github.com/miniBamboo/luckyshare/api/calls"
 	"github.com/miniBamboo/luckyshare/api/debug"
 	"github.com/miniBamboo/luckyshare/api/doc"
 	"github.com/miniBamboo/luckyshare/api/events"
+	"github.com/miniBamboo/luckyshare/api/fees"
+	"github.com/miniBamboo/luckyshare/api/governance"
 	"github.com/miniBamboo/luckyshare/api/node"
 	"github.com/miniBamboo/luckyshare/api/subscriptions"
+	"github.com/miniBamboo/luckyshare/api/tokens"
 	"github.com/miniBamboo/luckyshare/api/transactions"
 	"github.com/miniBamboo/luckyshare/api/transfers"
 	"github.com/miniBamboo/luckyshare/chain"
@@ -29,7 +34,7 @@ import (
 	"github.com/miniBamboo/luckyshare/txpool"
 )
 
-//New return api router
+// New return api router
 func New(
 	repo *chain.Repository,
 	stater *state.Stater,
@@ -41,6 +46,7 @@ func New(
 	callGasLimit uint64,
 	pprofOn bool,
 	skipLogs bool,
+	abisWritable bool,
 	forkConfig luckyshare.ForkConfig,
 ) (http.HandlerFunc, func()) {
 
@@ -65,14 +71,21 @@ func New(
 			http.Redirect(w, req, "doc/swagger-ui/", http.StatusTemporaryRedirect)
 		})
 
-	accounts.New(repo, stater, callGasLimit, forkConfig).
+	registry := abis.New(abisWritable)
+	registry.Mount(router, "/abis")
+
+	accounts.New(repo, stater, callGasLimit, forkConfig, logDB, skipLogs).
 		Mount(router, "/accounts")
 
 	if !skipLogs {
-		events.New(repo, logDB).
+		events.New(repo, logDB, registry).
 			Mount(router, "/logs/event")
 		transfers.New(repo, logDB).
 			Mount(router, "/logs/transfer")
+		calls.New(repo, logDB).
+			Mount(router, "/logs/calls")
+		tokens.New(logDB).
+			Mount(router, "/tokens")
 	}
 	blocks.New(repo).
 		Mount(router, "/blocks")
@@ -80,9 +93,15 @@ func New(
 		Mount(router, "/transactions")
 	debug.New(repo, stater, forkConfig).
 		Mount(router, "/debug")
-	node.New(nw).
+	fees.New(repo, stater, txPool, forkConfig).
+		Mount(router, "/fees")
+	governance.New(repo, stater, logDB, skipLogs).
+		Mount(router, "/governance")
+	governance.NewAuthority(repo, stater).
+		Mount(router, "/authority")
+	node.New(nw, txPool).
 		Mount(router, "/node")
-	subs := subscriptions.New(repo, origins, backtraceLimit)
+	subs := subscriptions.New(repo, origins, backtraceLimit, registry, txPool, stater, forkConfig)
 	subs.Mount(router, "/subscriptions")
 
 	if pprofOn {
@@ -96,7 +115,8 @@ func New(
 	handler := handlers.CompressHandler(router)
 	handler = handlers.CORS(
 		handlers.AllowedOrigins(origins),
-		handlers.AllowedHeaders([]string{"content-type", "x-genesis-id"}),
+		handlers.AllowedHeaders([]string{"content-type", "x-genesis-id", "last-event-id"}),
+		handlers.AllowedMethods([]string{http.MethodGet, http.MethodHead, http.MethodPost}),
 		handlers.ExposedHeaders([]string{"x-genesis-id", "x-thorest-ver"}),
 	)(handler)
 	return handler.ServeHTTP,
@@ -140,6 +140,8 @@ func buildJSONOutput(txID luckyshare.Bytes32, index uint32, c *tx.Clause, o *tx.
 
 func buildJSONEmbeddedTxs(txs tx.Transactions, receipts tx.Receipts) []*JSONEmbeddedTx {
 	jTxs := make([]*JSONEmbeddedTx, 0, len(txs))
+	// receipts of scheduled executions come first
+	receipts = receipts[len(receipts)-len(txs):]
 	for itx, tx := range txs {
 		receipt := receipts[itx]
 
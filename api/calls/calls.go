@@ -0,0 +1,109 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package calls
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/api/events"
+	"github.com/miniBamboo/luckyshare/api/utils"
+	"github.com/miniBamboo/luckyshare/chain"
+	"github.com/miniBamboo/luckyshare/logdb"
+	"github.com/pkg/errors"
+)
+
+type Calls struct {
+	repo *chain.Repository
+	db   *logdb.LogDB
+}
+
+func New(repo *chain.Repository, db *logdb.LogDB) *Calls {
+	return &Calls{
+		repo,
+		db,
+	}
+}
+
+func (c *Calls) convertFilter(filter *CallFilter) (*logdb.CallFilter, error) {
+	rng, err := events.ConvertRange(c.repo.NewBestChain(), filter.Range)
+	if err != nil {
+		return nil, err
+	}
+	f := &logdb.CallFilter{
+		Range:   rng,
+		Options: filter.Options,
+		Order:   filter.Order,
+	}
+	for i, criteria := range filter.CriteriaSet {
+		if criteria == nil {
+			return nil, utils.BadRequest(fmt.Errorf("criteriaSet[%v]: null", i))
+		}
+		cc := &logdb.CallCriteria{
+			Caller: criteria.Caller,
+			Callee: criteria.Callee,
+		}
+		if criteria.Selector != nil {
+			if len(*criteria.Selector) != 4 {
+				return nil, utils.BadRequest(fmt.Errorf("criteriaSet[%v].selector: should be 4 bytes", i))
+			}
+			cc.Selector = *criteria.Selector
+		}
+		f.CriteriaSet = append(f.CriteriaSet, cc)
+	}
+	return f, nil
+}
+
+// filter queries calls with option
+func (c *Calls) filter(ctx context.Context, filter *CallFilter) ([]*FilteredCall, error) {
+	f, err := c.convertFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	calls, err := c.db.FilterCalls(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	fcs := make([]*FilteredCall, len(calls))
+	for i, call := range calls {
+		fcs[i] = convertCall(call)
+	}
+	return fcs, nil
+}
+
+func (c *Calls) handleFilterCalls(w http.ResponseWriter, req *http.Request) error {
+	var filter CallFilter
+	if err := utils.ParseJSON(req.Body, &filter); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	if count, err := events.ParseCount(req); err != nil {
+		return err
+	} else if count {
+		f, err := c.convertFilter(&filter)
+		if err != nil {
+			return err
+		}
+		n, err := c.db.CountCalls(req.Context(), f)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJSON(w, &events.LogCount{Count: n})
+	}
+	fcs, err := c.filter(req.Context(), &filter)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, fcs)
+}
+
+func (c *Calls) Mount(root *mux.Router, pathPrefix string) {
+	sub := root.PathPrefix(pathPrefix).Subrouter()
+
+	sub.Path("").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(c.handleFilterCalls))
+}
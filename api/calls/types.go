@@ -0,0 +1,67 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package calls
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/miniBamboo/luckyshare/api/events"
+	"github.com/miniBamboo/luckyshare/logdb"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+)
+
+// FilteredCall is a traced message call or contract creation.
+type FilteredCall struct {
+	Type     string                `json:"type"`
+	Depth    uint32                `json:"depth"`
+	Caller   luckyshare.Address    `json:"caller"`
+	Callee   luckyshare.Address    `json:"callee"`
+	Selector *hexutil.Bytes        `json:"selector"`
+	Value    *math.HexOrDecimal256 `json:"value"`
+	Reverted bool                  `json:"reverted"`
+	Meta     events.LogMeta        `json:"meta"`
+	Cursor   *logdb.Cursor         `json:"cursor"`
+}
+
+func convertCall(call *logdb.Call) *FilteredCall {
+	v := math.HexOrDecimal256(*call.Value)
+	fc := &FilteredCall{
+		Type:     call.Type,
+		Depth:    call.Depth,
+		Caller:   call.Caller,
+		Callee:   call.Callee,
+		Value:    &v,
+		Reverted: call.Reverted,
+		Meta: events.LogMeta{
+			BlockID:        call.BlockID,
+			BlockNumber:    call.BlockNumber,
+			BlockTimestamp: call.BlockTime,
+			TxID:           call.TxID,
+			TxOrigin:       call.TxOrigin,
+			ClauseIndex:    call.ClauseIndex,
+		},
+		Cursor: call.Cursor(),
+	}
+	if call.Selector != nil {
+		selector := hexutil.Bytes(call.Selector)
+		fc.Selector = &selector
+	}
+	return fc
+}
+
+// CallCriteria matches calls with each field, if present.
+type CallCriteria struct {
+	Caller   *luckyshare.Address `json:"caller"`
+	Callee   *luckyshare.Address `json:"callee"`
+	Selector *hexutil.Bytes      `json:"selector"`
+}
+
+type CallFilter struct {
+	CriteriaSet []*CallCriteria
+	Range       *events.Range
+	Options     *logdb.Options
+	Order       logdb.Order //default asc
+}
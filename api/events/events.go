@@ -8,8 +8,11 @@ package events
 import (
 	"context"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/abi"
+	"github.com/miniBamboo/luckyshare/api/abis"
 	"github.com/miniBamboo/luckyshare/api/utils"
 	"github.com/miniBamboo/luckyshare/chain"
 	"github.com/miniBamboo/luckyshare/logdb"
@@ -19,19 +22,36 @@ import (
 type Events struct {
 	repo *chain.Repository
 	db   *logdb.LogDB
+	abis *abis.ABIs
 }
 
-func New(repo *chain.Repository, db *logdb.LogDB) *Events {
+func New(repo *chain.Repository, db *logdb.LogDB, abis *abis.ABIs) *Events {
 	return &Events{
 		repo,
 		db,
+		abis,
 	}
 }
 
-//Filter query events with option
-func (e *Events) filter(ctx context.Context, ef *EventFilter) ([]*FilteredEvent, error) {
+func (e *Events) convertFilter(ef *EventFilter) (*logdb.EventFilter, *abi.ABI, error) {
+	var extra *abi.ABI
+	if len(ef.ABI) > 0 {
+		var err error
+		if extra, err = abi.New(ef.ABI); err != nil {
+			return nil, nil, utils.BadRequest(errors.WithMessage(err, "abi"))
+		}
+	}
 	chain := e.repo.NewBestChain()
-	filter, err := convertEventFilter(chain, ef)
+	filter, err := convertEventFilter(chain, ef, e.abis, extra)
+	if err != nil {
+		return nil, nil, err
+	}
+	return filter, extra, nil
+}
+
+// Filter query events with option
+func (e *Events) filter(ctx context.Context, ef *EventFilter) ([]*FilteredEvent, error) {
+	filter, extra, err := e.convertFilter(ef)
 	if err != nil {
 		return nil, err
 	}
@@ -40,8 +60,8 @@ func (e *Events) filter(ctx context.Context, ef *EventFilter) ([]*FilteredEvent,
 		return nil, err
 	}
 	fes := make([]*FilteredEvent, len(events))
-	for i, e := range events {
-		fes[i] = convertEvent(e)
+	for i, ev := range events {
+		fes[i] = convertEvent(ev, e.abis, extra)
 	}
 	return fes, nil
 }
@@ -51,6 +71,19 @@ func (e *Events) handleFilter(w http.ResponseWriter, req *http.Request) error {
 	if err := utils.ParseJSON(req.Body, &filter); err != nil {
 		return utils.BadRequest(errors.WithMessage(err, "body"))
 	}
+	if count, err := ParseCount(req); err != nil {
+		return err
+	} else if count {
+		f, _, err := e.convertFilter(&filter)
+		if err != nil {
+			return err
+		}
+		n, err := e.db.CountEvents(req.Context(), f)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJSON(w, &LogCount{Count: n})
+	}
 	fes, err := e.filter(req.Context(), &filter)
 	if err != nil {
 		return err
@@ -58,6 +91,44 @@ func (e *Events) handleFilter(w http.ResponseWriter, req *http.Request) error {
 	return utils.WriteJSON(w, fes)
 }
 
+// ParseCount parses the 'count' query param, which requests count of matched logs rather than logs.
+func ParseCount(req *http.Request) (bool, error) {
+	s := req.URL.Query().Get("count")
+	if s == "" {
+		return false, nil
+	}
+	count, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, utils.BadRequest(errors.WithMessage(err, "count"))
+	}
+	return count, nil
+}
+
+// ParseOptions parses the optional offset, limit, cursor and order query params of paged logs in GET requests.
+func ParseOptions(req *http.Request) (*logdb.Options, logdb.Order, error) {
+	offset, limit, err := utils.ParsePage(req)
+	if err != nil {
+		return nil, "", err
+	}
+	options := &logdb.Options{Offset: offset, Limit: limit}
+	if s := req.URL.Query().Get("cursor"); s != "" {
+		var cursor logdb.Cursor
+		if err := cursor.UnmarshalText([]byte(s)); err != nil {
+			return nil, "", utils.BadRequest(errors.WithMessage(err, "cursor"))
+		}
+		options.Cursor = &cursor
+	}
+	order := logdb.Order(req.URL.Query().Get("order"))
+	switch order {
+	case "":
+		order = logdb.ASC
+	case logdb.ASC, logdb.DESC:
+	default:
+		return nil, "", utils.BadRequest(errors.New("order: should be 'asc' or 'desc'"))
+	}
+	return options, order, nil
+}
+
 func (e *Events) Mount(root *mux.Router, pathPrefix string) {
 	sub := root.PathPrefix(pathPrefix).Subrouter()
 
@@ -6,10 +6,14 @@
 package events
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/miniBamboo/luckyshare/abi"
+	"github.com/miniBamboo/luckyshare/api/abis"
+	"github.com/miniBamboo/luckyshare/api/utils"
 	"github.com/miniBamboo/luckyshare/block"
 	"github.com/miniBamboo/luckyshare/chain"
 	"github.com/miniBamboo/luckyshare/logdb"
@@ -39,10 +43,17 @@ type FilteredEvent struct {
 	Topics  []*luckyshare.Bytes32 `json:"topics"`
 	Data    string                `json:"data"`
 	Meta    LogMeta               `json:"meta"`
+	Decoded *abis.DecodedEvent    `json:"decoded,omitempty"`
+	Cursor  *logdb.Cursor         `json:"cursor"`
 }
 
-//convert a logdb.Event into a json format Event
-func convertEvent(event *logdb.Event) *FilteredEvent {
+// LogCount count of logs matching the filter.
+type LogCount struct {
+	Count uint64 `json:"count"`
+}
+
+//convert a logdb.Event into a json format Event, which is decoded if its ABI found
+func convertEvent(event *logdb.Event, registry *abis.ABIs, extra *abi.ABI) *FilteredEvent {
 	fe := FilteredEvent{
 		Address: event.Address,
 		Data:    hexutil.Encode(event.Data),
@@ -54,13 +65,17 @@ func convertEvent(event *logdb.Event) *FilteredEvent {
 			TxOrigin:       event.TxOrigin,
 			ClauseIndex:    event.ClauseIndex,
 		},
+		Cursor: event.Cursor(),
 	}
 	fe.Topics = make([]*luckyshare.Bytes32, 0)
+	topics := make([]luckyshare.Bytes32, 0, 5)
 	for i := 0; i < 5; i++ {
 		if event.Topics[i] != nil {
 			fe.Topics = append(fe.Topics, event.Topics[i])
+			topics = append(topics, *event.Topics[i])
 		}
 	}
+	fe.Decoded = registry.Decode(extra, event.Address, topics, event.Data)
 	return &fe
 }
 
@@ -89,19 +104,29 @@ func (e *FilteredEvent) String() string {
 	)
 }
 
+// EventCriteria matches events by address and topics.
+// Addresses and TopicSets extend Address and topics with alternatives.
+// Event and Args, if present, are resolved to topics with the event ABI.
 type EventCriteria struct {
-	Address *luckyshare.Address `json:"address"`
+	Address   *luckyshare.Address     `json:"address"`
+	Addresses []luckyshare.Address    `json:"addresses,omitempty"`
+	TopicSets [5][]luckyshare.Bytes32 `json:"topicSets,omitempty"`
 	TopicSet
+	Event string            `json:"event,omitempty"`
+	Args  map[string]string `json:"args,omitempty"`
 }
 
+// EventFilter the ABI fragment, if present, is used to resolve criteria and decode events,
+// prior to ABIs registered with the node.
 type EventFilter struct {
 	CriteriaSet []*EventCriteria `json:"criteriaSet"`
 	Range       *Range           `json:"range"`
 	Options     *logdb.Options   `json:"options"`
 	Order       logdb.Order      `json:"order"`
+	ABI         json.RawMessage  `json:"abi,omitempty"`
 }
 
-func convertEventFilter(chain *chain.Chain, filter *EventFilter) (*logdb.EventFilter, error) {
+func convertEventFilter(chain *chain.Chain, filter *EventFilter, registry *abis.ABIs, extra *abi.ABI) (*logdb.EventFilter, error) {
 	rng, err := ConvertRange(chain, filter.Range)
 	if err != nil {
 		return nil, err
@@ -120,9 +145,27 @@ func convertEventFilter(chain *chain.Chain, filter *EventFilter) (*logdb.EventFi
 			topics[2] = criteria.Topic2
 			topics[3] = criteria.Topic3
 			topics[4] = criteria.Topic4
+			if criteria.Event != "" {
+				address := criteria.Address
+				if address == nil && len(criteria.Addresses) > 0 {
+					// contracts in the set are assumed to share the event
+					address = &criteria.Addresses[0]
+				}
+				ev, found := registry.EventByName(extra, address, criteria.Event)
+				if !found {
+					return nil, utils.BadRequest(fmt.Errorf("criteriaSet[%d]: event %s not found", i, criteria.Event))
+				}
+				if err := abis.ResolveTopics(&topics, ev, criteria.Args); err != nil {
+					return nil, utils.BadRequest(fmt.Errorf("criteriaSet[%d]: %v", i, err))
+				}
+			} else if len(criteria.Args) > 0 {
+				return nil, utils.BadRequest(fmt.Errorf("criteriaSet[%d]: args without event", i))
+			}
 			criteria := &logdb.EventCriteria{
-				Address: criteria.Address,
-				Topics:  topics,
+				Address:   criteria.Address,
+				Addresses: criteria.Addresses,
+				Topics:    topics,
+				TopicSets: criteria.TopicSets,
 			}
 			criterias[i] = criteria
 		}
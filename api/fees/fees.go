@@ -0,0 +1,306 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package fees
+
+import (
+	"math"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	ethmath "github.com/ethereum/go-ethereum/common/math"
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/api/utils"
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/chain"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/runtime"
+	"github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/miniBamboo/luckyshare/txpool"
+	"github.com/pkg/errors"
+)
+
+const (
+	maxHistoryBlocks     = 1024
+	defaultHistoryBlocks = 10
+
+	// count of recent blocks sampled to suggest priority
+	priorityBlocks = 20
+	// blocks with gas used ratio above it are considered busy
+	busyGasUsedRatio = 0.8
+)
+
+var defaultPercentiles = []float64{25, 50, 75}
+
+type Fees struct {
+	repo       *chain.Repository
+	stater     *state.Stater
+	txPool     *txpool.TxPool
+	forkConfig luckyshare.ForkConfig
+}
+
+func New(repo *chain.Repository, stater *state.Stater, txPool *txpool.TxPool, forkConfig luckyshare.ForkConfig) *Fees {
+	return &Fees{
+		repo,
+		stater,
+		txPool,
+		forkConfig,
+	}
+}
+
+func (f *Fees) handleGetHistory(w http.ResponseWriter, req *http.Request) error {
+	count, err := parseBlocks(req.URL.Query().Get("blocks"))
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "blocks"))
+	}
+	percentiles, err := parsePercentiles(req.URL.Query().Get("percentiles"))
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "percentiles"))
+	}
+
+	blocks, err := f.recentBlocks(count, percentiles)
+	if err != nil {
+		return err
+	}
+	history := &History{
+		OldestBlock: blocks[0].Number,
+		Percentiles: percentiles,
+		Blocks:      blocks,
+	}
+
+	// apply the before process hook of the next block, to get the base gas price as block execution does
+	best := f.repo.BestBlock().Header()
+	st := f.stater.NewState(best.StateRoot())
+	if err := runtime.UpdateBaseGasPrice(st, best, f.forkConfig); err == nil {
+		if next, err := runtime.BaseGasPrice(st); err == nil {
+			history.NextBaseGasPrice = (*ethmath.HexOrDecimal256)(next)
+		}
+	}
+	return utils.WriteJSON(w, history)
+}
+
+func (f *Fees) handleGetPriority(w http.ResponseWriter, req *http.Request) error {
+	blocks, err := f.recentBlocks(priorityBlocks, []float64{50})
+	if err != nil {
+		return err
+	}
+
+	var busy []int
+	for _, b := range blocks {
+		if b.GasUsedRatio >= busyGasUsedRatio {
+			busy = append(busy, int(b.GasPriceCoefs[0]))
+		}
+	}
+
+	var p Priority
+	if len(busy) > 0 {
+		sort.Ints(busy)
+		p.RecentCoef = uint8(busy[len(busy)/2])
+	}
+
+	gasLimit := f.repo.BestBlock().Header().GasLimit()
+	p.PendingCoef, p.PendingGas = pendingCoef(f.txPool.Executables(), gasLimit)
+
+	p.GasPriceCoef = p.RecentCoef
+	if p.PendingCoef > p.GasPriceCoef {
+		p.GasPriceCoef = p.PendingCoef
+	}
+	return utils.WriteJSON(w, &p)
+}
+
+// recentBlocks collects fees of at most count blocks back from the best block.
+func (f *Fees) recentBlocks(count int, percentiles []float64) ([]*BlockFees, error) {
+	best := f.repo.BestBlock().Header()
+	if uint32(count) > best.Number()+1 {
+		count = int(best.Number()) + 1
+	}
+
+	chain := f.repo.NewBestChain()
+	blocks := make([]*BlockFees, count)
+	for i := 0; i < count; i++ {
+		header, err := chain.GetBlockHeader(best.Number() - uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		b, err := f.blockFees(header, percentiles)
+		if err != nil {
+			return nil, err
+		}
+		blocks[count-1-i] = b
+	}
+	return blocks, nil
+}
+
+func (f *Fees) blockFees(header *block.Header, percentiles []float64) (*BlockFees, error) {
+	txs, err := f.repo.GetBlockTransactions(header.ID())
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := f.repo.GetBlockReceipts(header.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	b := &BlockFees{
+		ID:            header.ID(),
+		Number:        header.Number(),
+		Timestamp:     header.Timestamp(),
+		GasLimit:      header.GasLimit(),
+		GasUsed:       header.GasUsed(),
+		TxCount:       len(txs),
+		GasPriceCoefs: coefPercentiles(txs, receipts, percentiles),
+	}
+	if b.GasLimit > 0 {
+		b.GasUsedRatio = float64(b.GasUsed) / float64(b.GasLimit)
+	}
+
+	// the state may be unavailable once pruned, leave base gas price and burned null then
+	st := f.stater.NewState(header.StateRoot())
+	if bgp, err := runtime.BaseGasPrice(st); err == nil {
+		b.BaseGasPrice = (*ethmath.HexOrDecimal256)(bgp)
+	}
+	if burned, err := f.burned(header, st); err == nil {
+		b.Burned = (*ethmath.HexOrDecimal256)(burned)
+	}
+	return b, nil
+}
+
+// burned computes energy burned in the block, by diffing total burned against parent's state.
+func (f *Fees) burned(header *block.Header, st *state.State) (*big.Int, error) {
+	total, err := sharer.Energy.Native(st, header.Timestamp()).TotalBurned()
+	if err != nil {
+		return nil, err
+	}
+	if header.Number() == 0 {
+		return total, nil
+	}
+	parent, err := f.repo.GetBlockSummary(header.ParentID())
+	if err != nil {
+		return nil, err
+	}
+	parentState := f.stater.NewState(parent.Header.StateRoot())
+	parentTotal, err := sharer.Energy.Native(parentState, parent.Header.Timestamp()).TotalBurned()
+	if err != nil {
+		return nil, err
+	}
+	return total.Sub(total, parentTotal), nil
+}
+
+// coefPercentiles computes gas price coefs at given percentiles, weighted by gas used of each tx.
+func coefPercentiles(txs tx.Transactions, receipts tx.Receipts, percentiles []float64) []uint8 {
+	coefs := make([]uint8, len(percentiles))
+	if len(txs) == 0 {
+		return coefs
+	}
+
+	// receipts of scheduled executions come first
+	receipts = receipts[len(receipts)-len(txs):]
+
+	type item struct {
+		coef uint8
+		gas  uint64
+	}
+	items := make([]item, len(txs))
+	var total uint64
+	for i, t := range txs {
+		items[i] = item{t.GasPriceCoef(), receipts[i].GasUsed}
+		total += receipts[i].GasUsed
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].coef < items[j].coef
+	})
+
+	for i, p := range percentiles {
+		threshold := uint64(math.Ceil(float64(total) * p / 100))
+		var (
+			cum uint64
+			j   int
+		)
+		for j = 0; j < len(items)-1; j++ {
+			cum += items[j].gas
+			if cum >= threshold {
+				break
+			}
+		}
+		coefs[i] = items[j].coef
+	}
+	return coefs
+}
+
+// pendingCoef returns the coef that ranks a tx within the next block among pending txs,
+// and the total gas of pending txs.
+func pendingCoef(pending tx.Transactions, gasLimit uint64) (uint8, uint64) {
+	var total uint64
+	for _, t := range pending {
+		total += t.Gas()
+	}
+	if total <= gasLimit {
+		return 0, total
+	}
+
+	// copy before sorting, the slice is shared by pool
+	sorted := append(tx.Transactions(nil), pending...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GasPriceCoef() > sorted[j].GasPriceCoef()
+	})
+	var cum uint64
+	for _, t := range sorted {
+		cum += t.Gas()
+		if cum > gasLimit {
+			if t.GasPriceCoef() == math.MaxUint8 {
+				return math.MaxUint8, total
+			}
+			return t.GasPriceCoef() + 1, total
+		}
+	}
+	return 0, total
+}
+
+func parseBlocks(s string) (int, error) {
+	if s == "" {
+		return defaultHistoryBlocks, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 || n > maxHistoryBlocks {
+		return 0, errors.Errorf("should be in range [1, %v]", maxHistoryBlocks)
+	}
+	return int(n), nil
+}
+
+func parsePercentiles(s string) ([]float64, error) {
+	if s == "" {
+		return defaultPercentiles, nil
+	}
+	var percentiles []float64
+	for _, str := range strings.Split(s, ",") {
+		p, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+		if err != nil {
+			return nil, err
+		}
+		if p < 0 || p > 100 {
+			return nil, errors.New("should be in range [0, 100]")
+		}
+		if len(percentiles) > 0 && p < percentiles[len(percentiles)-1] {
+			return nil, errors.New("should be in ascending order")
+		}
+		percentiles = append(percentiles, p)
+	}
+	return percentiles, nil
+}
+
+func (f *Fees) Mount(root *mux.Router, pathPrefix string) {
+	sub := root.PathPrefix(pathPrefix).Subrouter()
+
+	sub.Path("/history").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(f.handleGetHistory))
+	sub.Path("/priority").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(f.handleGetPriority))
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package fees
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/chain"
+	"github.com/miniBamboo/luckyshare/genesis"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/muxdb"
+	"github.com/miniBamboo/luckyshare/packer"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/miniBamboo/luckyshare/txpool"
+	"github.com/stretchr/testify/assert"
+)
+
+var blk *block.Block
+var ts *httptest.Server
+
+func TestFees(t *testing.T) {
+	initFeesServer(t)
+	defer ts.Close()
+
+	_, statusCode := httpGet(t, ts.URL+"/fees/history?blocks=0")
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	_, statusCode = httpGet(t, ts.URL+"/fees/history?blocks=1025")
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	_, statusCode = httpGet(t, ts.URL+"/fees/history?percentiles=50,10")
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+
+	res, statusCode := httpGet(t, ts.URL+"/fees/history?percentiles=0,50,100")
+	assert.Equal(t, http.StatusOK, statusCode)
+	var history History
+	if err := json.Unmarshal(res, &history); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(0), history.OldestBlock)
+	assert.Equal(t, 2, len(history.Blocks))
+
+	b := history.Blocks[1]
+	assert.Equal(t, blk.Header().ID(), b.ID)
+	assert.Equal(t, blk.Header().GasUsed(), b.GasUsed)
+	assert.Equal(t, 2, b.TxCount)
+	assert.Equal(t, []uint8{10, 10, 200}, b.GasPriceCoefs)
+	assert.Equal(t, luckyshare.InitialBaseGasPrice, (*big.Int)(b.BaseGasPrice))
+	assert.Equal(t, 1, (*big.Int)(b.Burned).Sign())
+	assert.Equal(t, luckyshare.InitialBaseGasPrice, (*big.Int)(history.NextBaseGasPrice))
+
+	res, statusCode = httpGet(t, ts.URL+"/fees/history?blocks=1")
+	assert.Equal(t, http.StatusOK, statusCode)
+	if err := json.Unmarshal(res, &history); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, blk.Header().Number(), history.OldestBlock)
+	assert.Equal(t, 1, len(history.Blocks))
+
+	res, statusCode = httpGet(t, ts.URL+"/fees/priority")
+	assert.Equal(t, http.StatusOK, statusCode)
+	var priority Priority
+	if err := json.Unmarshal(res, &priority); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, Priority{}, priority)
+}
+
+func TestPendingCoef(t *testing.T) {
+	build := func(coef uint8, gas uint64) *tx.Transaction {
+		return new(tx.Builder).GasPriceCoef(coef).Gas(gas).Build()
+	}
+	pending := tx.Transactions{build(10, 100), build(50, 100), build(255, 100)}
+
+	coef, total := pendingCoef(pending, 300)
+	assert.Equal(t, uint8(0), coef)
+	assert.Equal(t, uint64(300), total)
+
+	coef, _ = pendingCoef(pending, 200)
+	assert.Equal(t, uint8(11), coef)
+	// not sorted in place
+	assert.Equal(t, uint8(10), pending[0].GasPriceCoef())
+
+	coef, _ = pendingCoef(pending, 100)
+	assert.Equal(t, uint8(51), coef)
+
+	coef, _ = pendingCoef(pending, 50)
+	assert.Equal(t, uint8(255), coef)
+}
+
+func initFeesServer(t *testing.T) {
+	db := muxdb.NewMem()
+	stater := state.NewStater(db)
+	gene := genesis.NewDevnet()
+
+	b, _, _, err := gene.Build(stater)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, _ := chain.NewRepository(db, b)
+
+	packer := packer.New(repo, stater, genesis.DevAccounts()[0].Address, &genesis.DevAccounts()[0].Address, luckyshare.NoFork)
+	flow, err := packer.Schedule(b.Header(), uint64(time.Now().Unix()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := luckyshare.BytesToAddress([]byte("to"))
+	for i, coef := range []uint8{10, 200} {
+		tx := new(tx.Builder).
+			ChainTag(repo.ChainTag()).
+			GasPriceCoef(coef).
+			Expiration(10).
+			Gas(21000).
+			Nonce(uint64(i)).
+			Clause(tx.NewClause(&addr).WithValue(big.NewInt(10000))).
+			BlockRef(tx.NewBlockRef(0)).
+			Build()
+		sig, err := crypto.Sign(tx.SigningHash().Bytes(), genesis.DevAccounts()[0].PrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := flow.Adopt(tx.WithSignature(sig)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	block, stage, receipts, err := flow.Pack(genesis.DevAccounts()[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stage.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddBlock(block, receipts); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetBestBlockID(block.Header().ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	pool := txpool.New(repo, stater, txpool.Options{
+		Limit:           10000,
+		LimitPerAccount: 16,
+		MaxLifetime:     10 * time.Minute,
+	})
+	router := mux.NewRouter()
+	New(repo, stater, pool, luckyshare.NoFork).Mount(router, "/fees")
+	ts = httptest.NewServer(router)
+	blk = block
+}
+
+func httpGet(t *testing.T, url string) ([]byte, int) {
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, res.StatusCode
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package fees
+
+import (
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+)
+
+// BlockFees gas usage and price statistics of a block.
+type BlockFees struct {
+	ID           luckyshare.Bytes32    `json:"id"`
+	Number       uint32                `json:"number"`
+	Timestamp    uint64                `json:"timestamp"`
+	GasLimit     uint64                `json:"gasLimit"`
+	GasUsed      uint64                `json:"gasUsed"`
+	GasUsedRatio float64               `json:"gasUsedRatio"`
+	BaseGasPrice *math.HexOrDecimal256 `json:"baseGasPrice"`
+	// energy burned in the block
+	Burned  *math.HexOrDecimal256 `json:"burned"`
+	TxCount int                   `json:"txCount"`
+	// gas price coefs at requested percentiles, weighted by gas used
+	GasPriceCoefs []uint8 `json:"gasPriceCoefs"`
+}
+
+// History fee history of recent blocks, in ascending order of block number.
+type History struct {
+	OldestBlock uint32       `json:"oldestBlock"`
+	Percentiles []float64    `json:"percentiles"`
+	Blocks      []*BlockFees `json:"blocks"`
+	// base gas price of the block next to best
+	NextBaseGasPrice *math.HexOrDecimal256 `json:"nextBaseGasPrice"`
+}
+
+// Priority suggested gas price coef for a tx to be packed promptly.
+type Priority struct {
+	GasPriceCoef uint8 `json:"gasPriceCoef"`
+	// coef derived from recent busy blocks
+	RecentCoef uint8 `json:"recentCoef"`
+	// coef required to rank within the next block among pending txs
+	PendingCoef uint8 `json:"pendingCoef"`
+	// total gas of executable txs in pool
+	PendingGas uint64 `json:"pendingGas"`
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package governance
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/api/utils"
+	"github.com/miniBamboo/luckyshare/chain"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/pkg/errors"
+)
+
+const (
+	maxRecentBlocks     = 1000
+	defaultRecentBlocks = 100
+)
+
+// Authority serves block proposer candidates and builds clauses to manage them.
+type Authority struct {
+	repo   *chain.Repository
+	stater *state.Stater
+}
+
+func NewAuthority(repo *chain.Repository, stater *state.Stater) *Authority {
+	return &Authority{
+		repo,
+		stater,
+	}
+}
+
+func (a *Authority) handleGetCandidates(w http.ResponseWriter, req *http.Request) error {
+	recent, err := parseRecentBlocks(req.URL.Query().Get("recentBlocks"))
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "recentBlocks"))
+	}
+
+	best := a.repo.BestBlock().Header()
+	st := a.stater.NewState(best.StateRoot())
+	endorsement, err := sharer.Params.Native(st).Get(luckyshare.KeyProposerEndorsement)
+	if err != nil {
+		return err
+	}
+	all, err := sharer.Authority.Native(st).AllCandidates()
+	if err != nil {
+		return err
+	}
+
+	result := &Candidates{
+		BestBlock:   best.Number(),
+		Endorsement: (*math.HexOrDecimal256)(endorsement),
+		Candidates:  make([]*Candidate, 0, len(all)),
+	}
+	index := make(map[luckyshare.Address]*Candidate, len(all))
+	for _, c := range all {
+		bal, err := st.GetBalance(c.Endorsor)
+		if err != nil {
+			return err
+		}
+		delegated, err := sharer.Staker.Native(st).Delegated(c.Endorsor)
+		if err != nil {
+			return err
+		}
+		candidate := &Candidate{
+			NodeMaster:      c.NodeMaster,
+			Endorsor:        c.Endorsor,
+			Identity:        c.Identity,
+			Active:          c.Active,
+			EndorsorBalance: (*math.HexOrDecimal256)(bal),
+			Delegated:       (*math.HexOrDecimal256)(delegated),
+			Endorsed:        new(big.Int).Add(bal, delegated).Cmp(endorsement) >= 0,
+		}
+		result.Candidates = append(result.Candidates, candidate)
+		index[c.NodeMaster] = candidate
+	}
+
+	// genesis block has no signer
+	if uint32(recent) > best.Number() {
+		recent = int(best.Number())
+	}
+	result.RecentBlocks = uint32(recent)
+
+	chain := a.repo.NewBestChain()
+	for i := 0; i < recent; i++ {
+		num := best.Number() - uint32(i)
+		header, err := chain.GetBlockHeader(num)
+		if err != nil {
+			return err
+		}
+		signer, err := header.Signer()
+		if err != nil {
+			return err
+		}
+		if c := index[signer]; c != nil {
+			c.SignedBlocks++
+			if c.LastSignedBlock == nil {
+				c.LastSignedBlock = &num
+			}
+		}
+	}
+	return utils.WriteJSON(w, result)
+}
+
+func (a *Authority) handleBuildAdd(w http.ResponseWriter, req *http.Request) error {
+	var body AddAuthority
+	if err := utils.ParseJSON(req.Body, &body); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	if body.NodeMaster.IsZero() || body.Endorsor.IsZero() || body.Identity.IsZero() {
+		return utils.BadRequest(errors.New("body: nodeMaster, endorsor and identity required"))
+	}
+	return a.buildClause(w, body.NodeMaster, func(listed bool) error {
+		if listed {
+			return errors.New("node already listed")
+		}
+		return nil
+	}, fmt.Sprintf("add authority node %v with endorsor %v and identity %v", body.NodeMaster, body.Endorsor, body.Identity),
+		"add", body.NodeMaster, body.Endorsor, body.Identity)
+}
+
+func (a *Authority) handleBuildRevoke(w http.ResponseWriter, req *http.Request) error {
+	var body RevokeNode
+	if err := utils.ParseJSON(req.Body, &body); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	return a.buildClause(w, body.NodeMaster, func(listed bool) error {
+		if !listed {
+			return errors.New("node not listed")
+		}
+		return nil
+	}, fmt.Sprintf("revoke authority node %v", body.NodeMaster),
+		"revoke", body.NodeMaster)
+}
+
+// buildClause builds clause that calls the authority method.
+// The call is wrapped into a proposal if the executor is the builtin contract, otherwise it should be
+// directly sent by the executor.
+func (a *Authority) buildClause(
+	w http.ResponseWriter,
+	nodeMaster luckyshare.Address,
+	check func(listed bool) error,
+	description string,
+	method string,
+	args ...interface{},
+) error {
+	best := a.repo.BestBlock().Header()
+	st := a.stater.NewState(best.StateRoot())
+
+	listed, _, _, _, err := sharer.Authority.Native(st).Get(nodeMaster)
+	if err != nil {
+		return err
+	}
+	if err := check(listed); err != nil {
+		return utils.BadRequest(err)
+	}
+
+	m, _ := sharer.Authority.ABI.MethodByName(method)
+	data, err := m.EncodeInput(args...)
+	if err != nil {
+		return err
+	}
+	val, err := sharer.Params.Native(st).Get(luckyshare.KeyExecutorAddress)
+	if err != nil {
+		return err
+	}
+	executor := luckyshare.BytesToAddress(val.Bytes())
+
+	to := sharer.Authority.Address
+	if executor == sharer.Executor.Address {
+		propose, _ := sharer.Executor.ABI.MethodByName("propose")
+		if data, err = propose.EncodeInput(sharer.Authority.Address, data); err != nil {
+			return err
+		}
+		to = sharer.Executor.Address
+		description = "propose: " + description
+	}
+	built := newBuiltClause(to, data, description)
+	built.Executor = &executor
+	return utils.WriteJSON(w, built)
+}
+
+func parseRecentBlocks(s string) (int, error) {
+	if s == "" {
+		return defaultRecentBlocks, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	if n > maxRecentBlocks {
+		return 0, errors.Errorf("should not be greater than %v", maxRecentBlocks)
+	}
+	return int(n), nil
+}
+
+func (a *Authority) Mount(root *mux.Router, pathPrefix string) {
+	sub := root.PathPrefix(pathPrefix).Subrouter()
+
+	sub.Path("/candidates").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(a.handleGetCandidates))
+	sub.Path("/clauses/add").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(a.handleBuildAdd))
+	sub.Path("/clauses/revoke").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(a.handleBuildRevoke))
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package governance
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/chain"
+	"github.com/miniBamboo/luckyshare/genesis"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/muxdb"
+	"github.com/miniBamboo/luckyshare/packer"
+	"github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthority(t *testing.T) {
+	ts := initAuthorityServer(t)
+	defer ts.Close()
+
+	signer := genesis.DevAccounts()[0].Address
+
+	var candidates Candidates
+	res, statusCode := httpGet(t, ts.URL+"/authority/candidates")
+	assert.Equal(t, http.StatusOK, statusCode)
+	if err := json.Unmarshal(res, &candidates); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint32(1), candidates.BestBlock)
+	assert.Equal(t, luckyshare.InitialProposerEndorsement, (*big.Int)(candidates.Endorsement))
+	assert.Equal(t, uint32(1), candidates.RecentBlocks)
+	assert.Equal(t, 1, len(candidates.Candidates))
+
+	c := candidates.Candidates[0]
+	assert.Equal(t, signer, c.NodeMaster)
+	assert.Equal(t, signer, c.Endorsor)
+	assert.True(t, c.Active)
+	assert.True(t, c.Endorsed)
+	assert.Equal(t, uint32(1), c.SignedBlocks)
+	assert.Equal(t, uint32(1), *c.LastSignedBlock)
+
+	_, statusCode = httpGet(t, ts.URL+"/authority/candidates?recentBlocks=1001")
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+
+	node := luckyshare.BytesToAddress([]byte("node"))
+	identity := luckyshare.BytesToBytes32([]byte("identity"))
+
+	var built BuiltClause
+	res, statusCode = httpPost(t, ts.URL+"/authority/clauses/add", &AddAuthority{node, signer, identity})
+	assert.Equal(t, http.StatusOK, statusCode)
+	if err := json.Unmarshal(res, &built); err != nil {
+		t.Fatal(err)
+	}
+	add, _ := sharer.Authority.ABI.MethodByName("add")
+	data, _ := add.EncodeInput(node, signer, identity)
+	// the executor of devnet is an account, so the call is sent directly
+	assert.Equal(t, sharer.Authority.Address, *built.Clause.To)
+	assert.Equal(t, hexutil.Encode(data), built.Clause.Data)
+	assert.Equal(t, &signer, built.Executor)
+
+	_, statusCode = httpPost(t, ts.URL+"/authority/clauses/add", &AddAuthority{signer, signer, identity})
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	_, statusCode = httpPost(t, ts.URL+"/authority/clauses/add", &AddAuthority{NodeMaster: node})
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+
+	res, statusCode = httpPost(t, ts.URL+"/authority/clauses/revoke", &RevokeNode{signer})
+	assert.Equal(t, http.StatusOK, statusCode)
+	if err := json.Unmarshal(res, &built); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "revoke authority node "+signer.String(), built.Description)
+
+	_, statusCode = httpPost(t, ts.URL+"/authority/clauses/revoke", &RevokeNode{node})
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+}
+
+func initAuthorityServer(t *testing.T) *httptest.Server {
+	db := muxdb.NewMem()
+	stater := state.NewStater(db)
+	gene := genesis.NewDevnet()
+
+	b, _, _, err := gene.Build(stater)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, _ := chain.NewRepository(db, b)
+
+	packer := packer.New(repo, stater, genesis.DevAccounts()[0].Address, &genesis.DevAccounts()[0].Address, luckyshare.NoFork)
+	flow, err := packer.Schedule(b.Header(), uint64(time.Now().Unix()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, stage, receipts, err := flow.Pack(genesis.DevAccounts()[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stage.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddBlock(block, receipts); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetBestBlockID(block.Header().ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	router := mux.NewRouter()
+	NewAuthority(repo, stater).Mount(router, "/authority")
+	return httptest.NewServer(router)
+}
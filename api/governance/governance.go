@@ -0,0 +1,516 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package governance
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/abi"
+	"github.com/miniBamboo/luckyshare/api/utils"
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/chain"
+	"github.com/miniBamboo/luckyshare/logdb"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/sharer/executor"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/pkg/errors"
+)
+
+var (
+	proposalEvent, _ = sharer.Executor.ABI.EventByName("Proposal")
+	approverEvent, _ = sharer.Executor.ABI.EventByName("Approver")
+)
+
+// bounds the scan of approver logs. Each address can be added as approver only once,
+// and adding one takes a passed proposal, so it's far beyond the practical count.
+const maxApproverLogs = 1000
+
+// proposalLogBatch is the count of proposal logs fetched at a time, when scanning for a page of proposals.
+const proposalLogBatch = 256
+
+type Governance struct {
+	repo     *chain.Repository
+	stater   *state.Stater
+	db       *logdb.LogDB
+	skipLogs bool
+}
+
+func New(repo *chain.Repository, stater *state.Stater, db *logdb.LogDB, skipLogs bool) *Governance {
+	return &Governance{
+		repo,
+		stater,
+		db,
+		skipLogs,
+	}
+}
+
+// executorEvents filters events of given type emitted by the executor, with the action decoded.
+func (g *Governance) executorEvents(ctx context.Context, eventID luckyshare.Bytes32, order logdb.Order, options *logdb.Options) ([]*logdb.Event, []string, error) {
+	events, err := g.db.FilterEvents(ctx, &logdb.EventFilter{
+		CriteriaSet: []*logdb.EventCriteria{{
+			Address: &sharer.Executor.Address,
+			Topics:  [5]*luckyshare.Bytes32{&eventID},
+		}},
+		Options: options,
+		Order:   order,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	// the only non-indexed arg is the bytes32 action
+	actions := make([]string, len(events))
+	for i, ev := range events {
+		actions[i] = string(bytes.TrimRight(ev.Data, "\x00"))
+	}
+	return events, actions, nil
+}
+
+// approvers returns approvers ever added, in the order of addition.
+func (g *Governance) approvers(ctx context.Context, ex *executor.Executor) ([]*Approver, error) {
+	events, actions, err := g.executorEvents(ctx, approverEvent.ID(), logdb.ASC, &logdb.Options{Limit: maxApproverLogs})
+	if err != nil {
+		return nil, err
+	}
+	var (
+		approvers []*Approver
+		seen      = make(map[luckyshare.Address]bool)
+	)
+	for i, ev := range events {
+		if actions[i] != "added" || ev.Topics[1] == nil {
+			continue
+		}
+		addr := luckyshare.BytesToAddress(ev.Topics[1].Bytes())
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+
+		identity, inPower, err := ex.GetApprover(addr)
+		if err != nil {
+			return nil, err
+		}
+		approvers = append(approvers, &Approver{
+			Address:  addr,
+			Identity: identity,
+			InPower:  inPower,
+		})
+	}
+	return approvers, nil
+}
+
+func (g *Governance) convertProposal(
+	ex *executor.Executor,
+	id luckyshare.Bytes32,
+	p *executor.Proposal,
+	approvers []*Approver,
+	now uint64,
+) (*Proposal, error) {
+	proposal := &Proposal{
+		ID:            id,
+		Proposer:      p.Proposer,
+		TimeProposed:  p.TimeProposed,
+		Quorum:        p.Quorum,
+		ApprovalCount: p.ApprovalCount,
+		Approvals:     []luckyshare.Address{},
+		Executed:      p.Executed,
+		Expired:       !p.Executed && p.Expired(now),
+		Target:        p.Target,
+		Data:          hexutil.Encode(p.Data),
+		Description:   describe(p.Target, p.Data),
+	}
+	for _, a := range approvers {
+		approved, err := ex.IsApproved(id, a.Address)
+		if err != nil {
+			return nil, err
+		}
+		if approved {
+			proposal.Approvals = append(proposal.Approvals, a.Address)
+		}
+	}
+	return proposal, nil
+}
+
+func (g *Governance) bestExecutor() (*executor.Executor, *block.Header) {
+	best := g.repo.BestBlock().Header()
+	return sharer.Executor.Native(g.stater.NewState(best.StateRoot())), best
+}
+
+func (g *Governance) handleGetProposals(w http.ResponseWriter, req *http.Request) error {
+	status := req.URL.Query().Get("status")
+	switch status {
+	case "", "pending", "executed", "expired":
+	default:
+		return utils.BadRequest(errors.New("status: should be one of pending, executed and expired"))
+	}
+	offset, limit, err := utils.ParsePage(req)
+	if err != nil {
+		return err
+	}
+
+	ex, best := g.bestExecutor()
+	approvers, err := g.approvers(req.Context(), ex)
+	if err != nil {
+		return err
+	}
+	// the action is not indexed, so proposal logs are scanned in batches until the page of matched proposals is filled
+	var (
+		proposals = make([]*Proposal, 0)
+		skipped   uint64
+	)
+	for logOffset := uint64(0); uint64(len(proposals)) < limit; logOffset += proposalLogBatch {
+		events, actions, err := g.executorEvents(req.Context(), proposalEvent.ID(), logdb.DESC, &logdb.Options{Offset: logOffset, Limit: proposalLogBatch})
+		if err != nil {
+			return err
+		}
+		for i, ev := range events {
+			if actions[i] != "proposed" || ev.Topics[1] == nil {
+				continue
+			}
+			id := *ev.Topics[1]
+			p, err := ex.GetProposal(id)
+			if err != nil {
+				return err
+			}
+			if p.TimeProposed == 0 {
+				// the log is ahead of best state
+				continue
+			}
+			proposal, err := g.convertProposal(ex, id, p, approvers, best.Timestamp())
+			if err != nil {
+				return err
+			}
+
+			switch status {
+			case "pending":
+				if proposal.Executed || proposal.Expired {
+					continue
+				}
+			case "executed":
+				if !proposal.Executed {
+					continue
+				}
+			case "expired":
+				if !proposal.Expired {
+					continue
+				}
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			proposal.Meta = &LogMeta{
+				BlockID:        ev.BlockID,
+				BlockNumber:    ev.BlockNumber,
+				BlockTimestamp: ev.BlockTime,
+				TxID:           ev.TxID,
+				TxOrigin:       ev.TxOrigin,
+				ClauseIndex:    ev.ClauseIndex,
+			}
+			proposals = append(proposals, proposal)
+			if uint64(len(proposals)) >= limit {
+				break
+			}
+		}
+		if len(events) < proposalLogBatch {
+			break
+		}
+	}
+	return utils.WriteJSON(w, proposals)
+}
+
+func (g *Governance) handleGetProposal(w http.ResponseWriter, req *http.Request) error {
+	id, err := luckyshare.ParseBytes32(mux.Vars(req)["id"])
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "id"))
+	}
+	ex, best := g.bestExecutor()
+	p, err := ex.GetProposal(id)
+	if err != nil {
+		return err
+	}
+	if p.TimeProposed == 0 {
+		return utils.WriteJSON(w, nil)
+	}
+	approvers, err := g.approvers(req.Context(), ex)
+	if err != nil {
+		return err
+	}
+	proposal, err := g.convertProposal(ex, id, p, approvers, best.Timestamp())
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, proposal)
+}
+
+func (g *Governance) handleGetApprovers(w http.ResponseWriter, req *http.Request) error {
+	ex, _ := g.bestExecutor()
+	approvers, err := g.approvers(req.Context(), ex)
+	if err != nil {
+		return err
+	}
+	if approvers == nil {
+		approvers = []*Approver{}
+	}
+	return utils.WriteJSON(w, approvers)
+}
+
+func (g *Governance) handleBuildPropose(w http.ResponseWriter, req *http.Request) error {
+	var body ProposeRequest
+	if err := utils.ParseJSON(req.Body, &body); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	target, data, err := buildProposal(&body)
+	if err != nil {
+		return utils.BadRequest(err)
+	}
+	return g.writeClause(w, "propose", fmt.Sprintf("propose: %v", describeOrRaw(target, data)), target, data)
+}
+
+func (g *Governance) handleBuildApprove(w http.ResponseWriter, req *http.Request) error {
+	return g.buildProposalAction(w, req, "approve", func(p *executor.Proposal, now uint64) error {
+		if p.Expired(now) {
+			return errors.New("proposal expired")
+		}
+		return nil
+	})
+}
+
+func (g *Governance) handleBuildExecute(w http.ResponseWriter, req *http.Request) error {
+	return g.buildProposalAction(w, req, "execute", func(p *executor.Proposal, now uint64) error {
+		if p.Executed {
+			return errors.New("proposal executed")
+		}
+		if p.Expired(now) {
+			return errors.New("proposal expired")
+		}
+		if p.ApprovalCount < p.Quorum {
+			return errors.New("quorum unsatisfied")
+		}
+		return nil
+	})
+}
+
+// buildProposalAction builds clause to act on an existing proposal, which is checked against best state.
+func (g *Governance) buildProposalAction(
+	w http.ResponseWriter,
+	req *http.Request,
+	action string,
+	check func(p *executor.Proposal, now uint64) error,
+) error {
+	var body ProposalRef
+	if err := utils.ParseJSON(req.Body, &body); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	ex, best := g.bestExecutor()
+	p, err := ex.GetProposal(body.ProposalID)
+	if err != nil {
+		return err
+	}
+	if p.TimeProposed == 0 {
+		return utils.BadRequest(errors.New("proposal not found"))
+	}
+	if err := check(p, best.Timestamp()); err != nil {
+		return utils.BadRequest(err)
+	}
+	return g.writeClause(w, action,
+		fmt.Sprintf("%v proposal %v: %v", action, body.ProposalID, describeOrRaw(p.Target, p.Data)),
+		body.ProposalID)
+}
+
+// writeClause writes clause that calls the executor method with args.
+func (g *Governance) writeClause(w http.ResponseWriter, method string, description string, args ...interface{}) error {
+	m, _ := sharer.Executor.ABI.MethodByName(method)
+	data, err := m.EncodeInput(args...)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, newBuiltClause(sharer.Executor.Address, data, description))
+}
+
+func newBuiltClause(to luckyshare.Address, data []byte, description string) *BuiltClause {
+	return &BuiltClause{
+		Clause: Clause{
+			To:    &to,
+			Value: (*math.HexOrDecimal256)(new(big.Int)),
+			Data:  hexutil.Encode(data),
+		},
+		Description: description,
+	}
+}
+
+// buildProposal builds target and call data of the proposal.
+func buildProposal(req *ProposeRequest) (luckyshare.Address, []byte, error) {
+	var (
+		target luckyshare.Address
+		data   []byte
+		err    error
+		n      int
+	)
+	encode := func(contract *abi.ABI, method string, args ...interface{}) ([]byte, error) {
+		m, _ := contract.MethodByName(method)
+		return m.EncodeInput(args...)
+	}
+
+	if req.Target != nil {
+		n++
+		target = *req.Target
+		if data, err = hexutil.Decode(req.Data); err != nil {
+			return target, nil, errors.WithMessage(err, "data")
+		}
+	}
+	if p := req.SetParam; p != nil {
+		n++
+		if len(p.Key) == 0 || len(p.Key) > 32 {
+			return target, nil, errors.New("setParam.key: should be 1 to 32 bytes")
+		}
+		if p.Value == nil {
+			return target, nil, errors.New("setParam.value: required")
+		}
+		target = sharer.Params.Address
+		data, err = encode(sharer.Params.ABI, "set", common.Hash(luckyshare.BytesToBytes32([]byte(p.Key))), (*big.Int)(p.Value))
+	}
+	if a := req.AddAuthority; a != nil {
+		n++
+		target = sharer.Authority.Address
+		data, err = encode(sharer.Authority.ABI, "add", common.Address(a.NodeMaster), common.Address(a.Endorsor), common.Hash(a.Identity))
+	}
+	if a := req.RevokeAuthority; a != nil {
+		n++
+		target = sharer.Authority.Address
+		data, err = encode(sharer.Authority.ABI, "revoke", common.Address(*a))
+	}
+	if a := req.AddApprover; a != nil {
+		n++
+		target = sharer.Executor.Address
+		data, err = encode(sharer.Executor.ABI, "addApprover", common.Address(a.Approver), common.Hash(a.Identity))
+	}
+	if a := req.RevokeApprover; a != nil {
+		n++
+		target = sharer.Executor.Address
+		data, err = encode(sharer.Executor.ABI, "revokeApprover", common.Address(*a))
+	}
+
+	if n != 1 {
+		return target, nil, errors.New("body: exactly one of target, setParam, addAuthority, revokeAuthority, addApprover and revokeApprover required")
+	}
+	if err != nil {
+		return target, nil, err
+	}
+	if target.IsZero() {
+		return target, nil, errors.New("target: invalid")
+	}
+	return target, data, nil
+}
+
+func describeOrRaw(target luckyshare.Address, data []byte) string {
+	if desc := describe(target, data); desc != "" {
+		return desc
+	}
+	return fmt.Sprintf("call %v with data %v", target, hexutil.Encode(data))
+}
+
+// describe decodes calls to sharer contracts into human readable text.
+// Empty string returned if the call is unknown.
+func describe(target luckyshare.Address, data []byte) string {
+	var contract *abi.ABI
+	switch target {
+	case sharer.Params.Address:
+		contract = sharer.Params.ABI
+	case sharer.Authority.Address:
+		contract = sharer.Authority.ABI
+	case sharer.Executor.Address:
+		contract = sharer.Executor.ABI
+	default:
+		return ""
+	}
+	method, err := contract.MethodByInput(data)
+	if err != nil {
+		return ""
+	}
+
+	switch method.Name() {
+	case "set":
+		var args struct {
+			Key   common.Hash
+			Value *big.Int
+		}
+		if err := method.DecodeInput(data, &args); err != nil {
+			return ""
+		}
+		return fmt.Sprintf("set %v to %v", PrintableName(luckyshare.Bytes32(args.Key)), args.Value)
+	case "add":
+		var args struct {
+			NodeMaster common.Address
+			Endorsor   common.Address
+			Identity   common.Hash
+		}
+		if err := method.DecodeInput(data, &args); err != nil {
+			return ""
+		}
+		return fmt.Sprintf("add authority node %v with endorsor %v and identity %v",
+			luckyshare.Address(args.NodeMaster), luckyshare.Address(args.Endorsor), luckyshare.Bytes32(args.Identity))
+	case "addApprover":
+		var args struct {
+			Approver common.Address
+			Identity common.Hash
+		}
+		if err := method.DecodeInput(data, &args); err != nil {
+			return ""
+		}
+		return fmt.Sprintf("add approver %v with identity %v", luckyshare.Address(args.Approver), luckyshare.Bytes32(args.Identity))
+	case "revoke", "revokeApprover", "attachVotingContract", "detachVotingContract":
+		var addr common.Address
+		if err := method.DecodeInput(data, &addr); err != nil {
+			return ""
+		}
+		action := map[string]string{
+			"revoke":               "revoke authority node",
+			"revokeApprover":       "revoke approver",
+			"attachVotingContract": "attach voting contract",
+			"detachVotingContract": "detach voting contract",
+		}[method.Name()]
+		return fmt.Sprintf("%v %v", action, luckyshare.Address(addr))
+	}
+	return ""
+}
+
+// PrintableName returns the text of a bytes32 name, e.g. param key or identity, or hex if not printable.
+func PrintableName(key luckyshare.Bytes32) string {
+	name := bytes.TrimLeft(key[:], "\x00")
+	if len(name) == 0 {
+		return key.String()
+	}
+	for _, c := range name {
+		if c > unicode.MaxASCII || !unicode.IsPrint(rune(c)) {
+			return key.String()
+		}
+	}
+	return string(name)
+}
+
+func (g *Governance) Mount(root *mux.Router, pathPrefix string) {
+	sub := root.PathPrefix(pathPrefix).Subrouter()
+
+	if !g.skipLogs {
+		// proposals and approvers are enumerated from logs
+		sub.Path("/proposals").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(g.handleGetProposals))
+		sub.Path("/proposals/{id}").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(g.handleGetProposal))
+		sub.Path("/approvers").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(g.handleGetApprovers))
+	}
+	sub.Path("/clauses/propose").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(g.handleBuildPropose))
+	sub.Path("/clauses/approve").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(g.handleBuildApprove))
+	sub.Path("/clauses/execute").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(g.handleBuildExecute))
+}
@@ -0,0 +1,307 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package governance
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/abi"
+	"github.com/miniBamboo/luckyshare/chain"
+	"github.com/miniBamboo/luckyshare/genesis"
+	"github.com/miniBamboo/luckyshare/logdb"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/muxdb"
+	"github.com/miniBamboo/luckyshare/packer"
+	"github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/stretchr/testify/assert"
+)
+
+var ts *httptest.Server
+var proposalID luckyshare.Bytes32
+var proposeTxID luckyshare.Bytes32
+
+func mustEncode(contract *abi.ABI, name string, args ...interface{}) []byte {
+	m, _ := contract.MethodByName(name)
+	data, err := m.EncodeInput(args...)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func TestDescribe(t *testing.T) {
+	addr := luckyshare.BytesToAddress([]byte("addr"))
+	id := luckyshare.BytesToBytes32([]byte("id"))
+
+	tests := []struct {
+		target luckyshare.Address
+		data   []byte
+		want   string
+	}{
+		{sharer.Params.Address, mustEncode(sharer.Params.ABI, "set", luckyshare.KeyRewardRatio, big.NewInt(1)), "set reward-ratio to 1"},
+		{sharer.Params.Address, mustEncode(sharer.Params.ABI, "set", luckyshare.Bytes32{1}, big.NewInt(1)), "set " + luckyshare.Bytes32{1}.String() + " to 1"},
+		{sharer.Authority.Address, mustEncode(sharer.Authority.ABI, "add", addr, addr, id), "add authority node " + addr.String() + " with endorsor " + addr.String() + " and identity " + id.String()},
+		{sharer.Authority.Address, mustEncode(sharer.Authority.ABI, "revoke", addr), "revoke authority node " + addr.String()},
+		{sharer.Executor.Address, mustEncode(sharer.Executor.ABI, "addApprover", addr, id), "add approver " + addr.String() + " with identity " + id.String()},
+		{sharer.Executor.Address, mustEncode(sharer.Executor.ABI, "revokeApprover", addr), "revoke approver " + addr.String()},
+		{sharer.Executor.Address, mustEncode(sharer.Executor.ABI, "attachVotingContract", addr), "attach voting contract " + addr.String()},
+		// unknown method
+		{sharer.Params.Address, []byte{1, 2, 3, 4}, ""},
+		// unknown target
+		{addr, mustEncode(sharer.Params.ABI, "set", luckyshare.KeyRewardRatio, big.NewInt(1)), ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, describe(tt.target, tt.data))
+	}
+}
+
+func TestGovernance(t *testing.T) {
+	initGovernanceServer(t)
+	defer ts.Close()
+
+	approver := genesis.DevAccounts()[0].Address
+
+	var approvers []*Approver
+	res, statusCode := httpGet(t, ts.URL+"/governance/approvers")
+	assert.Equal(t, http.StatusOK, statusCode)
+	if err := json.Unmarshal(res, &approvers); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []*Approver{{approver, luckyshare.BytesToBytes32([]byte("approver")), true}}, approvers)
+
+	var proposals []*Proposal
+	res, statusCode = httpGet(t, ts.URL+"/governance/proposals")
+	assert.Equal(t, http.StatusOK, statusCode)
+	if err := json.Unmarshal(res, &proposals); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(proposals))
+	p := proposals[0]
+	assert.Equal(t, proposalID, p.ID)
+	assert.Equal(t, approver, p.Proposer)
+	assert.Equal(t, uint8(1), p.Quorum)
+	assert.Equal(t, []luckyshare.Address{approver}, p.Approvals)
+	assert.False(t, p.Executed)
+	assert.False(t, p.Expired)
+	assert.Equal(t, sharer.Params.Address, p.Target)
+	assert.Equal(t, "set reward-ratio to 12345", p.Description)
+	assert.Equal(t, proposeTxID, p.Meta.TxID)
+
+	res, statusCode = httpGet(t, ts.URL+"/governance/proposals?status=executed")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, "[]", string(bytes.TrimSpace(res)))
+	_, statusCode = httpGet(t, ts.URL+"/governance/proposals?status=unknown")
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+
+	// the page applies to matched proposals, though approving logs come first in descending order
+	res, statusCode = httpGet(t, ts.URL+"/governance/proposals?limit=1")
+	assert.Equal(t, http.StatusOK, statusCode)
+	if err := json.Unmarshal(res, &proposals); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(proposals))
+	res, statusCode = httpGet(t, ts.URL+"/governance/proposals?offset=1&limit=1")
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, "[]", string(bytes.TrimSpace(res)))
+	_, statusCode = httpGet(t, ts.URL+"/governance/proposals?limit=1001")
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+
+	var single *Proposal
+	res, statusCode = httpGet(t, ts.URL+"/governance/proposals/"+proposalID.String())
+	assert.Equal(t, http.StatusOK, statusCode)
+	if err := json.Unmarshal(res, &single); err != nil {
+		t.Fatal(err)
+	}
+	p.Meta = nil
+	assert.Equal(t, p, single)
+
+	res, statusCode = httpGet(t, ts.URL+"/governance/proposals/"+luckyshare.Bytes32{1}.String())
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, "null", string(bytes.TrimSpace(res)))
+
+	var built BuiltClause
+	res, statusCode = httpPost(t, ts.URL+"/governance/clauses/execute", &ProposalRef{proposalID})
+	assert.Equal(t, http.StatusOK, statusCode)
+	if err := json.Unmarshal(res, &built); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, sharer.Executor.Address, *built.Clause.To)
+	assert.Equal(t, hexutil.Encode(mustEncode(sharer.Executor.ABI, "execute", proposalID)), built.Clause.Data)
+	assert.Equal(t, "execute proposal "+proposalID.String()+": set reward-ratio to 12345", built.Description)
+
+	_, statusCode = httpPost(t, ts.URL+"/governance/clauses/approve", &ProposalRef{luckyshare.Bytes32{1}})
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+
+	res, statusCode = httpPost(t, ts.URL+"/governance/clauses/propose", map[string]interface{}{
+		"setParam": map[string]string{"key": "reward-ratio", "value": "1"},
+	})
+	assert.Equal(t, http.StatusOK, statusCode)
+	if err := json.Unmarshal(res, &built); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, hexutil.Encode(mustEncode(sharer.Executor.ABI, "propose",
+		sharer.Params.Address,
+		mustEncode(sharer.Params.ABI, "set", luckyshare.KeyRewardRatio, big.NewInt(1)))), built.Clause.Data)
+	assert.Equal(t, "propose: set reward-ratio to 1", built.Description)
+
+	// no action
+	_, statusCode = httpPost(t, ts.URL+"/governance/clauses/propose", map[string]interface{}{})
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+	// more than one action
+	_, statusCode = httpPost(t, ts.URL+"/governance/clauses/propose", map[string]interface{}{
+		"revokeAuthority": approver,
+		"revokeApprover":  approver,
+	})
+	assert.Equal(t, http.StatusBadRequest, statusCode)
+}
+
+func initGovernanceServer(t *testing.T) {
+	db := muxdb.NewMem()
+	stater := state.NewStater(db)
+	approver := genesis.DevAccounts()[0]
+	launchTime := uint64(1526400000)
+
+	b, events, transfers, err := new(genesis.Builder).
+		GasLimit(luckyshare.InitialGasLimit).
+		Timestamp(launchTime).
+		State(func(state *state.State) error {
+			if err := state.SetCode(sharer.Params.Address, sharer.Params.RuntimeBytecodes()); err != nil {
+				return err
+			}
+			if err := state.SetCode(sharer.Executor.Address, sharer.Executor.RuntimeBytecodes()); err != nil {
+				return err
+			}
+			if err := state.SetCode(sharer.Prototype.Address, sharer.Prototype.RuntimeBytecodes()); err != nil {
+				return err
+			}
+			return sharer.Params.Native(state).Set(luckyshare.KeyExecutorAddress, new(big.Int).SetBytes(sharer.Executor.Address[:]))
+		}).
+		Call(
+			tx.NewClause(&sharer.Executor.Address).WithData(mustEncode(sharer.Executor.ABI, "addApprover", approver.Address, luckyshare.BytesToBytes32([]byte("approver")))),
+			sharer.Executor.Address).
+		Build(stater)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, _ := chain.NewRepository(db, b)
+
+	blockTime := launchTime + luckyshare.BlockInterval
+	flow, err := packer.New(repo, stater, approver.Address, &approver.Address, luckyshare.NoFork).Mock(b.Header(), blockTime, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proposalID = func() luckyshare.Bytes32 {
+		var b8 [8]byte
+		binary.BigEndian.PutUint64(b8[:], blockTime)
+		return luckyshare.Bytes32(crypto.Keccak256Hash(b8[:], approver.Address[:]))
+	}()
+	setParam := mustEncode(sharer.Params.ABI, "set", luckyshare.KeyRewardRatio, big.NewInt(12345))
+	for i, data := range [][]byte{
+		mustEncode(sharer.Executor.ABI, "propose", sharer.Params.Address, setParam),
+		mustEncode(sharer.Executor.ABI, "approve", proposalID),
+	} {
+		tx := new(tx.Builder).
+			ChainTag(repo.ChainTag()).
+			Expiration(10).
+			Gas(1000000).
+			Nonce(uint64(i)).
+			Clause(tx.NewClause(&sharer.Executor.Address).WithData(data)).
+			BlockRef(tx.NewBlockRef(0)).
+			Build()
+		sig, err := crypto.Sign(tx.SigningHash().Bytes(), approver.PrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx = tx.WithSignature(sig)
+		if i == 0 {
+			proposeTxID = tx.ID()
+		}
+		if err := flow.Adopt(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	block, stage, receipts, err := flow.Pack(approver.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range receipts {
+		assert.False(t, r.Reverted)
+	}
+	if _, err := stage.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddBlock(block, receipts); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetBestBlockID(block.Header().ID()); err != nil {
+		t.Fatal(err)
+	}
+
+	logDB, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := logDB.Log(func(w *logdb.Writer) error {
+		// approvers added in genesis
+		if err := w.Write(b, tx.Receipts{{
+			Outputs: []*tx.Output{
+				{Events: events, Transfers: transfers},
+			},
+		}}); err != nil {
+			return err
+		}
+		return w.Write(block, receipts)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	router := mux.NewRouter()
+	New(repo, stater, logDB, false).Mount(router, "/governance")
+	ts = httptest.NewServer(router)
+}
+
+func httpGet(t *testing.T, url string) ([]byte, int) {
+	res, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, res.StatusCode
+}
+
+func httpPost(t *testing.T, url string, body interface{}) ([]byte, int) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.Post(url, "application/x-www-form-urlencoded", bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, res.StatusCode
+}
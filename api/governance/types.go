@@ -0,0 +1,126 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package governance
+
+import (
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+)
+
+// LogMeta locates the tx where a proposal was raised.
+type LogMeta struct {
+	BlockID        luckyshare.Bytes32 `json:"blockID"`
+	BlockNumber    uint32             `json:"blockNumber"`
+	BlockTimestamp uint64             `json:"blockTimestamp"`
+	TxID           luckyshare.Bytes32 `json:"txID"`
+	TxOrigin       luckyshare.Address `json:"txOrigin"`
+	ClauseIndex    uint32             `json:"clauseIndex"`
+}
+
+// Proposal governance proposal with decoded content.
+type Proposal struct {
+	ID            luckyshare.Bytes32   `json:"id"`
+	Proposer      luckyshare.Address   `json:"proposer"`
+	TimeProposed  uint64               `json:"timeProposed"`
+	Quorum        uint8                `json:"quorum"`
+	ApprovalCount uint8                `json:"approvalCount"`
+	Approvals     []luckyshare.Address `json:"approvals"`
+	Executed      bool                 `json:"executed"`
+	Expired       bool                 `json:"expired"`
+	Target        luckyshare.Address   `json:"target"`
+	Data          string               `json:"data"`
+	// human readable content, empty if the call is unknown
+	Description string   `json:"description"`
+	Meta        *LogMeta `json:"meta"`
+}
+
+// Approver governance approver.
+type Approver struct {
+	Address  luckyshare.Address `json:"address"`
+	Identity luckyshare.Bytes32 `json:"identity"`
+	InPower  bool               `json:"inPower"`
+}
+
+// Clause unsigned clause to be included in a tx.
+type Clause struct {
+	To    *luckyshare.Address   `json:"to"`
+	Value *math.HexOrDecimal256 `json:"value"`
+	Data  string                `json:"data"`
+}
+
+// BuiltClause clause built for governance or authority actions.
+type BuiltClause struct {
+	Clause Clause `json:"clause"`
+	// the executor set in params, set only by authority APIs. It's the account expected to sign the tx,
+	// or the builtin executor contract whose approvers propose
+	Executor    *luckyshare.Address `json:"executor,omitempty"`
+	Description string              `json:"description"`
+}
+
+// SetParam content to set a param.
+type SetParam struct {
+	Key   string                `json:"key"`
+	Value *math.HexOrDecimal256 `json:"value"`
+}
+
+// AddAuthority content to add an authority node, or node to be added by authority API.
+type AddAuthority struct {
+	NodeMaster luckyshare.Address `json:"nodeMaster"`
+	Endorsor   luckyshare.Address `json:"endorsor"`
+	Identity   luckyshare.Bytes32 `json:"identity"`
+}
+
+// AddApprover content to add an approver.
+type AddApprover struct {
+	Approver luckyshare.Address `json:"approver"`
+	Identity luckyshare.Bytes32 `json:"identity"`
+}
+
+// ProposeRequest content of a proposal, either raw target and data, or exactly one of the well-known actions.
+type ProposeRequest struct {
+	Target          *luckyshare.Address `json:"target"`
+	Data            string              `json:"data"`
+	SetParam        *SetParam           `json:"setParam"`
+	AddAuthority    *AddAuthority       `json:"addAuthority"`
+	RevokeAuthority *luckyshare.Address `json:"revokeAuthority"`
+	AddApprover     *AddApprover        `json:"addApprover"`
+	RevokeApprover  *luckyshare.Address `json:"revokeApprover"`
+}
+
+// ProposalRef refers to a proposal.
+type ProposalRef struct {
+	ProposalID luckyshare.Bytes32 `json:"proposalID"`
+}
+
+// Candidate registered block proposer candidate.
+type Candidate struct {
+	NodeMaster      luckyshare.Address    `json:"nodeMaster"`
+	Endorsor        luckyshare.Address    `json:"endorsor"`
+	Identity        luckyshare.Bytes32    `json:"identity"`
+	Active          bool                  `json:"active"`
+	EndorsorBalance *math.HexOrDecimal256 `json:"endorsorBalance"`
+	// tokens delegated to the endorsor by stakers
+	Delegated *math.HexOrDecimal256 `json:"delegated"`
+	// whether endorsor balance plus delegated tokens satisfies the endorsement
+	Endorsed bool `json:"endorsed"`
+	// count of blocks signed within the recent blocks
+	SignedBlocks    uint32  `json:"signedBlocks"`
+	LastSignedBlock *uint32 `json:"lastSignedBlock"`
+}
+
+// Candidates all candidates along with the context.
+type Candidates struct {
+	BestBlock   uint32                `json:"bestBlock"`
+	Endorsement *math.HexOrDecimal256 `json:"endorsement"`
+	// count of recent blocks scanned for signers
+	RecentBlocks uint32       `json:"recentBlocks"`
+	Candidates   []*Candidate `json:"candidates"`
+}
+
+// RevokeNode node to be revoked.
+type RevokeNode struct {
+	NodeMaster luckyshare.Address `json:"nodeMaster"`
+}
@@ -10,15 +10,18 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/miniBamboo/luckyshare/api/utils"
+	"github.com/miniBamboo/luckyshare/txpool"
 )
 
 type Node struct {
-	nw Network
+	nw   Network
+	pool *txpool.TxPool
 }
 
-func New(nw Network) *Node {
+func New(nw Network, pool *txpool.TxPool) *Node {
 	return &Node{
 		nw,
+		pool,
 	}
 }
 
@@ -30,8 +33,13 @@ func (n *Node) handleNetwork(w http.ResponseWriter, req *http.Request) error {
 	return utils.WriteJSON(w, n.PeersStats())
 }
 
+func (n *Node) handleTxPoolBlocklist(w http.ResponseWriter, req *http.Request) error {
+	return utils.WriteJSON(w, convertBlocklist(n.pool.Blocklist()))
+}
+
 func (n *Node) Mount(root *mux.Router, pathPrefix string) {
 	sub := root.PathPrefix(pathPrefix).Subrouter()
 
 	sub.Path("/network/peers").Methods("Get").HandlerFunc(utils.WrapHandlerFunc(n.handleNetwork))
+	sub.Path("/txpool/blocklist").Methods("Get").HandlerFunc(utils.WrapHandlerFunc(n.handleTxPoolBlocklist))
 }
@@ -35,6 +35,18 @@ func TestNode(t *testing.T) {
 	assert.Equal(t, 0, len(peersStats), "count should be zero")
 }
 
+func TestTxPoolBlocklist(t *testing.T) {
+	initCommServer(t)
+	res := httpGet(t, ts.URL+"/node/txpool/blocklist")
+	var blocklist node.Blocklist
+	if err := json.Unmarshal(res, &blocklist); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, txpool.BlocklistMergeUnion, blocklist.MergeMode)
+	assert.Equal(t, 0, len(blocklist.Addresses))
+	assert.Equal(t, 0, len(blocklist.Sources))
+}
+
 func initCommServer(t *testing.T) {
 	db := muxdb.NewMem()
 	stater := state.NewStater(db)
@@ -45,13 +57,14 @@ func initCommServer(t *testing.T) {
 		t.Fatal(err)
 	}
 	repo, _ := chain.NewRepository(db, b)
-	comm := commu.New(repo, txpool.New(repo, stater, txpool.Options{
+	pool := txpool.New(repo, stater, txpool.Options{
 		Limit:           10000,
 		LimitPerAccount: 16,
 		MaxLifetime:     10 * time.Minute,
-	}))
+	})
+	comm := commu.New(repo, pool)
 	router := mux.NewRouter()
-	node.New(comm).Mount(router, "/node")
+	node.New(comm, pool).Mount(router, "/node")
 	ts = httptest.NewServer(router)
 }
 
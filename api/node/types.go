@@ -8,6 +8,7 @@ package node
 import (
 	"github.com/miniBamboo/luckyshare/commu"
 	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/txpool"
 )
 
 type Network interface {
@@ -42,3 +43,41 @@ func ConvertPeersStats(ss []*commu.PeerStats) []*PeerStats {
 	}
 	return peersStats
 }
+
+type BlocklistSource struct {
+	URL       string              `json:"url"`
+	Signer    *luckyshare.Address `json:"signer"`
+	Count     int                 `json:"count"`
+	UpdatedAt uint64              `json:"updatedAt"`
+	Error     string              `json:"error,omitempty"`
+	Expired   bool                `json:"expired"`
+}
+
+type Blocklist struct {
+	MergeMode string               `json:"mergeMode"`
+	Addresses []luckyshare.Address `json:"addresses"`
+	Sources   []*BlocklistSource   `json:"sources"`
+}
+
+func convertBlocklist(info *txpool.BlocklistInfo) *Blocklist {
+	bl := &Blocklist{
+		MergeMode: info.MergeMode,
+		Addresses: info.Addresses,
+		Sources:   make([]*BlocklistSource, 0, len(info.Sources)),
+	}
+	for _, src := range info.Sources {
+		var updatedAt uint64
+		if !src.UpdatedAt.IsZero() {
+			updatedAt = uint64(src.UpdatedAt.Unix())
+		}
+		bl.Sources = append(bl.Sources, &BlocklistSource{
+			URL:       src.URL,
+			Signer:    src.Signer,
+			Count:     src.Len,
+			UpdatedAt: updatedAt,
+			Error:     src.Error,
+			Expired:   src.Expired,
+		})
+	}
+	return bl
+}
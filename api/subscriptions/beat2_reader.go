@@ -12,6 +12,7 @@ import (
 	"github.com/miniBamboo/luckyshare/chain"
 	"github.com/miniBamboo/luckyshare/luckyshare"
 	"github.com/miniBamboo/luckyshare/luckyshare/bloom"
+	"github.com/miniBamboo/luckyshare/tx"
 )
 
 type beat2Reader struct {
@@ -64,7 +65,7 @@ func (br *beat2Reader) Read() ([]interface{}, bool, error) {
 					bloomAdd(transfer.Recipient.Bytes())
 				}
 			}
-			origin, _ := txs[i].Origin()
+			_, origin := tx.ExecutionMeta(header.Number(), txs, receipts, i)
 			bloomAdd(origin.Bytes())
 		}
 		signer, _ := header.Signer()
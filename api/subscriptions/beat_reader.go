@@ -12,6 +12,7 @@ import (
 	"github.com/miniBamboo/luckyshare/chain"
 	"github.com/miniBamboo/luckyshare/luckyshare"
 	"github.com/miniBamboo/luckyshare/luckyshare/bloom"
+	"github.com/miniBamboo/luckyshare/tx"
 )
 
 type beatReader struct {
@@ -54,7 +55,7 @@ func (br *beatReader) Read() ([]interface{}, bool, error) {
 					bloomContent.add(transfer.Recipient.Bytes())
 				}
 			}
-			origin, _ := txs[i].Origin()
+			_, origin := tx.ExecutionMeta(header.Number(), txs, receipts, i)
 			bloomContent.add(origin.Bytes())
 		}
 		signer, _ := header.Signer()
@@ -6,20 +6,24 @@
 package subscriptions
 
 import (
+	"github.com/miniBamboo/luckyshare/api/abis"
 	"github.com/miniBamboo/luckyshare/chain"
 	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/tx"
 )
 
 type eventReader struct {
 	repo        *chain.Repository
 	filter      *EventFilter
+	abis        *abis.ABIs
 	blockReader chain.BlockReader
 }
 
-func newEventReader(repo *chain.Repository, position luckyshare.Bytes32, filter *EventFilter) *eventReader {
+func newEventReader(repo *chain.Repository, position luckyshare.Bytes32, filter *EventFilter, abis *abis.ABIs) *eventReader {
 	return &eventReader{
 		repo:        repo,
 		filter:      filter,
+		abis:        abis,
 		blockReader: repo.NewBlockReader(position),
 	}
 }
@@ -37,13 +41,12 @@ func (er *eventReader) Read() ([]interface{}, bool, error) {
 		}
 		txs := block.Transactions()
 		for i, receipt := range receipts {
+			txID, origin := tx.ExecutionMeta(block.Header().Number(), txs, receipts, i)
 			for j, output := range receipt.Outputs {
 				for _, event := range output.Events {
 					if er.filter.Match(event) {
-						msg, err := convertEvent(block.Header(), txs[i], uint32(j), event, block.Obsolete)
-						if err != nil {
-							return nil, false, err
-						}
+						msg := convertEvent(block.Header(), txID, origin, uint32(j), event, block.Obsolete)
+						msg.Decoded = er.abis.Decode(nil, event.Address, event.Topics, event.Data)
 						msgs = append(msgs, msg)
 					}
 				}
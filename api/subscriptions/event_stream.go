@@ -0,0 +1,136 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package subscriptions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/miniBamboo/luckyshare/api/utils"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/pkg/errors"
+)
+
+// isEventStream returns whether the client requests server-sent events rather than websocket.
+func isEventStream(req *http.Request) bool {
+	return strings.Contains(req.Header.Get("Accept"), "text/event-stream")
+}
+
+// msgPosition returns the position, from which a reader resumes right after the msg.
+// Obsolete blocks resume from their parent, while obsolete logs from their block,
+// so that the obsolete logs of the block may be sent again.
+func msgPosition(msg interface{}) (luckyshare.Bytes32, bool) {
+	switch msg := msg.(type) {
+	case *BlockMessage:
+		if msg.Obsolete {
+			return msg.ParentID, true
+		}
+		return msg.ID, true
+	case *BeatMessage:
+		if msg.Obsolete {
+			return msg.ParentID, true
+		}
+		return msg.ID, true
+	case *Beat2Message:
+		if msg.Obsolete {
+			return msg.ParentID, true
+		}
+		return msg.ID, true
+	case *EventMessage:
+		return msg.Meta.BlockID, true
+	case *TransferMessage:
+		return msg.Meta.BlockID, true
+	}
+	return luckyshare.Bytes32{}, false
+}
+
+// stream writes msgs as server-sent events.
+// The event id is set to the position when all msgs of a block are sent, so that clients
+// reconnecting with 'Last-Event-ID' header resume after the block.
+func (s *Subscriptions) stream(w http.ResponseWriter, req *http.Request, reader msgReader) error {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return utils.HTTPError(errors.New("streaming unsupported"), http.StatusInternalServerError)
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// disable buffering of proxies like nginx
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// since the header is written, no error should be returned in lines below
+	if err := s.pipeEvents(w, flusher, req, reader); err != nil {
+		data, _ := json.Marshal(err.Error())
+		fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+		flusher.Flush()
+	}
+	return nil
+}
+
+func (s *Subscriptions) pipeEvents(w http.ResponseWriter, flusher http.Flusher, req *http.Request, reader msgReader) error {
+	// wait for new blocks by default
+	wait := s.repo.NewTicker().C
+	if n, ok := reader.(notifier); ok {
+		wait = n.Notify
+	}
+	// comment lines keep the connection alive through proxies
+	pingTicker := time.NewTicker(pingPeriod)
+	defer pingTicker.Stop()
+	for {
+		msgs, hasMore, err := reader.Read()
+		if err != nil {
+			return err
+		}
+		for i, msg := range msgs {
+			data, err := json.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n", data); err != nil {
+				log.Debug("write event stream", "err", err)
+				return nil
+			}
+			if pos, ok := msgPosition(msg); ok {
+				// the last msg of the block
+				if i == len(msgs)-1 {
+					fmt.Fprintf(w, "id: %v\n", pos)
+				} else if next, _ := msgPosition(msgs[i+1]); next != pos {
+					fmt.Fprintf(w, "id: %v\n", pos)
+				}
+			}
+			fmt.Fprint(w, "\n")
+		}
+		flusher.Flush()
+
+		if hasMore {
+			select {
+			case <-s.done:
+				return nil
+			case <-req.Context().Done():
+				return nil
+			default:
+			}
+		} else {
+			select {
+			case <-s.done:
+				return nil
+			case <-req.Context().Done():
+				return nil
+			case <-wait():
+			case <-pingTicker.C:
+				if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+					log.Debug("write event stream", "err", err)
+					return nil
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
@@ -0,0 +1,312 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package subscriptions
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxSubscriptionsPerConn limits subscriptions alive on a multiplexed connection.
+	maxSubscriptionsPerConn = 256
+	// outQueueSize is the number of messages buffered for writing to a multiplexed connection.
+	// Subscriptions stop reading once it's full, until the client catches up.
+	outQueueSize = 256
+)
+
+// error codes of the multiplex protocol, follow JSON-RPC 2.0.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeSubscription   = -32000
+)
+
+// MuxRequest request message sent by client on multiplexed connection.
+//
+// Methods:
+//
+//	subscribe   params: {"subject": "block|event|transfer|beat2|...", <query params of the subject>}
+//	            result: subscription id
+//	unsubscribe params: {"subscription": "<subscription id>"}
+//	            result: true
+type MuxRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params map[string]string `json:"params"`
+}
+
+// MuxError error of a request or a subscription.
+type MuxError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// MuxResponse response message to a request.
+type MuxResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result interface{}     `json:"result,omitempty"`
+	Error  *MuxError       `json:"error,omitempty"`
+}
+
+// MuxNotification message pushed for a subscription.
+// A notification with error means the subscription is terminated, and the client
+// may resubscribe with 'pos' set to the last block id it received.
+type MuxNotification struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result,omitempty"`
+	Error        *MuxError   `json:"error,omitempty"`
+}
+
+// muxConn serves many subscriptions on one websocket connection.
+// All writes go through out queue, so that only the write loop writes to conn.
+type muxConn struct {
+	s      *Subscriptions
+	conn   *websocket.Conn
+	out    chan interface{}
+	closed chan struct{}
+	// closed when write loop exits
+	writerDone chan struct{}
+	wg         sync.WaitGroup
+
+	lock   sync.Mutex
+	subs   map[string]chan struct{}
+	nextID uint64
+}
+
+func (s *Subscriptions) handleMultiplex(w http.ResponseWriter, req *http.Request) error {
+	s.wg.Add(1)
+	defer s.wg.Done()
+
+	conn, err := s.upgrader.Upgrade(w, req, nil)
+	// since the conn is hijacked here, no error should be returned in lines below
+	if err != nil {
+		log.Debug("upgrade to websocket", "err", err)
+		return nil
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Debug("close websocket", "err", err)
+		}
+	}()
+
+	mc := &muxConn{
+		s:          s,
+		conn:       conn,
+		out:        make(chan interface{}, outQueueSize),
+		closed:     make(chan struct{}),
+		writerDone: make(chan struct{}),
+		subs:       make(map[string]chan struct{}),
+	}
+	mc.serve()
+	return nil
+}
+
+func (mc *muxConn) serve() {
+	mc.wg.Add(1)
+	go func() {
+		defer mc.wg.Done()
+		defer close(mc.writerDone)
+		mc.writeLoop()
+	}()
+
+	mc.conn.SetReadDeadline(time.Now().Add(pongWait))
+	mc.conn.SetPongHandler(func(string) error {
+		mc.conn.SetReadDeadline(time.Now().Add(pongWait))
+		return nil
+	})
+	for {
+		_, data, err := mc.conn.ReadMessage()
+		if err != nil {
+			log.Debug("websocket read err", "err", err)
+			break
+		}
+		var req MuxRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			mc.send(&MuxResponse{Error: &MuxError{codeParseError, err.Error()}}, nil)
+			continue
+		}
+		mc.send(mc.handleRequest(&req), nil)
+	}
+
+	// stop write loop and all subscriptions
+	close(mc.closed)
+	mc.wg.Wait()
+}
+
+func (mc *muxConn) writeLoop() {
+	pingTicker := time.NewTicker(pingPeriod)
+	defer pingTicker.Stop()
+	for {
+		select {
+		case msg := <-mc.out:
+			if err := mc.conn.WriteJSON(msg); err != nil {
+				log.Debug("websocket write err", "err", err)
+				// to break the read loop
+				mc.conn.Close()
+				return
+			}
+		case <-pingTicker.C:
+			mc.conn.WriteMessage(websocket.PingMessage, nil)
+		case <-mc.closed:
+			return
+		case <-mc.s.done:
+			closeMsg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "")
+			if err := mc.conn.WriteMessage(websocket.CloseMessage, closeMsg); err != nil {
+				log.Debug("write close message", "err", err)
+			}
+			mc.conn.Close()
+			return
+		}
+	}
+}
+
+// send queues the msg for writing. It blocks while the queue is full, and returns false
+// if the connection or the subscription (when done is not nil) is closed.
+func (mc *muxConn) send(msg interface{}, done <-chan struct{}) bool {
+	select {
+	case mc.out <- msg:
+		return true
+	case <-done:
+		return false
+	case <-mc.closed:
+		return false
+	case <-mc.writerDone:
+		return false
+	}
+}
+
+func (mc *muxConn) handleRequest(req *MuxRequest) *MuxResponse {
+	if len(req.ID) == 0 {
+		return &MuxResponse{Error: &MuxError{codeInvalidRequest, "id: required"}}
+	}
+	switch req.Method {
+	case "subscribe":
+		id, err := mc.subscribe(req.Params)
+		if err != nil {
+			return &MuxResponse{ID: req.ID, Error: &MuxError{codeInvalidParams, err.Error()}}
+		}
+		return &MuxResponse{ID: req.ID, Result: id}
+	case "unsubscribe":
+		if !mc.unsubscribe(req.Params["subscription"]) {
+			return &MuxResponse{ID: req.ID, Error: &MuxError{codeInvalidParams, "subscription: not found"}}
+		}
+		return &MuxResponse{ID: req.ID, Result: true}
+	default:
+		return &MuxResponse{ID: req.ID, Error: &MuxError{codeMethodNotFound, "method: not found"}}
+	}
+}
+
+func (mc *muxConn) subscribe(params map[string]string) (string, error) {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	if len(mc.subs) >= maxSubscriptionsPerConn {
+		return "", errors.New("too many subscriptions")
+	}
+
+	query := make(url.Values, len(params))
+	for k, v := range params {
+		if k != "subject" {
+			query.Set(k, v)
+		}
+	}
+	reader, err := mc.s.newReader(params["subject"], query)
+	if err != nil {
+		if params["subject"] == "" {
+			return "", errors.New("subject: required")
+		}
+		return "", err
+	}
+
+	mc.nextID++
+	id := "0x" + strconv.FormatUint(mc.nextID, 16)
+	done := make(chan struct{})
+	mc.subs[id] = done
+
+	mc.wg.Add(1)
+	go func() {
+		defer mc.wg.Done()
+		if closer, ok := reader.(io.Closer); ok {
+			defer closer.Close()
+		}
+		if err := mc.pipe(id, reader, done); err != nil {
+			// terminated by error, remove it unless already unsubscribed
+			if mc.remove(id) {
+				mc.send(&MuxNotification{Subscription: id, Error: &MuxError{codeSubscription, err.Error()}}, nil)
+			}
+		}
+	}()
+	return id, nil
+}
+
+func (mc *muxConn) unsubscribe(id string) bool {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	done, ok := mc.subs[id]
+	if ok {
+		delete(mc.subs, id)
+		close(done)
+	}
+	return ok
+}
+
+func (mc *muxConn) remove(id string) bool {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	_, ok := mc.subs[id]
+	delete(mc.subs, id)
+	return ok
+}
+
+// pipe is like Subscriptions.pipe, but wraps msgs with the subscription id.
+func (mc *muxConn) pipe(id string, reader msgReader, done <-chan struct{}) error {
+	// wait for new blocks by default
+	wait := mc.s.repo.NewTicker().C
+	if n, ok := reader.(notifier); ok {
+		wait = n.Notify
+	}
+	for {
+		msgs, hasMore, err := reader.Read()
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			if !mc.send(&MuxNotification{Subscription: id, Result: msg}, done) {
+				return nil
+			}
+		}
+		if hasMore {
+			select {
+			case <-done:
+				return nil
+			case <-mc.closed:
+				return nil
+			default:
+			}
+		} else {
+			select {
+			case <-done:
+				return nil
+			case <-mc.closed:
+				return nil
+			case <-wait():
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package subscriptions
+
+import (
+	"github.com/miniBamboo/luckyshare/api/abis"
+)
+
+// pendingEventReader reads events that txs would emit once they become executable in the pool.
+// Txs are dry-run by the hub on the next block of the best block.
+type pendingEventReader struct {
+	hub    *txEventHub
+	sub    *txEventSub
+	filter *EventFilter
+	abis   *abis.ABIs
+}
+
+func newPendingEventReader(hub *txEventHub, filter *EventFilter, abis *abis.ABIs) *pendingEventReader {
+	return &pendingEventReader{
+		hub:    hub,
+		sub:    hub.subscribe(true),
+		filter: filter,
+		abis:   abis,
+	}
+}
+
+func (pr *pendingEventReader) Read() ([]interface{}, bool, error) {
+	events, err := pr.sub.take()
+	if err != nil {
+		return nil, false, err
+	}
+	var msgs []interface{}
+	for _, ev := range events {
+		if ev.Receipt == nil {
+			continue
+		}
+		// origin of tx in pool is always valid
+		origin, _ := ev.Tx.Origin()
+		for i, output := range ev.Receipt.Outputs {
+			for _, event := range output.Events {
+				if pr.filter.Match(event) {
+					msg := convertPendingEvent(ev.Tx.ID(), origin, uint32(i), event)
+					msg.Decoded = pr.abis.Decode(nil, event.Address, event.Topics, event.Data)
+					msgs = append(msgs, msg)
+				}
+			}
+		}
+	}
+	return msgs, false, nil
+}
+
+func (pr *pendingEventReader) Notify() <-chan bool {
+	return pr.sub.notify
+}
+
+func (pr *pendingEventReader) Close() error {
+	pr.hub.unsubscribe(pr.sub)
+	return nil
+}
@@ -6,23 +6,31 @@
 package subscriptions
 
 import (
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/inconshreveable/log15"
+	"github.com/miniBamboo/luckyshare/api/abis"
 	"github.com/miniBamboo/luckyshare/api/utils"
 	"github.com/miniBamboo/luckyshare/block"
 	"github.com/miniBamboo/luckyshare/chain"
 	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/miniBamboo/luckyshare/txpool"
 	"github.com/pkg/errors"
 )
 
 type Subscriptions struct {
 	backtraceLimit uint32
 	repo           *chain.Repository
+	abis           *abis.ABIs
+	txEvents       *txEventHub
 	upgrader       *websocket.Upgrader
 	done           chan struct{}
 	wg             sync.WaitGroup
@@ -32,6 +40,11 @@ type msgReader interface {
 	Read() (msgs []interface{}, hasMore bool, err error)
 }
 
+// notifier is implemented by readers not driven by new blocks, to signal that messages arrived.
+type notifier interface {
+	Notify() <-chan bool
+}
+
 var (
 	log = log15.New("pkg", "subscriptions")
 )
@@ -43,10 +56,20 @@ const (
 	pingPeriod = (pongWait * 7) / 10
 )
 
-func New(repo *chain.Repository, allowedOrigins []string, backtraceLimit uint32) *Subscriptions {
-	return &Subscriptions{
+func New(
+	repo *chain.Repository,
+	allowedOrigins []string,
+	backtraceLimit uint32,
+	abis *abis.ABIs,
+	txPool *txpool.TxPool,
+	stater *state.Stater,
+	forkConfig luckyshare.ForkConfig,
+) *Subscriptions {
+	s := &Subscriptions{
 		backtraceLimit: backtraceLimit,
 		repo:           repo,
+		abis:           abis,
+		txEvents:       newTxEventHub(repo, stater, forkConfig),
 		upgrader: &websocket.Upgrader{
 			EnableCompression: true,
 			CheckOrigin: func(r *http.Request) bool {
@@ -64,70 +87,128 @@ func New(repo *chain.Repository, allowedOrigins []string, backtraceLimit uint32)
 		},
 		done: make(chan struct{}),
 	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.txEvents.run(txPool, s.done)
+	}()
+	return s
 }
 
-func (s *Subscriptions) handleBlockReader(w http.ResponseWriter, req *http.Request) (*blockReader, error) {
-	position, err := s.parsePosition(req.URL.Query().Get("pos"))
+func (s *Subscriptions) handleBlockReader(query url.Values) (*blockReader, error) {
+	position, err := s.parsePosition(query.Get("pos"))
 	if err != nil {
 		return nil, err
 	}
 	return newBlockReader(s.repo, position), nil
 }
 
-func (s *Subscriptions) handleEventReader(w http.ResponseWriter, req *http.Request) (*eventReader, error) {
-	position, err := s.parsePosition(req.URL.Query().Get("pos"))
+func (s *Subscriptions) handleEventReader(query url.Values) (*eventReader, error) {
+	position, err := s.parsePosition(query.Get("pos"))
 	if err != nil {
 		return nil, err
 	}
-	address, err := parseAddress(req.URL.Query().Get("addr"))
+	eventFilter, err := s.parseEventFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	return newEventReader(s.repo, position, eventFilter, s.abis), nil
+}
+
+func (s *Subscriptions) parseEventFilter(query url.Values) (*EventFilter, error) {
+	address, err := parseAddress(query.Get("addr"))
 	if err != nil {
 		return nil, utils.BadRequest(errors.WithMessage(err, "addr"))
 	}
-	t0, err := parseTopic(req.URL.Query().Get("t0"))
+	t0, err := parseTopic(query.Get("t0"))
 	if err != nil {
 		return nil, utils.BadRequest(errors.WithMessage(err, "t0"))
 	}
-	t1, err := parseTopic(req.URL.Query().Get("t1"))
+	t1, err := parseTopic(query.Get("t1"))
 	if err != nil {
 		return nil, utils.BadRequest(errors.WithMessage(err, "t1"))
 	}
-	t2, err := parseTopic(req.URL.Query().Get("t2"))
+	t2, err := parseTopic(query.Get("t2"))
 	if err != nil {
 		return nil, utils.BadRequest(errors.WithMessage(err, "t2"))
 	}
-	t3, err := parseTopic(req.URL.Query().Get("t3"))
+	t3, err := parseTopic(query.Get("t3"))
 	if err != nil {
 		return nil, utils.BadRequest(errors.WithMessage(err, "t3"))
 	}
-	t4, err := parseTopic(req.URL.Query().Get("t4"))
+	t4, err := parseTopic(query.Get("t4"))
 	if err != nil {
 		return nil, utils.BadRequest(errors.WithMessage(err, "t4"))
 	}
-	eventFilter := &EventFilter{
+	topics := [5]*luckyshare.Bytes32{t0, t1, t2, t3, t4}
+
+	// indexed args given by name in the form of 'arg.<name>=<value>'
+	args := make(map[string]string)
+	for key, values := range query {
+		if name := strings.TrimPrefix(key, "arg."); name != key && len(values) > 0 {
+			args[name] = values[0]
+		}
+	}
+	if name := query.Get("event"); name != "" {
+		ev, found := s.abis.EventByName(nil, address, name)
+		if !found {
+			return nil, utils.BadRequest(errors.New("event: not found"))
+		}
+		if err := abis.ResolveTopics(&topics, ev, args); err != nil {
+			return nil, utils.BadRequest(errors.WithMessage(err, "event"))
+		}
+	} else if len(args) > 0 {
+		return nil, utils.BadRequest(errors.New("event: required by args"))
+	}
+
+	return &EventFilter{
 		Address: address,
-		Topic0:  t0,
-		Topic1:  t1,
-		Topic2:  t2,
-		Topic3:  t3,
-		Topic4:  t4,
+		Topic0:  topics[0],
+		Topic1:  topics[1],
+		Topic2:  topics[2],
+		Topic3:  topics[3],
+		Topic4:  topics[4],
+	}, nil
+}
+
+func (s *Subscriptions) handleTxPoolReader(query url.Values) (*txPoolReader, error) {
+	origin, err := parseAddress(query.Get("origin"))
+	if err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, "origin"))
+	}
+	target, err := parseAddress(query.Get("target"))
+	if err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, "target"))
+	}
+	return newTxPoolReader(s.txEvents, &TxPoolFilter{
+		Origin: origin,
+		Target: target,
+	}), nil
+}
+
+func (s *Subscriptions) handlePendingEventReader(query url.Values) (*pendingEventReader, error) {
+	eventFilter, err := s.parseEventFilter(query)
+	if err != nil {
+		return nil, err
 	}
-	return newEventReader(s.repo, position, eventFilter), nil
+	return newPendingEventReader(s.txEvents, eventFilter, s.abis), nil
 }
 
-func (s *Subscriptions) handleTransferReader(w http.ResponseWriter, req *http.Request) (*transferReader, error) {
-	position, err := s.parsePosition(req.URL.Query().Get("pos"))
+func (s *Subscriptions) handleTransferReader(query url.Values) (*transferReader, error) {
+	position, err := s.parsePosition(query.Get("pos"))
 	if err != nil {
 		return nil, err
 	}
-	txOrigin, err := parseAddress(req.URL.Query().Get("txOrigin"))
+	txOrigin, err := parseAddress(query.Get("txOrigin"))
 	if err != nil {
 		return nil, utils.BadRequest(errors.WithMessage(err, "txOrigin"))
 	}
-	sender, err := parseAddress(req.URL.Query().Get("sender"))
+	sender, err := parseAddress(query.Get("sender"))
 	if err != nil {
 		return nil, utils.BadRequest(errors.WithMessage(err, "sender"))
 	}
-	recipient, err := parseAddress(req.URL.Query().Get("recipient"))
+	recipient, err := parseAddress(query.Get("recipient"))
 	if err != nil {
 		return nil, utils.BadRequest(errors.WithMessage(err, "recipient"))
 	}
@@ -139,53 +220,65 @@ func (s *Subscriptions) handleTransferReader(w http.ResponseWriter, req *http.Re
 	return newTransferReader(s.repo, position, transferFilter), nil
 }
 
-func (s *Subscriptions) handleBeatReader(w http.ResponseWriter, req *http.Request) (*beatReader, error) {
-	position, err := s.parsePosition(req.URL.Query().Get("pos"))
+func (s *Subscriptions) handleBeatReader(query url.Values) (*beatReader, error) {
+	position, err := s.parsePosition(query.Get("pos"))
 	if err != nil {
 		return nil, err
 	}
 	return newBeatReader(s.repo, position), nil
 }
 
-func (s *Subscriptions) handleBeat2Reader(w http.ResponseWriter, req *http.Request) (*beat2Reader, error) {
-	position, err := s.parsePosition(req.URL.Query().Get("pos"))
+func (s *Subscriptions) handleBeat2Reader(query url.Values) (*beat2Reader, error) {
+	position, err := s.parsePosition(query.Get("pos"))
 	if err != nil {
 		return nil, err
 	}
 	return newBeat2Reader(s.repo, position), nil
 }
 
-func (s *Subscriptions) handleSubject(w http.ResponseWriter, req *http.Request) error {
-	s.wg.Add(1)
-	defer s.wg.Done()
-
-	var (
-		reader msgReader
-		err    error
-	)
-	switch mux.Vars(req)["subject"] {
+// newReader creates the reader of the subject, with params in query.
+func (s *Subscriptions) newReader(subject string, query url.Values) (msgReader, error) {
+	switch subject {
 	case "block":
-		if reader, err = s.handleBlockReader(w, req); err != nil {
-			return err
-		}
+		return s.handleBlockReader(query)
 	case "event":
-		if reader, err = s.handleEventReader(w, req); err != nil {
-			return err
-		}
+		return s.handleEventReader(query)
 	case "transfer":
-		if reader, err = s.handleTransferReader(w, req); err != nil {
-			return err
-		}
+		return s.handleTransferReader(query)
 	case "beat":
-		if reader, err = s.handleBeatReader(w, req); err != nil {
-			return err
-		}
+		return s.handleBeatReader(query)
 	case "beat2":
-		if reader, err = s.handleBeat2Reader(w, req); err != nil {
-			return err
-		}
+		return s.handleBeat2Reader(query)
+	case "txpool":
+		return s.handleTxPoolReader(query)
+	case "pending-event":
+		return s.handlePendingEventReader(query)
 	default:
-		return utils.HTTPError(errors.New("not found"), http.StatusNotFound)
+		return nil, utils.HTTPError(errors.New("not found"), http.StatusNotFound)
+	}
+}
+
+func (s *Subscriptions) handleSubject(w http.ResponseWriter, req *http.Request) error {
+	s.wg.Add(1)
+	defer s.wg.Done()
+
+	query := req.URL.Query()
+	eventStream := isEventStream(req)
+	if eventStream {
+		// resume from the position of the last received event
+		if lastID := req.Header.Get("Last-Event-ID"); lastID != "" {
+			query.Set("pos", lastID)
+		}
+	}
+	reader, err := s.newReader(mux.Vars(req)["subject"], query)
+	if err != nil {
+		return err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if eventStream {
+		return s.stream(w, req, reader)
 	}
 
 	conn, err := s.upgrader.Upgrade(w, req, nil)
@@ -233,7 +326,11 @@ func (s *Subscriptions) pipe(conn *websocket.Conn, reader msgReader) error {
 			}
 		}
 	}()
-	ticker := s.repo.NewTicker()
+	// wait for new blocks by default
+	wait := s.repo.NewTicker().C
+	if n, ok := reader.(notifier); ok {
+		wait = n.Notify
+	}
 	pingTicker := time.NewTicker(pingPeriod)
 	defer pingTicker.Stop()
 	for {
@@ -262,7 +359,7 @@ func (s *Subscriptions) pipe(conn *websocket.Conn, reader msgReader) error {
 				return nil
 			case <-closed:
 				return nil
-			case <-ticker.C():
+			case <-wait():
 			case <-pingTicker.C:
 				conn.WriteMessage(websocket.PingMessage, nil)
 			}
@@ -315,5 +412,6 @@ func (s *Subscriptions) Close() {
 func (s *Subscriptions) Mount(root *mux.Router, pathPrefix string) {
 	sub := root.PathPrefix(pathPrefix).Subrouter()
 
+	sub.Path("/ws").Methods("Get").HandlerFunc(utils.WrapHandlerFunc(s.handleMultiplex))
 	sub.Path("/{subject}").Methods("Get").HandlerFunc(utils.WrapHandlerFunc(s.handleSubject))
 }
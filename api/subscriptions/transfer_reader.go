@@ -8,6 +8,7 @@ package subscriptions
 import (
 	"github.com/miniBamboo/luckyshare/chain"
 	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/tx"
 )
 
 type transferReader struct {
@@ -37,18 +38,11 @@ func (tr *transferReader) Read() ([]interface{}, bool, error) {
 		}
 		txs := block.Transactions()
 		for i, receipt := range receipts {
+			txID, origin := tx.ExecutionMeta(block.Header().Number(), txs, receipts, i)
 			for j, output := range receipt.Outputs {
 				for _, transfer := range output.Transfers {
-					origin, err := txs[i].Origin()
-					if err != nil {
-						return nil, false, err
-					}
 					if tr.filter.Match(transfer, origin) {
-						msg, err := convertTransfer(block.Header(), txs[i], uint32(j), transfer, block.Obsolete)
-						if err != nil {
-							return nil, false, err
-						}
-						msgs = append(msgs, msg)
+						msgs = append(msgs, convertTransfer(block.Header(), txID, origin, uint32(j), transfer, block.Obsolete))
 					}
 				}
 			}
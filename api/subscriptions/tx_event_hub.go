@@ -0,0 +1,173 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package subscriptions
+
+import (
+	"sync"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/miniBamboo/luckyshare/chain"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/packer"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/miniBamboo/luckyshare/txpool"
+	"github.com/pkg/errors"
+)
+
+// maxQueuedTxEvents limits tx events queued for a subscriber.
+const maxQueuedTxEvents = 4096
+
+var errTxEventsOverflow = errors.New("too many tx events queued, consume faster")
+
+// txEvent is the tx event of the pool, along with the receipt of dry-run.
+type txEvent struct {
+	*txpool.TxEvent
+	Receipt *tx.Receipt // nil if not dry-run
+}
+
+// txEventHub fans out tx events of the pool to subscribers.
+// Events are queued for each subscriber rather than blocking the pool,
+// and a subscriber falling behind too far gets an error.
+// If any subscriber asks for dry-run, txs are dry-run once they become executable,
+// and the receipt is shared among subscribers.
+type txEventHub struct {
+	repo       *chain.Repository
+	stater     *state.Stater
+	forkConfig luckyshare.ForkConfig
+	dryRun     *simplelru.LRU // ids of txs dry-run, since executable events may be posted repeatedly for the same tx
+
+	lock sync.Mutex
+	subs map[*txEventSub]struct{}
+}
+
+type txEventSub struct {
+	dryRun   bool
+	lock     sync.Mutex
+	queue    []*txEvent
+	overflow bool
+	notify   chan bool
+}
+
+func newTxEventHub(repo *chain.Repository, stater *state.Stater, forkConfig luckyshare.ForkConfig) *txEventHub {
+	dryRun, _ := simplelru.NewLRU(1024, nil)
+	return &txEventHub{
+		repo:       repo,
+		stater:     stater,
+		forkConfig: forkConfig,
+		dryRun:     dryRun,
+		subs:       make(map[*txEventSub]struct{}),
+	}
+}
+
+// run dispatches tx events from the pool until done.
+func (h *txEventHub) run(txPool *txpool.TxPool, done <-chan struct{}) {
+	ch := make(chan *txpool.TxEvent)
+	sub := txPool.SubscribeTxEvent(ch)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-done:
+			return
+		case ev := <-ch:
+			h.dispatch(ev)
+		}
+	}
+}
+
+func (h *txEventHub) dispatch(ev *txpool.TxEvent) {
+	e := &txEvent{TxEvent: ev}
+	if !ev.Removed && ev.Executable != nil && *ev.Executable && h.wantsDryRun() && !h.dryRun.Contains(ev.Tx.ID()) {
+		h.dryRun.Add(ev.Tx.ID(), struct{}{})
+
+		receipt, err := h.execute(ev.Tx)
+		if err != nil {
+			// the tx may be no longer executable on the best state
+			log.Debug("dry-run pending tx", "id", ev.Tx.ID(), "err", err)
+		} else {
+			e.Receipt = receipt
+		}
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for s := range h.subs {
+		s.push(e)
+	}
+}
+
+func (h *txEventHub) wantsDryRun() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for s := range h.subs {
+		if s.dryRun {
+			return true
+		}
+	}
+	return false
+}
+
+// execute dry-runs the tx as if it's packed in the next block of the best block,
+// on the state prepared the same way as the packer does.
+func (h *txEventHub) execute(trx *tx.Transaction) (*tx.Receipt, error) {
+	best := h.repo.BestBlock().Header()
+	signer, _ := best.Signer()
+	flow, err := packer.New(h.repo, h.stater, signer, nil, h.forkConfig).
+		Mock(best, best.Timestamp()+luckyshare.BlockInterval, best.GasLimit())
+	if err != nil {
+		return nil, err
+	}
+	if err := flow.Adopt(trx); err != nil {
+		return nil, err
+	}
+	receipts := flow.Receipts()
+	return receipts[len(receipts)-1], nil
+}
+
+// subscribe adds a subscriber. If dryRun is true, receipts of dry-run are attached to events of executable txs.
+func (h *txEventHub) subscribe(dryRun bool) *txEventSub {
+	s := &txEventSub{dryRun: dryRun, notify: make(chan bool, 1)}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.subs[s] = struct{}{}
+	return s
+}
+
+func (h *txEventHub) unsubscribe(s *txEventSub) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	delete(h.subs, s)
+}
+
+func (s *txEventSub) push(ev *txEvent) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.queue) >= maxQueuedTxEvents {
+		s.overflow = true
+	} else {
+		s.queue = append(s.queue, ev)
+	}
+	select {
+	case s.notify <- true:
+	default:
+	}
+}
+
+// take takes all queued events.
+func (s *txEventSub) take() ([]*txEvent, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.overflow {
+		return nil, errTxEventsOverflow
+	}
+	queue := s.queue
+	s.queue = nil
+	return queue, nil
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package subscriptions
+
+type txPoolReader struct {
+	hub    *txEventHub
+	sub    *txEventSub
+	filter *TxPoolFilter
+}
+
+func newTxPoolReader(hub *txEventHub, filter *TxPoolFilter) *txPoolReader {
+	return &txPoolReader{
+		hub:    hub,
+		sub:    hub.subscribe(false),
+		filter: filter,
+	}
+}
+
+func (tr *txPoolReader) Read() ([]interface{}, bool, error) {
+	events, err := tr.sub.take()
+	if err != nil {
+		return nil, false, err
+	}
+	var msgs []interface{}
+	for _, ev := range events {
+		// origin of tx in pool is always valid
+		origin, _ := ev.Tx.Origin()
+		if tr.filter.Match(ev.Tx, origin) {
+			msgs = append(msgs, convertTxEvent(ev.TxEvent, origin))
+		}
+	}
+	return msgs, false, nil
+}
+
+func (tr *txPoolReader) Notify() <-chan bool {
+	return tr.sub.notify
+}
+
+func (tr *txPoolReader) Close() error {
+	tr.hub.unsubscribe(tr.sub)
+	return nil
+}
@@ -8,10 +8,12 @@ package subscriptions
 import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/miniBamboo/luckyshare/api/abis"
 	"github.com/miniBamboo/luckyshare/block"
 	"github.com/miniBamboo/luckyshare/chain"
 	"github.com/miniBamboo/luckyshare/luckyshare"
 	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/miniBamboo/luckyshare/txpool"
 )
 
 //BlockMessage block piped by websocket
@@ -84,12 +86,7 @@ type TransferMessage struct {
 	Obsolete  bool                  `json:"obsolete"`
 }
 
-func convertTransfer(header *block.Header, tx *tx.Transaction, clauseIndex uint32, transfer *tx.Transfer, obsolete bool) (*TransferMessage, error) {
-	origin, err := tx.Origin()
-	if err != nil {
-		return nil, err
-	}
-
+func convertTransfer(header *block.Header, txID luckyshare.Bytes32, txOrigin luckyshare.Address, clauseIndex uint32, transfer *tx.Transfer, obsolete bool) *TransferMessage {
 	return &TransferMessage{
 		Sender:    transfer.Sender,
 		Recipient: transfer.Recipient,
@@ -98,12 +95,12 @@ func convertTransfer(header *block.Header, tx *tx.Transaction, clauseIndex uint3
 			BlockID:        header.ID(),
 			BlockNumber:    header.Number(),
 			BlockTimestamp: header.Timestamp(),
-			TxID:           tx.ID(),
-			TxOrigin:       origin,
+			TxID:           txID,
+			TxOrigin:       txOrigin,
 			ClauseIndex:    clauseIndex,
 		},
 		Obsolete: obsolete,
-	}, nil
+	}
 }
 
 //EventMessage event piped by websocket
@@ -113,13 +110,10 @@ type EventMessage struct {
 	Data     string               `json:"data"`
 	Meta     LogMeta              `json:"meta"`
 	Obsolete bool                 `json:"obsolete"`
+	Decoded  *abis.DecodedEvent   `json:"decoded,omitempty"`
 }
 
-func convertEvent(header *block.Header, tx *tx.Transaction, clauseIndex uint32, event *tx.Event, obsolete bool) (*EventMessage, error) {
-	signer, err := tx.Origin()
-	if err != nil {
-		return nil, err
-	}
+func convertEvent(header *block.Header, txID luckyshare.Bytes32, txOrigin luckyshare.Address, clauseIndex uint32, event *tx.Event, obsolete bool) *EventMessage {
 	return &EventMessage{
 		Address: event.Address,
 		Data:    hexutil.Encode(event.Data),
@@ -127,13 +121,13 @@ func convertEvent(header *block.Header, tx *tx.Transaction, clauseIndex uint32,
 			BlockID:        header.ID(),
 			BlockNumber:    header.Number(),
 			BlockTimestamp: header.Timestamp(),
-			TxID:           tx.ID(),
-			TxOrigin:       signer,
+			TxID:           txID,
+			TxOrigin:       txOrigin,
 			ClauseIndex:    clauseIndex,
 		},
 		Topics:   event.Topics,
 		Obsolete: obsolete,
-	}, nil
+	}
 }
 
 // EventFilter contains options for contract event filtering.
@@ -217,3 +211,81 @@ type Beat2Message struct {
 	K           uint8              `json:"k"`
 	Obsolete    bool               `json:"obsolete"`
 }
+
+// TxMessage tx event of the pool piped by websocket
+type TxMessage struct {
+	ID           luckyshare.Bytes32  `json:"id"`
+	Origin       luckyshare.Address  `json:"origin"`
+	Delegator    *luckyshare.Address `json:"delegator"`
+	Gas          uint64              `json:"gas"`
+	GasPriceCoef uint8               `json:"gasPriceCoef"`
+	Status       string              `json:"status"` // one of new, executable and removed
+}
+
+func convertTxEvent(ev *txpool.TxEvent, origin luckyshare.Address) *TxMessage {
+	status := "new"
+	if ev.Removed {
+		status = "removed"
+	} else if ev.Executable != nil && *ev.Executable {
+		status = "executable"
+	}
+	delegator, _ := ev.Tx.Delegator()
+	return &TxMessage{
+		ID:           ev.Tx.ID(),
+		Origin:       origin,
+		Delegator:    delegator,
+		Gas:          ev.Tx.Gas(),
+		GasPriceCoef: ev.Tx.GasPriceCoef(),
+		Status:       status,
+	}
+}
+
+// TxPoolFilter contains options for tx filtering.
+type TxPoolFilter struct {
+	Origin *luckyshare.Address // who send transaction
+	Target *luckyshare.Address // recipient of any clause
+}
+
+// Match returs whether tx matches filter
+func (tf *TxPoolFilter) Match(trx *tx.Transaction, origin luckyshare.Address) bool {
+	if (tf.Origin != nil) && (*tf.Origin != origin) {
+		return false
+	}
+	if tf.Target != nil {
+		for _, c := range trx.Clauses() {
+			if to := c.To(); to != nil && *to == *tf.Target {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+type PendingLogMeta struct {
+	TxID        luckyshare.Bytes32 `json:"txID"`
+	TxOrigin    luckyshare.Address `json:"txOrigin"`
+	ClauseIndex uint32             `json:"clauseIndex"`
+}
+
+// PendingEventMessage event which a pending tx would emit piped by websocket
+type PendingEventMessage struct {
+	Address luckyshare.Address   `json:"address"`
+	Topics  []luckyshare.Bytes32 `json:"topics"`
+	Data    string               `json:"data"`
+	Meta    PendingLogMeta       `json:"meta"`
+	Decoded *abis.DecodedEvent   `json:"decoded,omitempty"`
+}
+
+func convertPendingEvent(txID luckyshare.Bytes32, txOrigin luckyshare.Address, clauseIndex uint32, event *tx.Event) *PendingEventMessage {
+	return &PendingEventMessage{
+		Address: event.Address,
+		Topics:  event.Topics,
+		Data:    hexutil.Encode(event.Data),
+		Meta: PendingLogMeta{
+			TxID:        txID,
+			TxOrigin:    txOrigin,
+			ClauseIndex: clauseIndex,
+		},
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package tokens
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/miniBamboo/luckyshare/api/events"
+	"github.com/miniBamboo/luckyshare/api/utils"
+	"github.com/miniBamboo/luckyshare/logdb"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/pkg/errors"
+)
+
+type Tokens struct {
+	db *logdb.LogDB
+}
+
+func New(db *logdb.LogDB) *Tokens {
+	return &Tokens{
+		db,
+	}
+}
+
+// parseAddress parses the optional address query param.
+func parseAddress(req *http.Request, name string) (*luckyshare.Address, error) {
+	s := req.URL.Query().Get(name)
+	if s == "" {
+		return nil, nil
+	}
+	addr, err := luckyshare.ParseAddress(s)
+	if err != nil {
+		return nil, utils.BadRequest(errors.WithMessage(err, name))
+	}
+	return &addr, nil
+}
+
+// HolderCriteriaSet returns criteria matching transfers from or to the holder, of the token if present.
+func HolderCriteriaSet(token *luckyshare.Address, holder luckyshare.Address) []*logdb.TokenTransferCriteria {
+	return []*logdb.TokenTransferCriteria{
+		{Token: token, Sender: &holder},
+		{Token: token, Recipient: &holder},
+	}
+}
+
+func (t *Tokens) handleGetHolders(w http.ResponseWriter, req *http.Request) error {
+	contract, err := luckyshare.ParseAddress(mux.Vars(req)["contract"])
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "contract"))
+	}
+	offset, limit, err := utils.ParsePage(req)
+	if err != nil {
+		return err
+	}
+	balances, err := t.db.TokenHolders(req.Context(), contract, offset, limit)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, ConvertBalances(balances))
+}
+
+func (t *Tokens) handleGetTransfers(w http.ResponseWriter, req *http.Request) error {
+	contract, err := luckyshare.ParseAddress(mux.Vars(req)["contract"])
+	if err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "contract"))
+	}
+	holder, err := parseAddress(req, "holder")
+	if err != nil {
+		return err
+	}
+	options, order, err := events.ParseOptions(req)
+	if err != nil {
+		return err
+	}
+	filter := &logdb.TokenTransferFilter{
+		CriteriaSet: []*logdb.TokenTransferCriteria{{Token: &contract}},
+		Options:     options,
+		Order:       order,
+	}
+	if holder != nil {
+		filter.CriteriaSet = HolderCriteriaSet(&contract, *holder)
+	}
+	transfers, err := t.db.FilterTokenTransfers(req.Context(), filter)
+	if err != nil {
+		return err
+	}
+	return utils.WriteJSON(w, ConvertTransfers(transfers))
+}
+
+func (t *Tokens) Mount(root *mux.Router, pathPrefix string) {
+	sub := root.PathPrefix(pathPrefix).Subrouter()
+
+	sub.Path("/{contract}/holders").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(t.handleGetHolders))
+	sub.Path("/{contract}/transfers").Methods(http.MethodGet).HandlerFunc(utils.WrapHandlerFunc(t.handleGetTransfers))
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package tokens
+
+import (
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/miniBamboo/luckyshare/api/events"
+	"github.com/miniBamboo/luckyshare/logdb"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+)
+
+// TokenTransfer is a fungible token transfer recognized from Transfer event.
+type TokenTransfer struct {
+	Token     luckyshare.Address    `json:"token"`
+	Sender    luckyshare.Address    `json:"sender"`
+	Recipient luckyshare.Address    `json:"recipient"`
+	Amount    *math.HexOrDecimal256 `json:"amount"`
+	Meta      events.LogMeta        `json:"meta"`
+	Cursor    *logdb.Cursor         `json:"cursor"`
+}
+
+// ConvertTransfers converts indexed token transfers.
+func ConvertTransfers(transfers []*logdb.TokenTransfer) []*TokenTransfer {
+	tts := make([]*TokenTransfer, len(transfers))
+	for i, t := range transfers {
+		v := math.HexOrDecimal256(*t.Amount)
+		tts[i] = &TokenTransfer{
+			Token:     t.Token,
+			Sender:    t.Sender,
+			Recipient: t.Recipient,
+			Amount:    &v,
+			Meta: events.LogMeta{
+				BlockID:        t.BlockID,
+				BlockNumber:    t.BlockNumber,
+				BlockTimestamp: t.BlockTime,
+				TxID:           t.TxID,
+				TxOrigin:       t.TxOrigin,
+				ClauseIndex:    t.ClauseIndex,
+			},
+			Cursor: t.Cursor(),
+		}
+	}
+	return tts
+}
+
+// TokenBalance is the balance of a token holder, accumulated from indexed transfers.
+// It may be negative if the token changes balances without Transfer events.
+type TokenBalance struct {
+	Token   luckyshare.Address    `json:"token"`
+	Holder  luckyshare.Address    `json:"holder"`
+	Balance *math.HexOrDecimal256 `json:"balance"`
+}
+
+// ConvertBalances converts indexed token balances.
+func ConvertBalances(balances []*logdb.TokenBalance) []*TokenBalance {
+	tbs := make([]*TokenBalance, len(balances))
+	for i, b := range balances {
+		tbs[i] = &TokenBalance{
+			Token:   b.Token,
+			Holder:  b.Holder,
+			Balance: (*math.HexOrDecimal256)(b.Balance),
+		}
+	}
+	return tbs
+}
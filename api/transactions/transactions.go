@@ -137,6 +137,30 @@ func (t *Transactions) handleSendTransaction(w http.ResponseWriter, req *http.Re
 	})
 }
 
+func (t *Transactions) handleSendBundle(w http.ResponseWriter, req *http.Request) error {
+	var rawBundle *RawBundle
+	if err := utils.ParseJSON(req.Body, &rawBundle); err != nil {
+		return utils.BadRequest(errors.WithMessage(err, "body"))
+	}
+	bundle, err := rawBundle.decode()
+	if err != nil {
+		return utils.BadRequest(err)
+	}
+
+	if err := t.pool.AddBundle(bundle); err != nil {
+		if txpool.IsBadTx(err) {
+			return utils.BadRequest(err)
+		}
+		if txpool.IsTxRejected(err) {
+			return utils.Forbidden(err)
+		}
+		return err
+	}
+	return utils.WriteJSON(w, map[string]string{
+		"id": bundle.ID().String(),
+	})
+}
+
 func (t *Transactions) handleGetTransactionByID(w http.ResponseWriter, req *http.Request) error {
 	id := mux.Vars(req)["id"]
 	txID, err := luckyshare.ParseBytes32(id)
@@ -216,6 +240,7 @@ func (t *Transactions) Mount(root *mux.Router, pathPrefix string) {
 	sub := root.PathPrefix(pathPrefix).Subrouter()
 
 	sub.Path("").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(t.handleSendTransaction))
+	sub.Path("/bundle").Methods("POST").HandlerFunc(utils.WrapHandlerFunc(t.handleSendBundle))
 	sub.Path("/{id}").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(t.handleGetTransactionByID))
 	sub.Path("/{id}/receipt").Methods("GET").HandlerFunc(utils.WrapHandlerFunc(t.handleGetTransactionReceiptByID))
 }
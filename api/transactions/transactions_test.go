@@ -41,6 +41,7 @@ func TestTransaction(t *testing.T) {
 	getTx(t)
 	getTxReceipt(t)
 	senTx(t)
+	sendBundle(t)
 }
 
 func getTx(t *testing.T) {
@@ -102,6 +103,38 @@ func senTx(t *testing.T) {
 	assert.Equal(t, tx.ID().String(), txObj["id"], "should be the same transaction id")
 }
 
+func sendBundle(t *testing.T) {
+	var raws []string
+	bundle := &txpool.Bundle{}
+	for i := 0; i < 2; i++ {
+		trx := new(tx.Builder).
+			BlockRef(tx.NewBlockRef(0)).
+			ChainTag(repo.ChainTag()).
+			Expiration(10).
+			Gas(21000).
+			Nonce(uint64(i)).
+			Build()
+		sig, err := crypto.Sign(trx.SigningHash().Bytes(), genesis.DevAccounts()[i].PrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trx = trx.WithSignature(sig)
+		rlpTx, err := rlp.EncodeToBytes(trx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raws = append(raws, hexutil.Encode(rlpTx))
+		bundle.Txs = append(bundle.Txs, trx)
+	}
+
+	res := httpPost(t, ts.URL+"/transactions/bundle", transactions.RawBundle{Raws: raws, MinBlock: 2, MaxBlock: 3})
+	var obj map[string]string
+	if err := json.Unmarshal(res, &obj); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, bundle.ID().String(), obj["id"], "should be the same bundle id")
+}
+
 func httpPost(t *testing.T, url string, obj interface{}) []byte {
 	data, err := json.Marshal(obj)
 	if err != nil {
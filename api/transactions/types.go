@@ -14,6 +14,8 @@ import (
 	"github.com/miniBamboo/luckyshare/block"
 	"github.com/miniBamboo/luckyshare/luckyshare"
 	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/miniBamboo/luckyshare/txpool"
+	"github.com/pkg/errors"
 )
 
 // Clause for json marshal
@@ -78,6 +80,29 @@ func (rtx *RawTx) decode() (*tx.Transaction, error) {
 	return tx, nil
 }
 
+// RawBundle an ordered list of raw txs to be packed together in a block within the range.
+type RawBundle struct {
+	Raws     []string `json:"raws"`
+	MinBlock uint32   `json:"minBlock"`
+	MaxBlock uint32   `json:"maxBlock"`
+}
+
+func (rb *RawBundle) decode() (*txpool.Bundle, error) {
+	bundle := &txpool.Bundle{
+		Txs:      make(tx.Transactions, 0, len(rb.Raws)),
+		MinBlock: rb.MinBlock,
+		MaxBlock: rb.MaxBlock,
+	}
+	for i, raw := range rb.Raws {
+		tx, err := (&RawTx{raw}).decode()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "raws[%v]", i)
+		}
+		bundle.Txs = append(bundle.Txs, tx)
+	}
+	return bundle, nil
+}
+
 type rawTransaction struct {
 	RawTx
 	Meta *TxMeta `json:"meta"`
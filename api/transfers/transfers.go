@@ -29,19 +29,27 @@ func New(repo *chain.Repository, db *logdb.LogDB) *Transfers {
 	}
 }
 
-//Filter query logs with option
-func (t *Transfers) filter(ctx context.Context, filter *TransferFilter) ([]*FilteredTransfer, error) {
+func (t *Transfers) convertFilter(filter *TransferFilter) (*logdb.TransferFilter, error) {
 	rng, err := events.ConvertRange(t.repo.NewBestChain(), filter.Range)
 	if err != nil {
 		return nil, err
 	}
-
-	transfers, err := t.db.FilterTransfers(ctx, &logdb.TransferFilter{
+	return &logdb.TransferFilter{
 		CriteriaSet: filter.CriteriaSet,
 		Range:       rng,
 		Options:     filter.Options,
 		Order:       filter.Order,
-	})
+	}, nil
+}
+
+//Filter query logs with option
+func (t *Transfers) filter(ctx context.Context, filter *TransferFilter) ([]*FilteredTransfer, error) {
+	f, err := t.convertFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers, err := t.db.FilterTransfers(ctx, f)
 	if err != nil {
 		return nil, err
 	}
@@ -57,6 +65,19 @@ func (t *Transfers) handleFilterTransferLogs(w http.ResponseWriter, req *http.Re
 	if err := utils.ParseJSON(req.Body, &filter); err != nil {
 		return utils.BadRequest(errors.WithMessage(err, "body"))
 	}
+	if count, err := events.ParseCount(req); err != nil {
+		return err
+	} else if count {
+		f, err := t.convertFilter(&filter)
+		if err != nil {
+			return err
+		}
+		n, err := t.db.CountTransfers(req.Context(), f)
+		if err != nil {
+			return err
+		}
+		return utils.WriteJSON(w, &events.LogCount{Count: n})
+	}
 	tLogs, err := t.filter(req.Context(), &filter)
 	if err != nil {
 		return err
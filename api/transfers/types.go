@@ -26,6 +26,7 @@ type FilteredTransfer struct {
 	Recipient luckyshare.Address    `json:"recipient"`
 	Amount    *math.HexOrDecimal256 `json:"amount"`
 	Meta      LogMeta               `json:"meta"`
+	Cursor    *logdb.Cursor         `json:"cursor"`
 }
 
 func convertTransfer(transfer *logdb.Transfer) *FilteredTransfer {
@@ -42,6 +43,7 @@ func convertTransfer(transfer *logdb.Transfer) *FilteredTransfer {
 			TxOrigin:       transfer.TxOrigin,
 			ClauseIndex:    transfer.ClauseIndex,
 		},
+		Cursor: transfer.Cursor(),
 	}
 }
 
@@ -0,0 +1,38 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package utils
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// limits of paged queries
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+// ParsePage parses the optional offset and limit query params.
+func ParsePage(req *http.Request) (offset, limit uint64, err error) {
+	limit = DefaultPageLimit
+	if s := req.URL.Query().Get("offset"); s != "" {
+		if offset, err = strconv.ParseUint(s, 0, 64); err != nil {
+			return 0, 0, BadRequest(errors.WithMessage(err, "offset"))
+		}
+	}
+	if s := req.URL.Query().Get("limit"); s != "" {
+		if limit, err = strconv.ParseUint(s, 0, 64); err != nil {
+			return 0, 0, BadRequest(errors.WithMessage(err, "limit"))
+		}
+		if limit > MaxPageLimit {
+			return 0, 0, BadRequest(errors.New("limit: exceeds " + strconv.Itoa(MaxPageLimit)))
+		}
+	}
+	return
+}
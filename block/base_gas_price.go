@@ -0,0 +1,53 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package block
+
+import (
+	"math/big"
+
+	"github.com/miniBamboo/luckyshare/luckyshare"
+)
+
+// CalcBaseGasPrice calculates the dynamic base gas price of the block next to parent.
+// The price goes up if parent used more gas than the target (half of gas limit), and goes down otherwise,
+// by at most 1/BaseGasPriceChangeDenominator. It never goes below minBaseGasPrice.
+func CalcBaseGasPrice(parentBaseGasPrice, minBaseGasPrice *big.Int, parentGasUsed, parentGasLimit uint64) *big.Int {
+	target := parentGasLimit / luckyshare.GasElasticityMultiplier
+	if target == 0 || parentGasUsed == target {
+		return maxBig(parentBaseGasPrice, minBaseGasPrice)
+	}
+
+	var (
+		delta = new(big.Int)
+		next  = new(big.Int)
+	)
+	if parentGasUsed > target {
+		delta.SetUint64(parentGasUsed - target)
+	} else {
+		delta.SetUint64(target - parentGasUsed)
+	}
+	delta.Mul(delta, parentBaseGasPrice)
+	delta.Div(delta, new(big.Int).SetUint64(target))
+	delta.Div(delta, new(big.Int).SetUint64(luckyshare.BaseGasPriceChangeDenominator))
+
+	if parentGasUsed > target {
+		// at least 1 wei to make the price move
+		if delta.Sign() == 0 {
+			delta.SetUint64(1)
+		}
+		next.Add(parentBaseGasPrice, delta)
+	} else {
+		next.Sub(parentBaseGasPrice, delta)
+	}
+	return maxBig(next, minBaseGasPrice)
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) < 0 {
+		return new(big.Int).Set(b)
+	}
+	return new(big.Int).Set(a)
+}
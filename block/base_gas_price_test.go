@@ -0,0 +1,50 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package block_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcBaseGasPrice(t *testing.T) {
+	var (
+		min   = big.NewInt(1000)
+		price = big.NewInt(8000)
+	)
+
+	tests := []struct {
+		parent   *big.Int
+		gasUsed  uint64
+		gasLimit uint64
+		want     *big.Int
+	}{
+		// at target
+		{price, 5000, 10000, big.NewInt(8000)},
+		// full block, +1/8
+		{price, 10000, 10000, big.NewInt(9000)},
+		// empty block, -1/8
+		{price, 0, 10000, big.NewInt(7000)},
+		// half way above target
+		{price, 7500, 10000, big.NewInt(8500)},
+		// tiny excess still moves the price up
+		{big.NewInt(1000), 5001, 10000, big.NewInt(1001)},
+		// bounded by min
+		{big.NewInt(1100), 0, 10000, big.NewInt(1000)},
+		// parent lower than min
+		{big.NewInt(10), 5000, 10000, big.NewInt(1000)},
+		// zero gas limit
+		{price, 0, 0, big.NewInt(8000)},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, block.CalcBaseGasPrice(tt.parent, min, tt.gasUsed, tt.gasLimit))
+	}
+	// the parent price is not modified
+	assert.Equal(t, big.NewInt(8000), price)
+}
@@ -68,6 +68,11 @@ func (c *Chain) HeadID() luckyshare.Bytes32 {
 	return c.headID
 }
 
+// GenesisID returns the genesis block id.
+func (c *Chain) GenesisID() luckyshare.Bytes32 {
+	return c.repo.GenesisBlock().Header().ID()
+}
+
 // GetBlockID returns block id by given block number.
 func (c *Chain) GetBlockID(num uint32) (luckyshare.Bytes32, error) {
 	trie, err := c.lazyInit()
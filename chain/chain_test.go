@@ -69,3 +69,25 @@ func TestChain(t *testing.T) {
 	assert.Equal(t, M([]luckyshare.Bytes32{b3.Header().ID()}, nil), M(c1.Exclude(c2)))
 	assert.Equal(t, M([]luckyshare.Bytes32{b3x.Header().ID()}, nil), M(c2.Exclude(c1)))
 }
+
+func TestScheduledReceipts(t *testing.T) {
+	tx1 := newTx()
+
+	repo := newTestRepo()
+
+	b1 := newBlock(repo.GenesisBlock(), 10, tx1)
+	scheduledReceipt := &tx.Receipt{GasUsed: 1}
+	tx1Receipt := &tx.Receipt{GasUsed: 2}
+	assert.Error(t, repo.AddBlock(b1, nil))
+	assert.Nil(t, repo.AddBlock(b1, tx.Receipts{scheduledReceipt, tx1Receipt}))
+
+	summary, err := repo.GetBlockSummary(b1.Header().ID())
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(1), summary.Scheduled)
+	// receipts in execution order
+	assert.Equal(t, M(tx.Receipts{scheduledReceipt, tx1Receipt}, nil), M(repo.GetBlockReceipts(b1.Header().ID())))
+
+	c := repo.NewChain(b1.Header().ID())
+	assert.Equal(t, M(&chain.TxMeta{BlockID: b1.Header().ID(), Index: 0, Reverted: false}, nil), M(c.GetTransactionMeta(tx1.ID())))
+	assert.Equal(t, M(tx1Receipt, nil), M(c.GetTransactionReceipt(tx1.ID())))
+}
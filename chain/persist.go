@@ -26,6 +26,17 @@ type BlockSummary struct {
 	IndexRoot luckyshare.Bytes32
 	Txs       []luckyshare.Bytes32
 	Size      uint64
+	Scheduled uint32 // count of scheduled executions
+}
+
+// the persisted form of block summary.
+// the count of scheduled executions is encoded as optional tail, to be compatible with summaries saved before.
+type blockSummaryRLP struct {
+	Header    *block.Header
+	IndexRoot luckyshare.Bytes32
+	Txs       []luckyshare.Bytes32
+	Size      uint64
+	Scheduled []uint32 `rlp:"tail"`
 }
 
 // the key for tx/receipt.
@@ -59,14 +70,22 @@ func loadRLP(r kv.Getter, key []byte, val interface{}) error {
 }
 
 func saveBlockSummary(w kv.Putter, summary *BlockSummary) error {
-	return saveRLP(w, summary.Header.ID().Bytes(), summary)
+	enc := blockSummaryRLP{summary.Header, summary.IndexRoot, summary.Txs, summary.Size, nil}
+	if summary.Scheduled > 0 {
+		enc.Scheduled = []uint32{summary.Scheduled}
+	}
+	return saveRLP(w, summary.Header.ID().Bytes(), &enc)
 }
 
 func loadBlockSummary(r kv.Getter, id luckyshare.Bytes32) (*BlockSummary, error) {
-	var summary BlockSummary
-	if err := loadRLP(r, id[:], &summary); err != nil {
+	var dec blockSummaryRLP
+	if err := loadRLP(r, id[:], &dec); err != nil {
 		return nil, err
 	}
+	summary := BlockSummary{dec.Header, dec.IndexRoot, dec.Txs, dec.Size, 0}
+	if len(dec.Scheduled) > 0 {
+		summary.Scheduled = dec.Scheduled[0]
+	}
 	return &summary, nil
 }
 
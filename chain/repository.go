@@ -78,7 +78,7 @@ func NewRepository(db *muxdb.MuxDB, genesis *block.Block) (*Repository, error) {
 		if err != nil {
 			return nil, err
 		}
-		if err := repo.saveBlock(genesis, nil, indexRoot); err != nil {
+		if err := repo.saveBlock(genesis, nil, 0, indexRoot); err != nil {
 			return nil, err
 		}
 		if err := repo.setBestBlock(genesis); err != nil {
@@ -141,13 +141,16 @@ func (r *Repository) setBestBlock(b *block.Block) error {
 	return nil
 }
 
-func (r *Repository) saveBlock(block *block.Block, receipts tx.Receipts, indexRoot luckyshare.Bytes32) error {
+// saveBlock saves the block along with its receipts in execution order, where the first `scheduled` ones
+// are of scheduled executions. In storage, receipts of txs are keyed by tx index, so that tx meta locates
+// the receipt, and receipts of scheduled executions follow them.
+func (r *Repository) saveBlock(block *block.Block, receipts tx.Receipts, scheduled int, indexRoot luckyshare.Bytes32) error {
 	return r.data.Batch(func(putter kv.PutFlusher) error {
 		var (
 			header  = block.Header()
 			id      = header.ID()
 			txs     = block.Transactions()
-			summary = BlockSummary{header, indexRoot, []luckyshare.Bytes32{}, uint64(block.Size())}
+			summary = BlockSummary{header, indexRoot, []luckyshare.Bytes32{}, uint64(block.Size()), uint32(scheduled)}
 		)
 
 		if n := len(txs); n > 0 {
@@ -160,9 +163,15 @@ func (r *Repository) saveBlock(block *block.Block, receipts tx.Receipts, indexRo
 				r.caches.txs.Add(key, tx)
 				summary.Txs = append(summary.Txs, tx.ID())
 			}
-			key = makeTxKey(id, receiptInfix)
+		}
+		if len(receipts) > 0 {
+			key := makeTxKey(id, receiptInfix)
 			for i, receipt := range receipts {
-				key.SetIndex(uint64(i))
+				if i < scheduled {
+					key.SetIndex(uint64(len(txs) + i))
+				} else {
+					key.SetIndex(uint64(i - scheduled))
+				}
 				if err := saveReceipt(putter, key, receipt); err != nil {
 					return err
 				}
@@ -178,6 +187,7 @@ func (r *Repository) saveBlock(block *block.Block, receipts tx.Receipts, indexRo
 }
 
 // AddBlock add a new block with its receipts into repository.
+// The receipts are in execution order, receipts of scheduled executions come first, followed by ones of txs.
 func (r *Repository) AddBlock(newBlock *block.Block, receipts tx.Receipts) error {
 	parentSummary, err := r.GetBlockSummary(newBlock.Header().ParentID())
 	if err != nil {
@@ -186,12 +196,16 @@ func (r *Repository) AddBlock(newBlock *block.Block, receipts tx.Receipts) error
 		}
 		return err
 	}
-	indexRoot, err := r.indexBlock(parentSummary.IndexRoot, newBlock, receipts)
+	scheduled := len(receipts) - len(newBlock.Transactions())
+	if scheduled < 0 {
+		return errors.New("txs count > receipts count")
+	}
+	indexRoot, err := r.indexBlock(parentSummary.IndexRoot, newBlock, receipts[scheduled:])
 	if err != nil {
 		return err
 	}
 
-	if err := r.saveBlock(newBlock, receipts, indexRoot); err != nil {
+	if err := r.saveBlock(newBlock, receipts, scheduled, indexRoot); err != nil {
 		return err
 	}
 	return nil
@@ -263,18 +277,23 @@ func (r *Repository) getReceipt(key txKey) (*tx.Receipt, error) {
 	return cached.(*tx.Receipt), nil
 }
 
-// GetBlockReceipts get all tx receipts of the block for given block id.
+// GetBlockReceipts get all receipts of the block for given block id, in execution order.
+// Receipts of scheduled executions come first, followed by receipts of txs.
 func (r *Repository) GetBlockReceipts(id luckyshare.Bytes32) (tx.Receipts, error) {
 	summary, err := r.GetBlockSummary(id)
 	if err != nil {
 		return nil, err
 	}
 
-	if n := len(summary.Txs); n > 0 {
+	if n := int(summary.Scheduled) + len(summary.Txs); n > 0 {
 		receipts := make(tx.Receipts, n)
 		key := makeTxKey(id, receiptInfix)
-		for i := range summary.Txs {
-			key.SetIndex(uint64(i))
+		for i := range receipts {
+			if i < int(summary.Scheduled) {
+				key.SetIndex(uint64(len(summary.Txs) + i))
+			} else {
+				key.SetIndex(uint64(i - int(summary.Scheduled)))
+			}
 			receipts[i], err = r.getReceipt(key)
 			if err != nil {
 				return nil, err
@@ -0,0 +1,168 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/miniBamboo/luckyshare/api/governance"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/pkg/errors"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+var authorityCommand = cli.Command{
+	Name:  "authority",
+	Usage: "authority node management, through API of a running node",
+	Subcommands: []cli.Command{
+		{
+			Name:   "list",
+			Usage:  "list all block proposer candidates",
+			Flags:  []cli.Flag{apiURLFlag, recentBlocksFlag},
+			Action: authorityListAction,
+		},
+		{
+			Name:   "add",
+			Usage:  "build clause to add a node",
+			Flags:  []cli.Flag{apiURLFlag, nodeMasterFlag, endorsorFlag, identityFlag},
+			Action: authorityAddAction,
+		},
+		{
+			Name:   "revoke",
+			Usage:  "build clause to revoke a node",
+			Flags:  []cli.Flag{apiURLFlag, nodeMasterFlag},
+			Action: authorityRevokeAction,
+		},
+	},
+}
+
+var apiClient = &http.Client{Timeout: 30 * time.Second}
+
+// callAPI sends request to API of the node, and decodes the JSON response into result.
+func callAPI(ctx *cli.Context, method, path string, body interface{}, result interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	url := strings.TrimRight(ctx.String(apiURLFlag.Name), "/") + path
+	req, err := http.NewRequest(method, url, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := apiClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v: %v", res.Status, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, result)
+}
+
+func authorityListAction(ctx *cli.Context) error {
+	var candidates governance.Candidates
+	path := fmt.Sprintf("/authority/candidates?recentBlocks=%v", ctx.Int(recentBlocksFlag.Name))
+	if err := callAPI(ctx, "GET", path, nil, &candidates); err != nil {
+		return err
+	}
+
+	fmt.Printf("Best block: %v, endorsement: %v, blocks scanned: %v\n",
+		candidates.BestBlock, (*big.Int)(candidates.Endorsement), candidates.RecentBlocks)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE MASTER\tENDORSOR\tIDENTITY\tACTIVE\tENDORSED\tSIGNED\tLAST SIGNED")
+	for _, c := range candidates.Candidates {
+		last := "-"
+		if c.LastSignedBlock != nil {
+			last = fmt.Sprint(*c.LastSignedBlock)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			c.NodeMaster, c.Endorsor, governance.PrintableName(c.Identity), c.Active, c.Endorsed, c.SignedBlocks, last)
+	}
+	return w.Flush()
+}
+
+func authorityAddAction(ctx *cli.Context) error {
+	nodeMaster, err := luckyshare.ParseAddress(ctx.String(nodeMasterFlag.Name))
+	if err != nil {
+		return errors.WithMessage(err, nodeMasterFlag.Name)
+	}
+	endorsor, err := luckyshare.ParseAddress(ctx.String(endorsorFlag.Name))
+	if err != nil {
+		return errors.WithMessage(err, endorsorFlag.Name)
+	}
+	identity, err := parseIdentity(ctx.String(identityFlag.Name))
+	if err != nil {
+		return errors.WithMessage(err, identityFlag.Name)
+	}
+
+	var built governance.BuiltClause
+	if err := callAPI(ctx, "POST", "/authority/clauses/add", &governance.AddAuthority{
+		NodeMaster: nodeMaster,
+		Endorsor:   endorsor,
+		Identity:   identity,
+	}, &built); err != nil {
+		return err
+	}
+	return printBuiltClause(&built)
+}
+
+func authorityRevokeAction(ctx *cli.Context) error {
+	nodeMaster, err := luckyshare.ParseAddress(ctx.String(nodeMasterFlag.Name))
+	if err != nil {
+		return errors.WithMessage(err, nodeMasterFlag.Name)
+	}
+
+	var built governance.BuiltClause
+	if err := callAPI(ctx, "POST", "/authority/clauses/revoke", &governance.RevokeNode{
+		NodeMaster: nodeMaster,
+	}, &built); err != nil {
+		return err
+	}
+	return printBuiltClause(&built)
+}
+
+func printBuiltClause(built *governance.BuiltClause) error {
+	fmt.Println(built.Description)
+	fmt.Println("Executor:", built.Executor)
+	data, err := json.MarshalIndent(built.Clause, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// parseIdentity parses identity in hex, or takes it as text.
+func parseIdentity(s string) (luckyshare.Bytes32, error) {
+	if strings.HasPrefix(s, "0x") && len(s) == 66 {
+		return luckyshare.ParseBytes32(s)
+	}
+	if len(s) == 0 || len(s) > 32 {
+		return luckyshare.Bytes32{}, errors.New("should be 1 to 32 bytes text, or 32 bytes hex")
+	}
+	return luckyshare.BytesToBytes32([]byte(s)), nil
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+// Package calltrace replays blocks to collect message calls for log db indexing.
+package calltrace
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/chain"
+	"github.com/miniBamboo/luckyshare/consensus"
+	"github.com/miniBamboo/luckyshare/logdb"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/miniBamboo/luckyshare/vm"
+)
+
+// Tracer traces calls of blocks.
+type Tracer struct {
+	cons *consensus.Consensus
+}
+
+// New creates a call tracer.
+func New(repo *chain.Repository, stater *state.Stater, forkConfig luckyshare.ForkConfig) *Tracer {
+	return &Tracer{consensus.New(repo, stater, forkConfig)}
+}
+
+// TraceBlock replays the block, which should be already verified, on its parent state,
+// and returns calls of scheduled executions and txs, in the order of receipts.
+func (tr *Tracer) TraceBlock(blk *block.Block) ([]*logdb.Call, error) {
+	// the block is verified, so skip PoA to ignore the proposer check
+	rt, err := tr.cons.NewRuntimeForReplay(blk.Header(), true)
+	if err != nil {
+		return nil, err
+	}
+	c := &collector{}
+	rt.SetVMConfig(vm.Config{Debug: true, Tracer: c})
+
+	var (
+		num = blk.Header().Number()
+		txs = blk.Transactions()
+	)
+	scheduled, err := rt.ExecuteScheduled()
+	if err != nil {
+		return nil, err
+	}
+	scheduledRuns := c.takeRuns()
+
+	var calls []*logdb.Call
+	for i, receipt := range scheduled {
+		// scheduled executions unable to pay for gas are not run
+		if receipt.GasUsed == 0 || len(scheduledRuns) == 0 {
+			continue
+		}
+		calls = appendRun(calls, scheduledRuns[0], tx.ScheduledID(num, i), receipt.GasPayer, 0)
+		scheduledRuns = scheduledRuns[1:]
+	}
+
+	for _, t := range txs {
+		if _, err := rt.ExecuteTransaction(t); err != nil {
+			return nil, err
+		}
+		origin, _ := t.Origin()
+		for clauseIndex, run := range c.takeRuns() {
+			calls = appendRun(calls, run, t.ID(), origin, uint32(clauseIndex))
+		}
+	}
+	return calls, nil
+}
+
+func appendRun(calls []*logdb.Call, run []*logdb.Call, txID luckyshare.Bytes32, origin luckyshare.Address, clauseIndex uint32) []*logdb.Call {
+	for _, call := range run {
+		call.TxID = txID
+		call.TxOrigin = origin
+		call.ClauseIndex = clauseIndex
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// pendingCall is a call made by the frame at depth, waiting for the frame to resume.
+type pendingCall struct {
+	call  *logdb.Call
+	depth int
+}
+
+// collector implements vm.Tracer. It collects calls of each clause run, by inspecting
+// CALL-like and CREATE-like ops, without capturing memory or storage.
+type collector struct {
+	runs    [][]*logdb.Call
+	pending []pendingCall
+}
+
+func (c *collector) takeRuns() [][]*logdb.Call {
+	runs := c.runs
+	c.runs = nil
+	return runs
+}
+
+func (c *collector) add(call *logdb.Call) {
+	c.runs[len(c.runs)-1] = append(c.runs[len(c.runs)-1], call)
+}
+
+func (c *collector) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	call := &logdb.Call{
+		Type:   "call",
+		Caller: luckyshare.Address(from),
+		Callee: luckyshare.Address(to),
+		Value:  copyValue(value),
+	}
+	if create {
+		call.Type = "create"
+	} else {
+		call.Selector = selector(input)
+	}
+	c.runs = append(c.runs, []*logdb.Call{call})
+	c.pending = c.pending[:0]
+	return nil
+}
+
+func (c *collector) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	// calls made by this frame or its sub frames are returned
+	for len(c.pending) > 0 {
+		p := c.pending[len(c.pending)-1]
+		if p.depth < depth {
+			break
+		}
+		c.pending = c.pending[:len(c.pending)-1]
+		if p.depth > depth || len(stack.Data()) == 0 {
+			// the calling frame exited without resuming
+			p.call.Reverted = true
+			continue
+		}
+		result := stack.Back(0)
+		switch p.call.Type {
+		case "create", "create2":
+			p.call.Callee = luckyshare.Address(common.BigToAddress(result))
+			p.call.Reverted = p.call.Callee.IsZero()
+		default:
+			p.call.Reverted = result.Sign() == 0
+		}
+	}
+
+	// op failed before execution
+	if err != nil {
+		return nil
+	}
+
+	call := &logdb.Call{
+		Depth:  uint32(depth),
+		Caller: luckyshare.Address(contract.Address()),
+		Value:  &big.Int{},
+	}
+	switch op {
+	case vm.CALL, vm.CALLCODE:
+		call.Callee = luckyshare.Address(common.BigToAddress(stack.Back(1)))
+		call.Value = copyValue(stack.Back(2))
+		call.Selector = memorySelector(memory, stack.Back(3), stack.Back(4))
+	case vm.DELEGATECALL, vm.STATICCALL:
+		call.Callee = luckyshare.Address(common.BigToAddress(stack.Back(1)))
+		call.Selector = memorySelector(memory, stack.Back(2), stack.Back(3))
+	case vm.CREATE, vm.CREATE2:
+		call.Value = copyValue(stack.Back(0))
+	default:
+		return nil
+	}
+	call.Type = opType(op)
+	c.add(call)
+	c.pending = append(c.pending, pendingCall{call, depth})
+	return nil
+}
+
+func (c *collector) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+func (c *collector) CaptureEnd(output []byte, gasUsed uint64, _ time.Duration, err error) error {
+	for _, p := range c.pending {
+		p.call.Reverted = true
+	}
+	c.pending = c.pending[:0]
+	if len(c.runs) > 0 {
+		c.runs[len(c.runs)-1][0].Reverted = err != nil
+	}
+	return nil
+}
+
+func opType(op vm.OpCode) string {
+	switch op {
+	case vm.CALLCODE:
+		return "callcode"
+	case vm.DELEGATECALL:
+		return "delegatecall"
+	case vm.STATICCALL:
+		return "staticcall"
+	case vm.CREATE:
+		return "create"
+	case vm.CREATE2:
+		return "create2"
+	}
+	return "call"
+}
+
+func copyValue(v *big.Int) *big.Int {
+	if v == nil {
+		return &big.Int{}
+	}
+	return new(big.Int).Set(v)
+}
+
+func selector(input []byte) []byte {
+	if len(input) < 4 {
+		return nil
+	}
+	return append([]byte(nil), input[:4]...)
+}
+
+// memorySelector reads selector from call input in memory, which is already expanded
+// to cover the input before the op executed.
+func memorySelector(memory *vm.Memory, offset, size *big.Int) []byte {
+	if !size.IsUint64() || size.Uint64() < 4 {
+		return nil
+	}
+	return memory.Get(offset.Int64(), 4)
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/chain"
+	"github.com/miniBamboo/luckyshare/logdb"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/muxdb"
+	"github.com/pkg/errors"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+var logDBCommand = cli.Command{
+	Name:  "logdb",
+	Usage: "log db management, while the node is stopped",
+	Subcommands: []cli.Command{
+		{
+			Name:   "rebuild",
+			Usage:  "rewrite logs from the given block up to the best block",
+			Flags:  []cli.Flag{networkFlag, dataDirFlag, disablePrunerFlag, cacheFlag, logDBFlag, indexCallsFlag, indexTokensFlag, fromFlag},
+			Action: logDBRebuildAction,
+		},
+		{
+			Name:   "verify",
+			Usage:  "verify logs of blocks in range against receipts",
+			Flags:  []cli.Flag{networkFlag, dataDirFlag, disablePrunerFlag, cacheFlag, logDBFlag, rangeFlag},
+			Action: logDBVerifyAction,
+		},
+		{
+			Name:   "prune",
+			Usage:  "delete logs of blocks before the given block, which are not written any more",
+			Flags:  []cli.Flag{networkFlag, dataDirFlag, disablePrunerFlag, logDBFlag, beforeFlag},
+			Action: logDBPruneAction,
+		},
+		{
+			Name:   "vacuum",
+			Usage:  "reclaim disk space of deleted logs",
+			Flags:  []cli.Flag{networkFlag, dataDirFlag, disablePrunerFlag, logDBFlag},
+			Action: logDBVacuumAction,
+		},
+	},
+}
+
+// logDBInstance is the log db of the instance selected by flags, and the chain if opened.
+type logDBInstance struct {
+	logDB      *logdb.LogDB
+	mainDB     *muxdb.MuxDB
+	repo       *chain.Repository
+	forkConfig luckyshare.ForkConfig
+}
+
+func openLogDBInstance(ctx *cli.Context, withChain bool) (inst *logDBInstance, err error) {
+	initLogger(ctx)
+	gene, forkConfig, err := selectGenesis(ctx)
+	if err != nil {
+		return nil, err
+	}
+	instanceDir, err := makeInstanceDir(ctx, gene)
+	if err != nil {
+		return nil, err
+	}
+
+	inst = &logDBInstance{forkConfig: forkConfig}
+	defer func() {
+		if err != nil {
+			inst.Close()
+		}
+	}()
+
+	if inst.logDB, err = openLogDB(ctx, instanceDir); err != nil {
+		return nil, err
+	}
+	indexTokens(ctx, inst.logDB)
+	if withChain {
+		if inst.mainDB, err = openMainDB(ctx, instanceDir); err != nil {
+			return nil, err
+		}
+		if inst.repo, err = initChainRepository(gene, inst.mainDB, inst.logDB); err != nil {
+			return nil, err
+		}
+	}
+	return inst, nil
+}
+
+func (inst *logDBInstance) Close() {
+	if inst.mainDB != nil {
+		inst.mainDB.Close()
+	}
+	if inst.logDB != nil {
+		inst.logDB.Close()
+	}
+}
+
+// parseBlockRange parses range like '1000-2000' or '1000-', and the end defaults to the given value.
+func parseBlockRange(s string, defaultEnd uint32) (uint32, uint32, error) {
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return 0, 0, errors.New("should be like '1000-2000' or '1000-'")
+	}
+	from, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, 0, err
+	}
+	to := uint64(defaultEnd)
+	if parts[1] != "" {
+		if to, err = strconv.ParseUint(parts[1], 10, 32); err != nil {
+			return 0, 0, err
+		}
+	}
+	if from > to {
+		return 0, 0, errors.New("start greater than end")
+	}
+	return uint32(from), uint32(to), nil
+}
+
+func logDBRebuildAction(ctx *cli.Context) error {
+	exitSignal := handleExitSignal()
+
+	inst, err := openLogDBInstance(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer inst.Close()
+
+	from := uint32(ctx.Uint(fromFlag.Name))
+	if from == 0 {
+		from = 1 // block 0 is written at startup
+	}
+	pruned, err := inst.logDB.PrunedBefore()
+	if err != nil {
+		return err
+	}
+	if from < pruned {
+		if ctx.IsSet(fromFlag.Name) {
+			return fmt.Errorf("%v: logs before block %v are pruned", fromFlag.Name, pruned)
+		}
+		from = pruned
+	}
+	bestNum := inst.repo.BestBlock().Header().Number()
+	if from > bestNum {
+		return fmt.Errorf("%v: exceeds best block %v", fromFlag.Name, bestNum)
+	}
+
+	fmt.Printf(">> Rebuilding log db from block %v <<\n", from)
+	return writeLogDB(exitSignal, inst.repo, inst.logDB, newCallTracer(ctx, inst.repo, inst.mainDB, inst.forkConfig), from, bestNum)
+}
+
+func logDBVerifyAction(ctx *cli.Context) error {
+	exitSignal := handleExitSignal()
+
+	inst, err := openLogDBInstance(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer inst.Close()
+
+	newestID, err := inst.logDB.NewestBlockID()
+	if err != nil {
+		return err
+	}
+	newestNum := block.Number(newestID)
+	if bestNum := inst.repo.BestBlock().Header().Number(); newestNum > bestNum {
+		newestNum = bestNum
+	}
+
+	from, to := uint32(1), newestNum
+	if s := ctx.String(rangeFlag.Name); s != "" {
+		if from, to, err = parseBlockRange(s, newestNum); err != nil {
+			return errors.WithMessage(err, rangeFlag.Name)
+		}
+		if from == 0 {
+			from = 1 // logs of block 0 are built from genesis
+		}
+	}
+	pruned, err := inst.logDB.PrunedBefore()
+	if err != nil {
+		return err
+	}
+	if from < pruned {
+		fmt.Printf("logs before block %v are pruned, verifying from it\n", pruned)
+		from = pruned
+	}
+	if from > to {
+		fmt.Println("no blocks to verify")
+		return nil
+	}
+
+	if err := verifyLogDB(exitSignal, from, to, inst.repo, inst.logDB); err != nil {
+		return err
+	}
+	fmt.Printf("logs of blocks %v-%v verified\n", from, to)
+	return nil
+}
+
+func logDBPruneAction(ctx *cli.Context) error {
+	if !ctx.IsSet(beforeFlag.Name) {
+		return fmt.Errorf("%v: required", beforeFlag.Name)
+	}
+	before := uint32(ctx.Uint(beforeFlag.Name))
+
+	inst, err := openLogDBInstance(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer inst.Close()
+
+	fmt.Printf(">> Pruning logs before block %v <<\n", before)
+	if err := inst.logDB.Prune(context.Background(), before); err != nil {
+		return errors.Wrap(err, "prune log db")
+	}
+	fmt.Println("done, run 'vacuum' to reclaim disk space")
+	return nil
+}
+
+func logDBVacuumAction(ctx *cli.Context) error {
+	inst, err := openLogDBInstance(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer inst.Close()
+
+	fmt.Printf(">> Vacuuming log db [%v] <<\n", inst.logDB.Path())
+	if err := inst.logDB.Vacuum(context.Background()); err != nil {
+		return errors.Wrap(err, "vacuum log db")
+	}
+	fmt.Println("done")
+	return nil
+}
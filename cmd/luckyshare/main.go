@@ -65,21 +65,33 @@ func main() {
 			dataDirFlag,
 			cacheFlag,
 			beneficiaryFlag,
+			reporterKeyFlag,
 			targetGasLimitFlag,
 			apiAddrFlag,
 			apiCorsFlag,
 			apiTimeoutFlag,
 			apiCallGasLimitFlag,
 			apiBacktraceLimitFlag,
+			apiABIsWritableFlag,
 			verbosityFlag,
 			maxPeersFlag,
 			p2pPortFlag,
 			natFlag,
 			bootNodeFlag,
 			skipLogsFlag,
+			logDBFlag,
+			indexCallsFlag,
+			indexTokensFlag,
 			pprofFlag,
 			verifyLogsFlag,
 			disablePrunerFlag,
+			txPoolPolicyFileFlag,
+			txPoolBlocklistSourcesFlag,
+			txPoolBlocklistSignersFlag,
+			txPoolBlocklistMergeFlag,
+			packerStrategyFlag,
+			packerOriginGasCapFlag,
+			packerReservedOriginsFlag,
 		},
 		Action: defaultAction,
 		Commands: []cli.Command{
@@ -94,6 +106,7 @@ func main() {
 					apiTimeoutFlag,
 					apiCallGasLimitFlag,
 					apiBacktraceLimitFlag,
+					apiABIsWritableFlag,
 					onDemandFlag,
 					persistFlag,
 					gasLimitFlag,
@@ -101,8 +114,12 @@ func main() {
 					pprofFlag,
 					verifyLogsFlag,
 					skipLogsFlag,
+					logDBFlag,
+					indexCallsFlag,
+					indexTokensFlag,
 					txPoolLimitFlag,
 					txPoolLimitPerAccountFlag,
+					txPoolPolicyFileFlag,
 					disablePrunerFlag,
 				},
 				Action: soloAction,
@@ -117,6 +134,8 @@ func main() {
 				},
 				Action: masterKeyAction,
 			},
+			authorityCommand,
+			logDBCommand,
 		},
 	}
 
@@ -154,6 +173,7 @@ func defaultAction(ctx *cli.Context) error {
 		return err
 	}
 	defer func() { log.Info("closing log database..."); logDB.Close() }()
+	indexTokens(ctx, logDB)
 
 	repo, err := initChainRepository(gene, mainDB, logDB)
 	if err != nil {
@@ -165,6 +185,11 @@ func defaultAction(ctx *cli.Context) error {
 		return err
 	}
 
+	packerStrategy, err := selectPackerStrategy(ctx)
+	if err != nil {
+		return err
+	}
+
 	printStartupMessage1(gene, repo, master, instanceDir, forkConfig)
 
 	if !skipLogs {
@@ -174,9 +199,17 @@ func defaultAction(ctx *cli.Context) error {
 	}
 
 	txpoolOpt := defaultTxPoolOptions
+	txpoolOpt.ForkConfig = forkConfig
+	if err := applyBlocklistOptions(ctx, &txpoolOpt, instanceDir); err != nil {
+		return err
+	}
 	txPool := txpool.New(repo, state.NewStater(mainDB), txpoolOpt)
 	defer func() { log.Info("closing tx pool..."); txPool.Close() }()
 
+	if err := loadTxPoolPolicies(ctx, exitSignal, txPool); err != nil {
+		return err
+	}
+
 	p2pcom, err := newP2PComm(ctx, repo, txPool, instanceDir)
 	if err != nil {
 		return err
@@ -192,6 +225,7 @@ func defaultAction(ctx *cli.Context) error {
 		uint64(ctx.Int(apiCallGasLimitFlag.Name)),
 		ctx.Bool(pprofFlag.Name),
 		skipLogs,
+		ctx.Bool(apiABIsWritableFlag.Name),
 		forkConfig)
 	defer func() { log.Info("closing API..."); apiCloser() }()
 
@@ -223,7 +257,9 @@ func defaultAction(ctx *cli.Context) error {
 		p2pcom.commu,
 		uint64(ctx.Int(targetGasLimitFlag.Name)),
 		skipLogs,
-		forkConfig).Run(exitSignal)
+		newCallTracer(ctx, repo, mainDB, forkConfig),
+		forkConfig,
+		packerStrategy).Run(exitSignal)
 }
 
 func soloAction(ctx *cli.Context) error {
@@ -257,6 +293,7 @@ func soloAction(ctx *cli.Context) error {
 		mainDB = openMemMainDB()
 		logDB = openMemLogDB()
 	}
+	indexTokens(ctx, logDB)
 
 	repo, err := initChainRepository(gene, mainDB, logDB)
 	if err != nil {
@@ -274,10 +311,15 @@ func soloAction(ctx *cli.Context) error {
 	txPoolOption := defaultTxPoolOptions
 	txPoolOption.Limit = ctx.Int(txPoolLimitFlag.Name)
 	txPoolOption.LimitPerAccount = ctx.Int(txPoolLimitPerAccountFlag.Name)
+	txPoolOption.ForkConfig = forkConfig
 
 	txPool := txpool.New(repo, state.NewStater(mainDB), txPoolOption)
 	defer func() { log.Info("closing tx pool..."); txPool.Close() }()
 
+	if err := loadTxPoolPolicies(ctx, exitSignal, txPool); err != nil {
+		return err
+	}
+
 	apiHandler, apiCloser := api.New(
 		repo,
 		state.NewStater(mainDB),
@@ -289,6 +331,7 @@ func soloAction(ctx *cli.Context) error {
 		uint64(ctx.Int(apiCallGasLimitFlag.Name)),
 		ctx.Bool(pprofFlag.Name),
 		skipLogs,
+		ctx.Bool(apiABIsWritableFlag.Name),
 		forkConfig)
 	defer func() { log.Info("closing API..."); apiCloser() }()
 
@@ -312,6 +355,7 @@ func soloAction(ctx *cli.Context) error {
 		uint64(ctx.Int(gasLimitFlag.Name)),
 		ctx.Bool(onDemandFlag.Name),
 		skipLogs,
+		newCallTracer(ctx, repo, mainDB, forkConfig),
 		forkConfig).Run(exitSignal)
 }
 
@@ -0,0 +1,80 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package node
+
+import (
+	"math/rand"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/commu"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	sharer "github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/tx"
+)
+
+const (
+	doubleSignReportGas        = 200000
+	doubleSignReportExpiration = 720
+)
+
+// reportDoubleSign submits the evidence of double signing to Staker contract, by a tx signed with reporter key.
+// The reporter pays for the tx, so the report fails if it has no sufficient energy.
+func (n *Node) reportDoubleSign(ev *commu.DoubleSignEvent) {
+	best := n.repo.BestBlock().Header()
+	if best.Number()+1 < n.forkConfig.STAKING {
+		log.Debug("skip reporting double signing before fork", "signer", ev.Signer)
+		return
+	}
+	// the evidence is accepted only if the conflicting blocks are built on the canonical chain
+	parentID := ev.Headers[0].ParentID()
+	if id, err := n.repo.NewBestChain().GetBlockID(block.Number(parentID)); err != nil || id != parentID {
+		log.Debug("skip reporting double signing off the best chain", "signer", ev.Signer, "parent", parentID)
+		return
+	}
+
+	var args [2][]byte
+	for i, header := range ev.Headers {
+		data, err := rlp.EncodeToBytes(header)
+		if err != nil {
+			log.Warn("failed to encode header", "err", err)
+			return
+		}
+		args[i] = data
+	}
+	method, _ := sharer.Staker.ABI.MethodByName("reportDoubleSign")
+	data, err := method.EncodeInput(args[0], args[1])
+	if err != nil {
+		log.Warn("failed to encode double signing evidence", "err", err)
+		return
+	}
+
+	trx := new(tx.Builder).
+		ChainTag(n.repo.ChainTag()).
+		BlockRef(tx.NewBlockRef(best.Number())).
+		Expiration(doubleSignReportExpiration).
+		Gas(doubleSignReportGas).
+		Nonce(rand.Uint64()).
+		Clause(tx.NewClause(&sharer.Staker.Address).WithData(data)).
+		Build()
+	reporterKey := n.master.Reporter()
+	sig, err := crypto.Sign(trx.SigningHash().Bytes(), reporterKey)
+	if err != nil {
+		log.Warn("failed to sign double signing report", "err", err)
+		return
+	}
+	trx = trx.WithSignature(sig)
+
+	if err := n.txPool.AddLocal(trx); err != nil {
+		log.Error("failed to report double signing, check energy of the reporter or set another one by --reporter-key",
+			"signer", ev.Signer,
+			"reporter", luckyshare.Address(crypto.PubkeyToAddress(reporterKey.PublicKey)),
+			"err", err)
+		return
+	}
+	log.Info("double signing reported", "signer", ev.Signer, "number", ev.Headers[0].Number(), "tx", trx.ID())
+}
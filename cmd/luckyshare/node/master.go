@@ -15,8 +15,17 @@ import (
 type Master struct {
 	PrivateKey  *ecdsa.PrivateKey
 	Beneficiary *luckyshare.Address
+	ReporterKey *ecdsa.PrivateKey // to sign double signing reports, master key used if nil
 }
 
 func (m *Master) Address() luckyshare.Address {
 	return luckyshare.Address(crypto.PubkeyToAddress(m.PrivateKey.PublicKey))
 }
+
+// Reporter returns the key to sign double signing reports.
+func (m *Master) Reporter() *ecdsa.PrivateKey {
+	if m.ReporterKey != nil {
+		return m.ReporterKey
+	}
+	return m.PrivateKey
+}
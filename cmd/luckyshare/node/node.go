@@ -21,6 +21,7 @@ import (
 	"github.com/miniBamboo/luckyshare/cache"
 	"github.com/miniBamboo/luckyshare/chain"
 	"github.com/miniBamboo/luckyshare/cmd/luckyshare/bandwidth"
+	"github.com/miniBamboo/luckyshare/cmd/luckyshare/calltrace"
 	"github.com/miniBamboo/luckyshare/common/co"
 	"github.com/miniBamboo/luckyshare/commu"
 	"github.com/miniBamboo/luckyshare/consensus"
@@ -40,6 +41,7 @@ var log = log15.New("pkg", "node")
 type Node struct {
 	goes     co.Goes
 	packer   *packer.Packer
+	strategy packer.Strategy
 	cons     *consensus.Consensus
 	consLock sync.Mutex
 
@@ -52,8 +54,10 @@ type Node struct {
 	commitLock     sync.Mutex
 	targetGasLimit uint64
 	skipLogs       bool
+	callTracer     *calltrace.Tracer // nil if calls not indexed
 	logDBFailed    bool
 	bandwidth      bandwidth.Bandwidth
+	forkConfig     luckyshare.ForkConfig
 }
 
 func New(
@@ -66,10 +70,13 @@ func New(
 	commu *commu.Communicator,
 	targetGasLimit uint64,
 	skipLogs bool,
+	callTracer *calltrace.Tracer,
 	forkConfig luckyshare.ForkConfig,
+	strategy packer.Strategy,
 ) *Node {
 	return &Node{
 		packer:         packer.New(repo, stater, master.Address(), master.Beneficiary, forkConfig),
+		strategy:       strategy,
 		cons:           consensus.New(repo, stater, forkConfig),
 		master:         master,
 		repo:           repo,
@@ -79,6 +86,8 @@ func New(
 		commu:          commu,
 		targetGasLimit: targetGasLimit,
 		skipLogs:       skipLogs,
+		callTracer:     callTracer,
+		forkConfig:     forkConfig,
 	}
 }
 
@@ -138,6 +147,9 @@ func (n *Node) houseKeeping(ctx context.Context) {
 	newBlockCh := make(chan *commu.NewBlockEvent)
 	scope.Track(n.commu.SubscribeBlock(newBlockCh))
 
+	doubleSignCh := make(chan *commu.DoubleSignEvent)
+	scope.Track(n.commu.SubscribeDoubleSign(doubleSignCh))
+
 	futureTicker := time.NewTicker(time.Duration(luckyshare.BlockInterval) * time.Second)
 	defer futureTicker.Stop()
 
@@ -164,6 +176,8 @@ func (n *Node) houseKeeping(ctx context.Context) {
 				n.commu.BroadcastBlock(newBlock.Block)
 				log.Info(fmt.Sprintf("imported blocks (%v)", stats.processed), stats.LogContext(newBlock.Block.Header())...)
 			}
+		case ev := <-doubleSignCh:
+			n.reportDoubleSign(ev)
 		case <-futureTicker.C:
 			// process future blocks
 			var blocks []*block.Block
@@ -231,8 +245,8 @@ func (n *Node) txStashLoop(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case txEv := <-txCh:
-			// skip executables
-			if txEv.Executable != nil && *txEv.Executable {
+			// skip executables and removed ones
+			if txEv.Removed || (txEv.Executable != nil && *txEv.Executable) {
 				continue
 			}
 			// only stash non-executable txs
@@ -281,7 +295,7 @@ func (n *Node) processBlock(blk *block.Block, stats *blockStats) (bool, error) {
 		log.Debug("bandwidth updated", "gps", v)
 	}
 
-	stats.UpdateProcessed(1, len(receipts), execElapsed, commitElapsed, blk.Header().GasUsed())
+	stats.UpdateProcessed(1, len(blk.Transactions()), execElapsed, commitElapsed, blk.Header().GasUsed())
 	n.processFork(prevTrunk, curTrunk)
 	return prevTrunk.HeadID() != curTrunk.HeadID(), nil
 }
@@ -352,14 +366,28 @@ func (n *Node) writeLogs(diff []luckyshare.Bytes32, newBlock *block.Block, newRe
 			if err != nil {
 				return err
 			}
-			if err := w.Write(b, receipts); err != nil {
+			if err := n.writeBlockLogs(w, b, receipts); err != nil {
 				return err
 			}
 		}
-		return w.Write(newBlock, newReceipts)
+		return n.writeBlockLogs(w, newBlock, newReceipts)
 	})
 }
 
+func (n *Node) writeBlockLogs(w *logdb.Writer, b *block.Block, receipts tx.Receipts) error {
+	if err := w.Write(b, receipts); err != nil {
+		return err
+	}
+	if n.callTracer == nil {
+		return nil
+	}
+	calls, err := n.callTracer.TraceBlock(b)
+	if err != nil {
+		return errors.WithMessage(err, "trace calls")
+	}
+	return w.WriteCalls(b, calls)
+}
+
 func (n *Node) processFork(prevTrunk, curTrunk *chain.Chain) {
 	sideIds, err := prevTrunk.Exclude(curTrunk)
 	if err != nil {
@@ -101,7 +101,7 @@ func (n *Node) packerLoop(ctx context.Context) {
 
 func (n *Node) pack(flow *packer.Flow) error {
 	txs := n.txPool.Executables()
-	var txsToRemove []*tx.Transaction
+	var txsToRemove tx.Transactions
 	defer func() {
 		for _, tx := range txsToRemove {
 			n.txPool.Remove(tx.Hash(), tx.ID())
@@ -109,17 +109,8 @@ func (n *Node) pack(flow *packer.Flow) error {
 	}()
 
 	startTime := mclock.Now()
-	for _, tx := range txs {
-		if err := flow.Adopt(tx); err != nil {
-			if packer.IsGasLimitReached(err) {
-				break
-			}
-			if packer.IsTxNotAdoptableNow(err) {
-				continue
-			}
-			txsToRemove = append(txsToRemove, tx)
-		}
-	}
+	n.adoptBundles(flow)
+	txsToRemove = n.strategy.Adopt(flow, txs)
 
 	newBlock, stage, receipts, err := flow.Pack(n.master.PrivateKey)
 	if err != nil {
@@ -138,7 +129,7 @@ func (n *Node) pack(flow *packer.Flow) error {
 	if prevTrunk.HeadID() != curTrunk.HeadID() {
 		n.commu.BroadcastBlock(newBlock)
 		log.Info("📦 new block packed",
-			"txs", len(receipts),
+			"txs", len(newBlock.Transactions()),
 			"mgas", float64(newBlock.Header().GasUsed())/1000/1000,
 			"et", fmt.Sprintf("%v|%v", common.PrettyDuration(execElapsed), common.PrettyDuration(commitElapsed)),
 			"id", shortID(newBlock.Header().ID()),
@@ -150,3 +141,23 @@ func (n *Node) pack(flow *packer.Flow) error {
 	}
 	return nil
 }
+
+// adoptBundles adopts pooled bundles ahead of other txs.
+// Bundles never adoptable are removed, and bundles reverted or not adoptable for several times are dropped,
+// the others are kept until packed or expired.
+func (n *Node) adoptBundles(flow *packer.Flow) {
+	for _, b := range n.txPool.Bundles(flow.Number()) {
+		if err := flow.AdoptBundle(b.Txs); err != nil {
+			switch {
+			case packer.IsGasLimitReached(err):
+				// may fit in the next block
+			case packer.IsTxNotAdoptableNow(err) || packer.IsBundleReverted(err):
+				log.Debug("bundle failed", "id", b.ID(), "err", err)
+				n.txPool.FailBundle(b.ID())
+			default:
+				log.Debug("bundle not adoptable", "id", b.ID(), "err", err)
+				n.txPool.RemoveBundle(b.ID())
+			}
+		}
+	}
+}
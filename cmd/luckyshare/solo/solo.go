@@ -16,6 +16,7 @@ import (
 	"github.com/miniBamboo/luckyshare/block"
 	"github.com/miniBamboo/luckyshare/chain"
 	"github.com/miniBamboo/luckyshare/cmd/luckyshare/bandwidth"
+	"github.com/miniBamboo/luckyshare/cmd/luckyshare/calltrace"
 	"github.com/miniBamboo/luckyshare/common/co"
 	"github.com/miniBamboo/luckyshare/genesis"
 	"github.com/miniBamboo/luckyshare/logdb"
@@ -31,14 +32,15 @@ var log = log15.New("pkg", "solo")
 
 // Solo mode is the standalone client without p2p server
 type Solo struct {
-	repo      *chain.Repository
-	txPool    *txpool.TxPool
-	packer    *packer.Packer
-	logDB     *logdb.LogDB
-	gasLimit  uint64
-	bandwidth bandwidth.Bandwidth
-	onDemand  bool
-	skipLogs  bool
+	repo       *chain.Repository
+	txPool     *txpool.TxPool
+	packer     *packer.Packer
+	logDB      *logdb.LogDB
+	gasLimit   uint64
+	bandwidth  bandwidth.Bandwidth
+	onDemand   bool
+	skipLogs   bool
+	callTracer *calltrace.Tracer // nil if calls not indexed
 }
 
 // New returns Solo instance
@@ -50,6 +52,7 @@ func New(
 	gasLimit uint64,
 	onDemand bool,
 	skipLogs bool,
+	callTracer *calltrace.Tracer,
 	forkConfig luckyshare.ForkConfig,
 ) *Solo {
 	return &Solo{
@@ -61,10 +64,11 @@ func New(
 			genesis.DevAccounts()[0].Address,
 			&genesis.DevAccounts()[0].Address,
 			forkConfig),
-		logDB:    logDB,
-		gasLimit: gasLimit,
-		skipLogs: skipLogs,
-		onDemand: onDemand,
+		logDB:      logDB,
+		gasLimit:   gasLimit,
+		skipLogs:   skipLogs,
+		callTracer: callTracer,
+		onDemand:   onDemand,
 	}
 }
 
@@ -131,17 +135,15 @@ func (s *Solo) packing(pendingTxs tx.Transactions, onDemand bool) error {
 	}
 
 	startTime := mclock.Now()
-	for _, tx := range pendingTxs {
-		if err := flow.Adopt(tx); err != nil {
-			if packer.IsGasLimitReached(err) {
-				break
-			}
-			if packer.IsTxNotAdoptableNow(err) {
+	for _, b := range s.txPool.Bundles(flow.Number()) {
+		if err := flow.AdoptBundle(b.Txs); err != nil {
+			if packer.IsGasLimitReached(err) || packer.IsTxNotAdoptableNow(err) {
 				continue
 			}
-			txsToRemove = append(txsToRemove, tx)
+			s.txPool.RemoveBundle(b.ID())
 		}
 	}
+	txsToRemove = packer.DefaultStrategy{}.Adopt(flow, pendingTxs)
 
 	b, stage, receipts, err := flow.Pack(genesis.DevAccounts()[0].PrivateKey)
 	if err != nil {
@@ -168,7 +170,17 @@ func (s *Solo) packing(pendingTxs tx.Transactions, onDemand bool) error {
 
 	if !s.skipLogs {
 		if err := s.logDB.Log(func(w *logdb.Writer) error {
-			return w.Write(b, receipts)
+			if err := w.Write(b, receipts); err != nil {
+				return err
+			}
+			if s.callTracer == nil {
+				return nil
+			}
+			calls, err := s.callTracer.TraceBlock(b)
+			if err != nil {
+				return errors.WithMessage(err, "trace calls")
+			}
+			return w.WriteCalls(b, calls)
 		}); err != nil {
 			return errors.WithMessage(err, "commit log")
 		}
@@ -182,7 +194,7 @@ func (s *Solo) packing(pendingTxs tx.Transactions, onDemand bool) error {
 
 	blockID := b.Header().ID()
 	log.Info("📦 new block packed",
-		"txs", len(receipts),
+		"txs", len(b.Transactions()),
 		"mgas", float64(b.Header().GasUsed())/1000/1000,
 		"et", fmt.Sprintf("%v|%v", common.PrettyDuration(execElapsed), common.PrettyDuration(commitElapsed)),
 		"id", fmt.Sprintf("[#%v…%x]", block.Number(blockID), blockID[28:]),
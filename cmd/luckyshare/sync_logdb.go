@@ -13,6 +13,7 @@ import (
 
 	"github.com/miniBamboo/luckyshare/block"
 	"github.com/miniBamboo/luckyshare/chain"
+	"github.com/miniBamboo/luckyshare/cmd/luckyshare/calltrace"
 	"github.com/miniBamboo/luckyshare/logdb"
 	"github.com/miniBamboo/luckyshare/luckyshare"
 	"github.com/miniBamboo/luckyshare/tx"
@@ -27,8 +28,18 @@ func syncLogDB(ctx context.Context, repo *chain.Repository, logDB *logdb.LogDB,
 		return errors.Wrap(err, "seek log db sync position")
 	}
 	if verify && startPos > 0 {
-		if err := verifyLogDB(ctx, startPos-1, repo, logDB); err != nil {
-			return errors.Wrap(err, "verify log db")
+		pruned, err := logDB.PrunedBefore()
+		if err != nil {
+			return err
+		}
+		from := uint32(1)
+		if pruned > from {
+			from = pruned
+		}
+		if from < startPos {
+			if err := verifyLogDB(ctx, from, startPos-1, repo, logDB); err != nil {
+				return errors.Wrap(err, "verify log db")
+			}
 		}
 	}
 
@@ -44,9 +55,14 @@ func syncLogDB(ctx context.Context, repo *chain.Repository, logDB *logdb.LogDB,
 	} else {
 		fmt.Println(">> Syncing log db <<")
 	}
+	return writeLogDB(ctx, repo, logDB, nil, startPos, bestNum)
+}
 
-	pb := pb.New64(int64(bestNum)).
-		Set64(int64(startPos - 1)).
+// writeLogDB writes logs of blocks in range [from, to] of the best chain, and traced calls if
+// callTracer present. Logs of blocks after are dropped.
+func writeLogDB(ctx context.Context, repo *chain.Repository, logDB *logdb.LogDB, callTracer *calltrace.Tracer, from, to uint32) error {
+	pb := pb.New64(int64(to)).
+		Set64(int64(from - 1)).
 		SetMaxWidth(90).
 		Start()
 
@@ -54,7 +70,7 @@ func syncLogDB(ctx context.Context, repo *chain.Repository, logDB *logdb.LogDB,
 	bestChain := repo.NewBestChain()
 
 	if err := logDB.Log(func(w *logdb.Writer) error {
-		for i := startPos; i <= bestNum; i++ {
+		for i := from; i <= to; i++ {
 
 			b, err := bestChain.GetBlock(i)
 			if err != nil {
@@ -67,6 +83,15 @@ func syncLogDB(ctx context.Context, repo *chain.Repository, logDB *logdb.LogDB,
 			if err := w.Write(b, receipts); err != nil {
 				return err
 			}
+			if callTracer != nil {
+				calls, err := callTracer.TraceBlock(b)
+				if err != nil {
+					return errors.Wrap(err, "trace block")
+				}
+				if err := w.WriteCalls(b, calls); err != nil {
+					return err
+				}
+			}
 			if w.Len() > 2048 {
 				if err := w.Flush(); err != nil {
 					return err
@@ -119,7 +144,12 @@ func seekLogDBSyncPosition(repo *chain.Repository, logDB *logdb.LogDB) (uint32,
 		return 0, err
 	}
 
-	for header.Number() > 0 {
+	pruned, err := logDB.PrunedBefore()
+	if err != nil {
+		return 0, err
+	}
+	// no logs to be found before the pruned height
+	for header.Number() > 0 && header.Number() >= pruned {
 		has, err := logDB.HasBlockID(header.ID())
 		if err != nil {
 			return 0, err
@@ -138,10 +168,11 @@ func seekLogDBSyncPosition(repo *chain.Repository, logDB *logdb.LogDB) (uint32,
 
 }
 
-func verifyLogDB(ctx context.Context, endBlockNum uint32, repo *chain.Repository, logDB *logdb.LogDB) error {
+// verifyLogDB verifies logs of blocks in range [startBlockNum, endBlockNum] of the best chain against receipts.
+func verifyLogDB(ctx context.Context, startBlockNum, endBlockNum uint32, repo *chain.Repository, logDB *logdb.LogDB) error {
 	fmt.Println(">> Verifying log db <<")
 	pb := pb.New64(int64(endBlockNum)).
-		Set64(0).
+		Set64(int64(startBlockNum - 1)).
 		SetMaxWidth(90).
 		Start()
 	defer func() { pb.NotPrint = true }()
@@ -152,7 +183,7 @@ func verifyLogDB(ctx context.Context, endBlockNum uint32, repo *chain.Repository
 		chain       = repo.NewBestChain()
 		evLogs      []*logdb.Event
 		trLogs      []*logdb.Transfer
-		logLimit    = uint32(0)
+		logLimit    = startBlockNum - 1
 		splitEvLogs = func(id luckyshare.Bytes32) (logs []*logdb.Event) {
 			if len(evLogs) == 0 {
 				return
@@ -189,7 +220,7 @@ func verifyLogDB(ctx context.Context, endBlockNum uint32, repo *chain.Repository
 		}
 	)
 
-	for i := uint32(1); i <= endBlockNum; i++ {
+	for i := startBlockNum; i <= endBlockNum; i++ {
 		b, err := chain.GetBlock(i)
 		if err != nil {
 			return err
@@ -261,8 +292,7 @@ func verifyLogDBPerBlock(
 	var expectedTrLogs []*logdb.Transfer
 	txs := block.Transactions()
 	for txIndex, r := range receipts {
-		tx := txs[txIndex]
-		origin, _ := tx.Origin()
+		txID, origin := tx.ExecutionMeta(n, txs, receipts, txIndex)
 
 		for clauseIndex, output := range r.Outputs {
 			for _, ev := range output.Events {
@@ -275,7 +305,7 @@ func verifyLogDBPerBlock(
 					Index:       uint32(len(expectedEvLogs)),
 					BlockID:     id,
 					BlockTime:   ts,
-					TxID:        tx.ID(),
+					TxID:        txID,
 					TxOrigin:    origin,
 					ClauseIndex: uint32(clauseIndex),
 					Address:     ev.Address,
@@ -289,7 +319,7 @@ func verifyLogDBPerBlock(
 					Index:       uint32(len(expectedTrLogs)),
 					BlockID:     id,
 					BlockTime:   ts,
-					TxID:        tx.ID(),
+					TxID:        txID,
 					TxOrigin:    origin,
 					ClauseIndex: uint32(clauseIndex),
 					Sender:      tr.Sender,
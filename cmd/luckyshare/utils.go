@@ -37,6 +37,7 @@ import (
 	tty "github.com/mattn/go-tty"
 	"github.com/miniBamboo/luckyshare/api/doc"
 	"github.com/miniBamboo/luckyshare/chain"
+	"github.com/miniBamboo/luckyshare/cmd/luckyshare/calltrace"
 	"github.com/miniBamboo/luckyshare/cmd/luckyshare/node"
 	"github.com/miniBamboo/luckyshare/common/co"
 	"github.com/miniBamboo/luckyshare/commu"
@@ -45,6 +46,8 @@ import (
 	"github.com/miniBamboo/luckyshare/luckyshare"
 	"github.com/miniBamboo/luckyshare/muxdb"
 	"github.com/miniBamboo/luckyshare/p2psrv"
+	"github.com/miniBamboo/luckyshare/packer"
+	"github.com/miniBamboo/luckyshare/sharer"
 	"github.com/miniBamboo/luckyshare/state"
 	"github.com/miniBamboo/luckyshare/tx"
 	"github.com/miniBamboo/luckyshare/txpool"
@@ -129,6 +132,85 @@ func handleExitSignal() context.Context {
 	return ctx
 }
 
+// applyBlocklistOptions fills tx pool options with blocklist related flags.
+func applyBlocklistOptions(ctx *cli.Context, opts *txpool.Options, instanceDir string) error {
+	sources := splitAndTrim(ctx.String(txPoolBlocklistSourcesFlag.Name))
+	if len(sources) == 0 {
+		return nil
+	}
+
+	var signers []luckyshare.Address
+	for _, s := range splitAndTrim(ctx.String(txPoolBlocklistSignersFlag.Name)) {
+		addr, err := luckyshare.ParseAddress(s)
+		if err != nil {
+			return errors.Wrapf(err, "invalid blocklist signer [%v]", s)
+		}
+		signers = append(signers, addr)
+	}
+
+	mergeMode := ctx.String(txPoolBlocklistMergeFlag.Name)
+	switch mergeMode {
+	case txpool.BlocklistMergeUnion, txpool.BlocklistMergeIntersection:
+	default:
+		return fmt.Errorf("invalid value of -%s: %v", txPoolBlocklistMergeFlag.Name, mergeMode)
+	}
+
+	opts.BlocklistSources = sources
+	opts.BlocklistSigners = signers
+	opts.BlocklistMergeMode = mergeMode
+	opts.BlocklistCacheFilePath = filepath.Join(instanceDir, "blocklist.cache")
+	return nil
+}
+
+func splitAndTrim(s string) []string {
+	var strs []string
+	for _, str := range strings.Split(s, ",") {
+		if str = strings.TrimSpace(str); str != "" {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
+
+// loadTxPoolPolicies loads tx pool admission policies from the policy file if specified,
+// and reloads them on SIGHUP until exit signal received.
+func loadTxPoolPolicies(ctx *cli.Context, exitSignal context.Context, txPool *txpool.TxPool) error {
+	path := ctx.String(txPoolPolicyFileFlag.Name)
+	if path == "" {
+		return nil
+	}
+
+	policies, err := txpool.LoadPolicies(path)
+	if err != nil {
+		return errors.Wrapf(err, "load tx pool policies [%v]", path)
+	}
+	txPool.SetPolicies(policies)
+	log.Info("tx pool policies loaded", "count", len(policies))
+
+	go func() {
+		reloadSignalCh := make(chan os.Signal, 1)
+		signal.Notify(reloadSignalCh, syscall.SIGHUP)
+		defer signal.Stop(reloadSignalCh)
+
+		for {
+			select {
+			case <-exitSignal.Done():
+				return
+			case <-reloadSignalCh:
+				policies, err := txpool.LoadPolicies(path)
+				if err != nil {
+					// keep the current policies
+					log.Warn("failed to reload tx pool policies", "err", err, "path", path)
+					continue
+				}
+				txPool.SetPolicies(policies)
+				log.Info("tx pool policies reloaded", "count", len(policies))
+			}
+		}
+	}()
+	return nil
+}
+
 // middleware to limit request body size.
 func requestBodyLimit(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -333,6 +415,18 @@ func suggestFDCache() int {
 }
 
 func openLogDB(ctx *cli.Context, dir string) (*logdb.LogDB, error) {
+	backend := ctx.String(logDBFlag.Name)
+	if strings.HasPrefix(backend, "postgres://") || strings.HasPrefix(backend, "postgresql://") {
+		db, err := logdb.NewPostgres(backend)
+		if err != nil {
+			return nil, errors.Wrap(err, "open log database [postgres]")
+		}
+		return db, nil
+	}
+	if backend != "sqlite" {
+		return nil, fmt.Errorf("unsupported log db backend: %v", backend)
+	}
+
 	path := filepath.Join(dir, "logs.db")
 	db, err := logdb.New(path)
 	if err != nil {
@@ -341,6 +435,22 @@ func openLogDB(ctx *cli.Context, dir string) (*logdb.LogDB, error) {
 	return db, nil
 }
 
+// newCallTracer returns the call tracer if calls indexing enabled, or nil.
+func newCallTracer(ctx *cli.Context, repo *chain.Repository, mainDB *muxdb.MuxDB, forkConfig luckyshare.ForkConfig) *calltrace.Tracer {
+	if ctx.Bool(skipLogsFlag.Name) || !ctx.Bool(indexCallsFlag.Name) {
+		return nil
+	}
+	return calltrace.New(repo, state.NewStater(mainDB), forkConfig)
+}
+
+// indexTokens enables token indexing of the log db if required.
+func indexTokens(ctx *cli.Context, logDB *logdb.LogDB) {
+	if ctx.Bool(indexTokensFlag.Name) {
+		// energy balances grow with time, regardless of Transfer events
+		logDB.IndexTokens(sharer.Energy.Address)
+	}
+}
+
 func initChainRepository(gene *genesis.Genesis, mainDB *muxdb.MuxDB, logDB *logdb.LogDB) (*chain.Repository, error) {
 	genesisBlock, genesisEvents, genesisTransfers, err := gene.Build(state.NewStater(mainDB))
 	if err != nil {
@@ -375,6 +485,29 @@ func beneficiary(ctx *cli.Context) (*luckyshare.Address, error) {
 	return &addr, nil
 }
 
+func selectPackerStrategy(ctx *cli.Context) (packer.Strategy, error) {
+	switch name := ctx.String(packerStrategyFlag.Name); name {
+	case "default":
+		return packer.DefaultStrategy{}, nil
+	case "fair":
+		gasCap := ctx.Int(packerOriginGasCapFlag.Name)
+		if gasCap < 0 || gasCap > 100 {
+			return nil, fmt.Errorf("invalid value of -%s: %v", packerOriginGasCapFlag.Name, gasCap)
+		}
+		var reserved []luckyshare.Address
+		for _, s := range splitAndTrim(ctx.String(packerReservedOriginsFlag.Name)) {
+			addr, err := luckyshare.ParseAddress(s)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid reserved origin [%v]", s)
+			}
+			reserved = append(reserved, addr)
+		}
+		return packer.NewFairStrategy(uint64(gasCap), reserved), nil
+	default:
+		return nil, fmt.Errorf("invalid value of -%s: %v", packerStrategyFlag.Name, name)
+	}
+}
+
 func masterKeyPath(ctx *cli.Context) (string, error) {
 	configDir, err := makeConfigDir(ctx)
 	if err != nil {
@@ -396,6 +529,11 @@ func loadNodeMaster(ctx *cli.Context) (*node.Master, error) {
 	if master.Beneficiary, err = beneficiary(ctx); err != nil {
 		return nil, err
 	}
+	if path := ctx.String(reporterKeyFlag.Name); path != "" {
+		if master.ReporterKey, err = crypto.LoadECDSA(path); err != nil {
+			return nil, errors.Wrap(err, reporterKeyFlag.Name)
+		}
+	}
 	return master, nil
 }
 
@@ -530,6 +668,7 @@ func printStartupMessage1(
     Forks        [ %v ]
     Master       [ %v ]
     Beneficiary  [ %v ]
+    Reporter     [ %v ]
     Instance dir [ %v ]
 `,
 		common.MakeName("Luckyshare", fullVersion()),
@@ -543,6 +682,12 @@ func printStartupMessage1(
 			}
 			return master.Beneficiary.String()
 		}(),
+		func() string {
+			if master.ReporterKey == nil {
+				return "not set, defaults to master"
+			}
+			return luckyshare.Address(crypto.PubkeyToAddress(master.ReporterKey.PublicKey)).String()
+		}(),
 		dataDir)
 }
 
@@ -84,6 +84,7 @@ func (c *Communicator) fetchBlockByID(peer *Peer, newBlockID luckyshare.Bytes32)
 		return
 	}
 
+	c.checkDoubleSign(blk.Header())
 	c.newBlockFeed.Send(&NewBlockEvent{
 		Block: &blk,
 	})
@@ -37,6 +37,8 @@ type Communicator struct {
 	peerSet        *PeerSet
 	syncedCh       chan struct{}
 	newBlockFeed   event.Feed
+	doubleSignFeed event.Feed
+	seenHeaders    *seenHeaders
 	announcementCh chan *announcement
 	feedScope      event.SubscriptionScope
 	goes           co.Goes
@@ -53,6 +55,7 @@ func New(repo *chain.Repository, txPool *txpool.TxPool) *Communicator {
 		cancel:         cancel,
 		peerSet:        newPeerSet(),
 		syncedCh:       make(chan struct{}),
+		seenHeaders:    newSeenHeaders(),
 		announcementCh: make(chan *announcement),
 	}
 }
@@ -227,6 +230,11 @@ func (c *Communicator) SubscribeBlock(ch chan *NewBlockEvent) event.Subscription
 	return c.feedScope.Track(c.newBlockFeed.Subscribe(ch))
 }
 
+// SubscribeDoubleSign subscribe the event that conflicting blocks from one signer detected.
+func (c *Communicator) SubscribeDoubleSign(ch chan *DoubleSignEvent) event.Subscription {
+	return c.feedScope.Track(c.doubleSignFeed.Subscribe(ch))
+}
+
 // BroadcastBlock broadcast a block to remote peers.
 func (c *Communicator) BroadcastBlock(blk *block.Block) {
 	peers := c.peerSet.Slice().Filter(func(p *Peer) bool {
@@ -0,0 +1,69 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package commu
+
+import (
+	"sync"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+)
+
+const maxSeenHeaders = 2048
+
+type signerSlot struct {
+	signer    luckyshare.Address
+	parentID  luckyshare.Bytes32
+	timestamp uint64
+}
+
+// seenHeaders remembers recently received headers by signer and slot.
+// Headers conflict only if they are built on the same parent at the same timestamp.
+type seenHeaders struct {
+	lru  *simplelru.LRU
+	lock sync.Mutex
+}
+
+func newSeenHeaders() *seenHeaders {
+	lru, _ := simplelru.NewLRU(maxSeenHeaders, nil)
+	return &seenHeaders{lru: lru}
+}
+
+// add adds the header and returns the previously seen header which conflicts with it.
+// A conflict is returned only once.
+func (s *seenHeaders) add(signer luckyshare.Address, header *block.Header) *block.Header {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := signerSlot{signer, header.ParentID(), header.Timestamp()}
+	prev, ok := s.lru.Get(key)
+	if !ok {
+		s.lru.Add(key, header)
+		return nil
+	}
+	if prev == nil || prev.(*block.Header).ID() == header.ID() {
+		// no conflict, or already reported
+		return nil
+	}
+	s.lru.Add(key, nil)
+	return prev.(*block.Header)
+}
+
+// checkDoubleSign emits DoubleSignEvent if the header conflicts with another one seen before.
+func (c *Communicator) checkDoubleSign(header *block.Header) {
+	signer, err := header.Signer()
+	if err != nil {
+		return
+	}
+	if prev := c.seenHeaders.add(signer, header); prev != nil {
+		log.Warn("double signing detected", "signer", signer, "number", header.Number(), "id1", prev.ID(), "id2", header.ID())
+		c.doubleSignFeed.Send(&DoubleSignEvent{
+			Signer:  signer,
+			Headers: [2]*block.Header{prev, header},
+		})
+	}
+}
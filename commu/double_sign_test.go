@@ -0,0 +1,39 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package commu
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeenHeaders(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := luckyshare.Address(crypto.PubkeyToAddress(key.PublicKey))
+
+	build := func(ts uint64, gasLimit uint64) *block.Header {
+		blk := new(block.Builder).Timestamp(ts).GasLimit(gasLimit).Build()
+		sig, _ := crypto.Sign(blk.Header().SigningHash().Bytes(), key)
+		return blk.WithSignature(sig).Header()
+	}
+	h1 := build(10, 1)
+	h2 := build(10, 2)
+	assert.Equal(t, h1.Number(), h2.Number())
+
+	seen := newSeenHeaders()
+	assert.Nil(t, seen.add(signer, h1))
+	assert.Nil(t, seen.add(signer, h1))
+	// same height but different slot
+	assert.Nil(t, seen.add(signer, build(20, 1)))
+	assert.Equal(t, h1, seen.add(signer, h2))
+	// reported only once
+	assert.Nil(t, seen.add(signer, h2))
+	assert.Nil(t, seen.add(signer, build(10, 3)))
+}
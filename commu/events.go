@@ -9,6 +9,7 @@ import (
 	"context"
 
 	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/luckyshare"
 )
 
 // NewBlockEvent event emitted when received block announcement.
@@ -16,5 +17,11 @@ type NewBlockEvent struct {
 	*block.Block
 }
 
+// DoubleSignEvent event emitted when two different blocks signed by one signer in the same slot received.
+type DoubleSignEvent struct {
+	Signer  luckyshare.Address
+	Headers [2]*block.Header
+}
+
 // HandleBlockStream to handle the stream of downloaded blocks in sync process.
 type HandleBlockStream func(ctx context.Context, stream <-chan *block.Block) error
@@ -80,6 +80,21 @@ func (c *Consensus) Process(blk *block.Block, nowTimestamp uint64) (*state.Stage
 		features |= tx.DelegationFeature
 	}
 
+	// Before process hook of DYNAMIC_FEE, update base gas price according to parent's gas usage
+	if err := runtime.UpdateBaseGasPrice(state, parentSummary.Header, c.forkConfig); err != nil {
+		return nil, nil, err
+	}
+
+	// Before process hook of STAKING, deploy sharer staker contract
+	if err := runtime.ActivateStaking(state, header.Number(), c.forkConfig); err != nil {
+		return nil, nil, err
+	}
+
+	// Before process hook of SCHEDULER, deploy sharer scheduler contract
+	if err := runtime.ActivateScheduler(state, header.Number(), c.forkConfig); err != nil {
+		return nil, nil, err
+	}
+
 	if header.TxsFeatures() != features {
 		return nil, nil, consensusError(fmt.Sprintf("block txs features invalid: want %v, have %v", features, header.TxsFeatures()))
 	}
@@ -110,8 +125,17 @@ func (c *Consensus) NewRuntimeForReplay(header *block.Header, skipPoA bool) (*ru
 			return nil, err
 		}
 	}
+	if err := runtime.UpdateBaseGasPrice(state, parentSummary.Header, c.forkConfig); err != nil {
+		return nil, err
+	}
+	if err := runtime.ActivateStaking(state, header.Number(), c.forkConfig); err != nil {
+		return nil, err
+	}
+	if err := runtime.ActivateScheduler(state, header.Number(), c.forkConfig); err != nil {
+		return nil, err
+	}
 
-	return runtime.New(
+	rt := runtime.New(
 		c.repo.NewChain(header.ParentID()),
 		state,
 		&xenv.BlockContext{
@@ -122,5 +146,11 @@ func (c *Consensus) NewRuntimeForReplay(header *block.Header, skipPoA bool) (*ru
 			GasLimit:    header.GasLimit(),
 			TotalScore:  header.TotalScore(),
 		},
-		c.forkConfig), nil
+		c.forkConfig)
+
+	// scheduled clauses are executed ahead of txs
+	if _, err := rt.ExecuteScheduled(); err != nil {
+		return nil, err
+	}
+	return rt, nil
 }
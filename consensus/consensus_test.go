@@ -109,9 +109,16 @@ func newTestConsensus(t *testing.T) *testConsensus {
 	}
 
 	forkConfig := luckyshare.ForkConfig{
-		VIP191:    math.MaxUint32,
-		ETH_CONST: math.MaxUint32,
-		BLOCKLIST: 0,
+		VIP191:       math.MaxUint32,
+		ETH_CONST:    math.MaxUint32,
+		BLOCKLIST:    0,
+		ETH_IST:      math.MaxUint32,
+		ETH_BERLIN:   math.MaxUint32,
+		ETH_SHANGHAI: math.MaxUint32,
+		ETH_PRAGUE:   math.MaxUint32,
+		DYNAMIC_FEE:  math.MaxUint32,
+		STAKING:      math.MaxUint32,
+		SCHEDULER:    math.MaxUint32,
 	}
 
 	con := New(repo, stater, forkConfig)
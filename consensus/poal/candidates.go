@@ -1,6 +1,8 @@
 package poal
 
 import (
+	"math/big"
+
 	"github.com/miniBamboo/luckyshare/luckyshare"
 	sharer "github.com/miniBamboo/luckyshare/sharer"
 	"github.com/miniBamboo/luckyshare/sharer/authority"
@@ -44,7 +46,8 @@ func (c *Candidates) Copy() *Candidates {
 }
 
 // Pick picks a list of proposers, which satisfy preset conditions.
-func (c *Candidates) Pick(state *state.State) ([]Proposer, error) {
+// Tokens delegated to endorsors are counted only if staking is true.
+func (c *Candidates) Pick(state *state.State, staking bool) ([]Proposer, error) {
 	satisfied := c.satisfied
 	if len(satisfied) == 0 {
 		// re-pick
@@ -59,6 +62,13 @@ func (c *Candidates) Pick(state *state.State) ([]Proposer, error) {
 			if err != nil {
 				return nil, err
 			}
+			if staking {
+				delegated, err := sharer.Staker.Native(state).Delegated(c.list[i].Endorsor)
+				if err != nil {
+					return nil, err
+				}
+				bal = new(big.Int).Add(bal, delegated)
+			}
 			if bal.Cmp(endorsement) >= 0 {
 				satisfied = append(satisfied, i)
 			}
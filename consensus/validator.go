@@ -66,7 +66,7 @@ func (c *Consensus) validate(
 			for _, r := range receipts {
 				for _, o := range r.Outputs {
 					for _, ev := range o.Events {
-						if ev.Address == sharer.Params.Address {
+						if ev.Address == sharer.Params.Address || ev.Address == sharer.Staker.Address {
 							return true
 						}
 					}
@@ -133,7 +133,7 @@ func (c *Consensus) validateProposer(header *block.Header, parent *block.Header,
 		candidates = poal.NewCandidates(list)
 	}
 
-	proposers, err := candidates.Pick(st)
+	proposers, err := candidates.Pick(st, header.Number() >= c.forkConfig.STAKING)
 	if err != nil {
 		return nil, err
 	}
@@ -236,6 +236,16 @@ func (c *Consensus) verifyBlock(blk *block.Block, state *state.State) (*state.St
 		return true, meta.Reverted, nil
 	}
 
+	scheduled, err := rt.ExecuteScheduled()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, r := range scheduled {
+		totalGasUsed += r.GasUsed
+	}
+	// receipts are in execution order
+	receipts = append(receipts, scheduled...)
+
 	for _, tx := range txs {
 		// check if tx existed
 		if found, _, err := findTx(tx.ID()); err != nil {
@@ -27,6 +27,7 @@ require (
 	github.com/inconshreveable/log15 v0.0.0-20171019012758-0decfc6c20d9
 	github.com/jbenet/go-base58 v0.0.0-20150317085156-6237cf65f3a6
 	github.com/karalabe/hid v1.0.0 // indirect
+	github.com/lib/pq v1.10.0
 	github.com/mattn/go-isatty v0.0.5-0.20180830101745-3fb116b82035
 	github.com/mattn/go-sqlite3 v1.14.1
 	github.com/mattn/go-tty v0.0.0-20180907095812-13ff1204f104
@@ -0,0 +1,86 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logdb
+
+import (
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/tx"
+)
+
+// Roles of an address in a transaction, as bit flags of Activity.
+const (
+	ActivityOrigin    uint32 = 1 << iota // the tx sender
+	ActivityGasPayer                     // the gas payer other than the origin, i.e. the delegator or sponsor
+	ActivityRecipient                    // the recipient of any clause
+)
+
+// Activity relates an address to a transaction.
+type Activity struct {
+	BlockNumber uint32
+	Index       uint32 // index of the activity in block
+	BlockID     luckyshare.Bytes32
+	BlockTime   uint64
+	TxID        luckyshare.Bytes32
+	Address     luckyshare.Address
+	Flags       uint32
+}
+
+// Cursor returns the cursor of the activity.
+func (a *Activity) Cursor() *Cursor {
+	return &Cursor{a.BlockNumber, a.Index}
+}
+
+// ActivityFilter matches activities of the address, in any of roles in Flags, or all roles if zero.
+type ActivityFilter struct {
+	Address luckyshare.Address
+	Flags   uint32
+	Range   *Range
+	Options *Options
+	Order   Order //default asc
+}
+
+func (f *ActivityFilter) toWhereCondition() (cond string, args []interface{}) {
+	cond, args = f.Range.toWhereCondition()
+	cond += " AND address = " + refIDQuery
+	args = append(args, f.Address.Bytes())
+	if f.Flags != 0 {
+		cond += " AND (flags & ?) <> 0"
+		args = append(args, f.Flags)
+	}
+	return
+}
+
+// addressRole is an address with its roles in a transaction.
+type addressRole struct {
+	addr  luckyshare.Address
+	flags uint32
+}
+
+// txActivities returns addresses related to the transaction, in the order of first appearance.
+// The gas payer is unknown without receipt.
+func txActivities(t *tx.Transaction, receipt *tx.Receipt) (roles []*addressRole) {
+	add := func(addr luckyshare.Address, flag uint32) {
+		for _, r := range roles {
+			if r.addr == addr {
+				r.flags |= flag
+				return
+			}
+		}
+		roles = append(roles, &addressRole{addr, flag})
+	}
+
+	origin, _ := t.Origin()
+	add(origin, ActivityOrigin)
+	if receipt != nil && !receipt.GasPayer.IsZero() && receipt.GasPayer != origin {
+		add(receipt.GasPayer, ActivityGasPayer)
+	}
+	for _, c := range t.Clauses() {
+		if to := c.To(); to != nil {
+			add(*to, ActivityRecipient)
+		}
+	}
+	return
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logdb
+
+import (
+	"context"
+
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/tx"
+)
+
+// Backend is the storage engine of LogDB.
+type Backend interface {
+	FilterEvents(ctx context.Context, filter *EventFilter) ([]*Event, error)
+	FilterTransfers(ctx context.Context, filter *TransferFilter) ([]*Transfer, error)
+	CountEvents(ctx context.Context, filter *EventFilter) (uint64, error)
+	CountTransfers(ctx context.Context, filter *TransferFilter) (uint64, error)
+	FilterCalls(ctx context.Context, filter *CallFilter) ([]*Call, error)
+	CountCalls(ctx context.Context, filter *CallFilter) (uint64, error)
+	FilterTokenTransfers(ctx context.Context, filter *TokenTransferFilter) ([]*TokenTransfer, error)
+	// TokenHolders returns balances of the token, in descending order of balance.
+	TokenHolders(ctx context.Context, token luckyshare.Address, offset, limit uint64) ([]*TokenBalance, error)
+	// HolderTokens returns balances of tokens held by the holder.
+	HolderTokens(ctx context.Context, holder luckyshare.Address, offset, limit uint64) ([]*TokenBalance, error)
+	FilterActivities(ctx context.Context, filter *ActivityFilter) ([]*Activity, error)
+	// NewestBlockID query newest written block id.
+	NewestBlockID() (luckyshare.Bytes32, error)
+	// HasBlockID query whether given block id related logs were written.
+	HasBlockID(id luckyshare.Bytes32) (bool, error)
+	NewWriter() BackendWriter
+	// Prune deletes logs of blocks before the given block number, along with refs no longer used.
+	// Token balances are kept. The pruned height is kept, and logs before it are not written any more.
+	Prune(ctx context.Context, before uint32) error
+	// PrunedBefore returns the block number logs are pruned before, 0 if never pruned.
+	PrunedBefore() (uint32, error)
+	// Vacuum reclaims space of deleted data.
+	Vacuum(ctx context.Context) error
+	// Path returns the location of the storage, for display.
+	Path() string
+	Close() error
+}
+
+// BackendWriter writes logs into Backend in a transaction.
+type BackendWriter interface {
+	// Write writes all logs and activities of the given block. Logs of the block and blocks after are
+	// overwritten if it's the first block written in the transaction. Logs of blocks pruned are skipped.
+	Write(b *block.Block, receipts tx.Receipts) error
+	// WriteCalls writes traced calls of the given block, which should be written by Write before.
+	// Block related fields of calls are ignored, and calls are indexed in the given order.
+	WriteCalls(b *block.Block, calls []*Call) error
+	// WriteTokenTransfers writes token transfers of the given block, and accumulates them into
+	// balances. Token transfers already written of the block are reverted first.
+	WriteTokenTransfers(b *block.Block, transfers []*TokenTransfer) error
+	// Flush commits accumulated logs.
+	Flush() error
+	// Rollback discards accumulated logs.
+	Rollback() error
+	// Len returns count of pending logs.
+	Len() int
+}
+
+var (
+	eventColumns    = []string{"blockID", "blockTime", "txID", "txOrigin", "clauseIndex", "address", "topic0", "topic1", "topic2", "topic3", "topic4", "data"}
+	transferColumns = []string{"blockID", "blockTime", "txID", "txOrigin", "clauseIndex", "sender", "recipient", "amount"}
+	callColumns     = []string{"blockID", "blockTime", "txID", "txOrigin", "clauseIndex", "depth", "type", "caller", "callee", "selector", "value", "reverted"}
+	tokenColumns    = []string{"blockID", "blockTime", "txID", "txOrigin", "clauseIndex", "token", "sender", "recipient", "amount"}
+	activityColumns = []string{"blockID", "blockTime", "txID", "address", "flags"}
+
+	// columns referring to ref table, of each table
+	refColumns = []struct {
+		table   string
+		columns []string
+	}{
+		{"event", []string{"blockID", "txID", "txOrigin", "address", "topic0", "topic1", "topic2", "topic3", "topic4"}},
+		{"transfer", []string{"blockID", "txID", "txOrigin", "sender", "recipient"}},
+		{"call", []string{"blockID", "txID", "txOrigin", "caller", "callee", "selector"}},
+		{"token_transfer", []string{"blockID", "txID", "txOrigin", "token", "sender", "recipient"}},
+		{"token_balance", []string{"token", "holder"}},
+		{"activity", []string{"blockID", "txID", "address"}},
+	}
+)
+
+// dialect adapts statements of sqlBackend, which are written in sqlite flavor, to a database.
+type dialect struct {
+	driver string
+	schema string
+	// rebind replaces '?' placeholders of the query.
+	rebind func(query string) string
+	// insertOrIgnore makes the insert statement ignore conflicting rows.
+	insertOrIgnore func(query string) string
+	// insertOrReplace makes the insert statement replace conflicting rows, which conflict on key column.
+	insertOrReplace func(query string, key string, columns []string) string
+}
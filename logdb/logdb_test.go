@@ -7,8 +7,13 @@ package logdb_test
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"crypto/rand"
+	"database/sql"
+	"io/ioutil"
 	"math/big"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/crypto"
@@ -99,6 +104,45 @@ func TestEvents(t *testing.T) {
 	}
 	defer db.Close()
 
+	testEvents(t, db)
+}
+
+// TestPostgres runs against the postgres database given by env LOGDB_TEST_POSTGRES,
+// e.g. 'postgres://postgres@localhost/logdb_test?sslmode=disable'. Tables are dropped before testing.
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv("LOGDB_TEST_POSTGRES")
+	if dsn == "" {
+		t.Skip("LOGDB_TEST_POSTGRES not set")
+	}
+
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec("DROP TABLE IF EXISTS config, ref, event, transfer, call, token_transfer, token_balance, activity"); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	db, err := logdb.NewPostgres(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	testEvents(t, db)
+	testCalls(t, db)
+	testTokens(t, db)
+	testActivities(t, db)
+
+	newest, err := db.NewestBlockID()
+	assert.Nil(t, err)
+	has, err := db.HasBlockID(newest)
+	assert.Nil(t, err)
+	assert.True(t, has)
+}
+
+func testEvents(t *testing.T, db *logdb.LogDB) {
 	b := new(block.Builder).Build()
 
 	var allEvents eventLogs
@@ -168,6 +212,14 @@ func TestEvents(t *testing.T) {
 			{"query all events with multi-criteria", &logdb.EventFilter{CriteriaSet: []*logdb.EventCriteria{{Address: &allEvents[1].Address}, {Topics: [5]*luckyshare.Bytes32{allEvents[2].Topics[0]}}, {Topics: [5]*luckyshare.Bytes32{allEvents[3].Topics[0]}}}}, allEvents.Filter(func(ev *logdb.Event) bool {
 				return ev.Address == allEvents[1].Address || *ev.Topics[0] == *allEvents[2].Topics[0] || *ev.Topics[0] == *allEvents[3].Topics[0]
 			})},
+			{"query all events with address set", &logdb.EventFilter{CriteriaSet: []*logdb.EventCriteria{{Addresses: []luckyshare.Address{allEvents[1].Address, allEvents[2].Address}}}}, allEvents.Filter(func(ev *logdb.Event) bool {
+				return ev.Address == allEvents[1].Address || ev.Address == allEvents[2].Address
+			})},
+			{"query all events with topic set", &logdb.EventFilter{CriteriaSet: []*logdb.EventCriteria{{Topics: [5]*luckyshare.Bytes32{allEvents[1].Topics[0]}, TopicSets: [5][]luckyshare.Bytes32{{*allEvents[2].Topics[0]}}}}}, allEvents.Filter(func(ev *logdb.Event) bool {
+				return *ev.Topics[0] == *allEvents[1].Topics[0] || *ev.Topics[0] == *allEvents[2].Topics[0]
+			})},
+			{"query all events cursor", &logdb.EventFilter{Options: &logdb.Options{Limit: 10, Cursor: allEvents[5].Cursor()}}, allEvents[6:16]},
+			{"query all events cursor desc", &logdb.EventFilter{Options: &logdb.Options{Limit: 10, Cursor: allEvents[15].Cursor()}, Order: logdb.DESC}, allEvents[5:15].Reverse()},
 		}
 
 		for _, tt := range tests {
@@ -196,6 +248,10 @@ func TestEvents(t *testing.T) {
 			{"query all transfers with multi-criteria", &logdb.TransferFilter{CriteriaSet: []*logdb.TransferCriteria{{Sender: &allTransfers[1].Sender}, {Recipient: &allTransfers[2].Recipient}}}, allTransfers.Filter(func(tr *logdb.Transfer) bool {
 				return tr.Sender == allTransfers[1].Sender || tr.Recipient == allTransfers[2].Recipient
 			})},
+			{"query all transfers with address sets", &logdb.TransferFilter{CriteriaSet: []*logdb.TransferCriteria{{Senders: []luckyshare.Address{allTransfers[1].Sender, allTransfers[2].Sender}, Recipient: &allTransfers[2].Recipient}}}, allTransfers.Filter(func(tr *logdb.Transfer) bool {
+				return tr.Sender == allTransfers[2].Sender && tr.Recipient == allTransfers[2].Recipient
+			})},
+			{"query all transfers cursor", &logdb.TransferFilter{Options: &logdb.Options{Limit: 10, Cursor: allTransfers[5].Cursor()}}, allTransfers[6:16]},
 		}
 
 		for _, tt := range tests {
@@ -206,4 +262,464 @@ func TestEvents(t *testing.T) {
 			})
 		}
 	}
+
+	count, err := db.CountEvents(context.Background(), &logdb.EventFilter{Range: &logdb.Range{From: 10, To: 20}})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(22), count)
+
+	count, err = db.CountTransfers(context.Background(), &logdb.TransferFilter{CriteriaSet: []*logdb.TransferCriteria{{Senders: []luckyshare.Address{allTransfers[1].Sender, allTransfers[2].Sender}}}})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), count)
+}
+
+type callLogs []*logdb.Call
+
+func (logs callLogs) Filter(f func(c *logdb.Call) bool) (ret callLogs) {
+	for _, c := range logs {
+		if f(c) {
+			ret = append(ret, c)
+		}
+	}
+	return
+}
+
+func (logs callLogs) Reverse() (ret callLogs) {
+	for i := len(logs) - 1; i >= 0; i-- {
+		ret = append(ret, logs[i])
+	}
+	return
+}
+
+func TestCalls(t *testing.T) {
+	db, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	testCalls(t, db)
+}
+
+func testCalls(t *testing.T, db *logdb.LogDB) {
+	var (
+		b        = new(block.Builder).Build()
+		contract = randAddress()
+		selector = []byte{0xa9, 0x05, 0x9c, 0xbb}
+		allCalls callLogs
+	)
+
+	for i := 0; i < 20; i++ {
+		b = new(block.Builder).
+			ParentID(b.Header().ID()).
+			Transaction(newTx()).
+			Build()
+		trx := b.Transactions()[0]
+		origin, _ := trx.Origin()
+
+		calls := []*logdb.Call{
+			{Depth: 0, Type: "call", Caller: origin, Callee: contract, Selector: selector, Value: big.NewInt(int64(i))},
+			{Depth: 1, Type: "delegatecall", Caller: contract, Callee: randAddress(), Selector: randBytes32().Bytes()[:4], Value: &big.Int{}},
+			{Depth: 1, Type: "create", Caller: contract, Value: &big.Int{}, Reverted: true},
+		}
+		for j, c := range calls {
+			c.BlockNumber = b.Header().Number()
+			c.Index = uint32(j)
+			c.BlockID = b.Header().ID()
+			c.BlockTime = b.Header().Timestamp()
+			c.TxID = trx.ID()
+			c.TxOrigin = origin
+			allCalls = append(allCalls, c)
+		}
+
+		if err := db.Log(func(w *logdb.Writer) error {
+			if err := w.Write(b, tx.Receipts{newReceipt()}); err != nil {
+				return err
+			}
+			return w.WriteCalls(b, calls)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		arg  *logdb.CallFilter
+		want callLogs
+	}{
+		{"query all calls", &logdb.CallFilter{}, allCalls},
+		{"query all calls desc", &logdb.CallFilter{Order: logdb.DESC}, allCalls.Reverse()},
+		{"query all calls limit offset", &logdb.CallFilter{Options: &logdb.Options{Offset: 1, Limit: 10}}, allCalls[1:11]},
+		{"query all calls range", &logdb.CallFilter{Range: &logdb.Range{From: 5, To: 8}}, allCalls.Filter(func(c *logdb.Call) bool { return c.BlockNumber >= 5 && c.BlockNumber <= 8 })},
+		{"query calls by caller", &logdb.CallFilter{CriteriaSet: []*logdb.CallCriteria{{Caller: &contract}}}, allCalls.Filter(func(c *logdb.Call) bool {
+			return c.Caller == contract
+		})},
+		{"query calls by callee and selector", &logdb.CallFilter{CriteriaSet: []*logdb.CallCriteria{{Callee: &contract, Selector: selector}}}, allCalls.Filter(func(c *logdb.Call) bool {
+			return c.Callee == contract
+		})},
+		{"query calls by selector", &logdb.CallFilter{CriteriaSet: []*logdb.CallCriteria{{Selector: allCalls[1].Selector}, {Callee: &allCalls[4].Callee}}}, callLogs{allCalls[1], allCalls[4]}},
+		{"query calls cursor", &logdb.CallFilter{Options: &logdb.Options{Limit: 5, Cursor: allCalls[5].Cursor()}}, allCalls[6:11]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.FilterCalls(context.Background(), tt.arg)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, callLogs(got))
+		})
+	}
+
+	count, err := db.CountCalls(context.Background(), &logdb.CallFilter{CriteriaSet: []*logdb.CallCriteria{{Caller: &contract}}})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(40), count)
+
+	// calls of rewritten blocks are replaced
+	if err := db.Log(func(w *logdb.Writer) error {
+		return w.Write(b, nil)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	count, err = db.CountCalls(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(len(allCalls)-3), count)
+}
+
+func newTokenTransferEvent(token, from, to luckyshare.Address, amount int64) *tx.Event {
+	return &tx.Event{
+		Address: token,
+		Topics: []luckyshare.Bytes32{
+			luckyshare.MustParseBytes32("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"),
+			luckyshare.BytesToBytes32(from.Bytes()),
+			luckyshare.BytesToBytes32(to.Bytes()),
+		},
+		Data: luckyshare.BytesToBytes32(big.NewInt(amount).Bytes()).Bytes(),
+	}
+}
+
+func TestTokens(t *testing.T) {
+	db, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	testTokens(t, db)
+}
+
+func testTokens(t *testing.T, db *logdb.LogDB) {
+	var (
+		token    = randAddress()
+		excluded = randAddress()
+		holders  = []luckyshare.Address{randAddress(), randAddress(), randAddress(), randAddress(), randAddress()}
+		zero     luckyshare.Address
+	)
+	db.IndexTokens(excluded)
+
+	write := func(b *block.Block, events ...*tx.Event) {
+		if err := db.Log(func(w *logdb.Writer) error {
+			return w.Write(b, tx.Receipts{{Outputs: []*tx.Output{{Events: events}}}})
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the genesis block may be written more than once
+	b := new(block.Builder).ParentID(luckyshare.Bytes32{0xff, 0xff, 0xff, 0xff}).Build()
+	for i := 0; i < 2; i++ {
+		write(b, newTokenTransferEvent(token, zero, holders[3], 5))
+	}
+
+	var blocks []*block.Block
+	for _, events := range [][]*tx.Event{
+		{newTokenTransferEvent(token, zero, holders[0], 100)},
+		{newTokenTransferEvent(token, holders[0], holders[1], 30), newTokenTransferEvent(token, holders[4], holders[0], 1)},
+		{
+			newReceipt().Outputs[0].Events[0],
+			newTokenTransferEvent(token, holders[1], holders[2], 10),
+			newTokenTransferEvent(excluded, zero, holders[2], 10),
+		},
+	} {
+		b = new(block.Builder).
+			ParentID(b.Header().ID()).
+			Transaction(newTx()).
+			Build()
+		write(b, events...)
+		blocks = append(blocks, b)
+	}
+
+	balances := func(token luckyshare.Address, values ...int64) (ret []*logdb.TokenBalance) {
+		for i, v := range values {
+			ret = append(ret, &logdb.TokenBalance{Token: token, Holder: holders[i], Balance: big.NewInt(v)})
+		}
+		return
+	}
+
+	got, err := db.TokenHolders(context.Background(), token, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, balances(token, 71, 20, 10, 5, -1), got)
+
+	got, err = db.TokenHolders(context.Background(), token, 1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, balances(token, 71, 20, 10)[1:], got)
+
+	got, err = db.HolderTokens(context.Background(), holders[2], 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, balances(token, 0, 0, 10)[2:], got)
+
+	transfers, err := db.FilterTokenTransfers(context.Background(), &logdb.TokenTransferFilter{
+		CriteriaSet: []*logdb.TokenTransferCriteria{{Recipient: &holders[2]}},
+	})
+	assert.Nil(t, err)
+	if assert.Len(t, transfers, 1) {
+		trx := blocks[2].Transactions()[0]
+		origin, _ := trx.Origin()
+		assert.Equal(t, &logdb.TokenTransfer{
+			BlockNumber: 3,
+			Index:       1,
+			BlockID:     blocks[2].Header().ID(),
+			BlockTime:   blocks[2].Header().Timestamp(),
+			TxID:        trx.ID(),
+			TxOrigin:    origin,
+			Token:       token,
+			Sender:      holders[1],
+			Recipient:   holders[2],
+			Amount:      big.NewInt(10),
+		}, transfers[0])
+	}
+
+	transfers, err = db.FilterTokenTransfers(context.Background(), &logdb.TokenTransferFilter{
+		CriteriaSet: []*logdb.TokenTransferCriteria{{Sender: &holders[0]}, {Sender: &holders[4]}},
+		Order:       logdb.DESC,
+	})
+	assert.Nil(t, err)
+	if assert.Len(t, transfers, 2) {
+		assert.Equal(t, holders[4], transfers[0].Sender)
+		assert.Equal(t, holders[0], transfers[1].Sender)
+	}
+
+	// transfers of rewritten blocks are reverted
+	write(blocks[1])
+	got, err = db.TokenHolders(context.Background(), token, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, balances(token, 100, 0, 0, 5)[:1], got[:1])
+	assert.Equal(t, balances(token, 100, 0, 0, 5)[3:], got[1:])
+
+	transfers, err = db.FilterTokenTransfers(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Len(t, transfers, 2)
+}
+
+func TestPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "logs.db")
+	db, err := logdb.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var (
+		b         = new(block.Builder).Build()
+		blocks    []*block.Block
+		receipts  tx.Receipts
+		allEvents eventLogs
+	)
+	for i := 0; i < 10; i++ {
+		b = new(block.Builder).
+			ParentID(b.Header().ID()).
+			Transaction(newTx()).
+			Build()
+		receipt := newReceipt()
+		trx := b.Transactions()[0]
+		origin, _ := trx.Origin()
+		allEvents = append(allEvents, &logdb.Event{
+			BlockNumber: b.Header().Number(),
+			BlockID:     b.Header().ID(),
+			BlockTime:   b.Header().Timestamp(),
+			TxID:        trx.ID(),
+			TxOrigin:    origin,
+			Address:     receipt.Outputs[0].Events[0].Address,
+			Topics:      [5]*luckyshare.Bytes32{&receipt.Outputs[0].Events[0].Topics[0]},
+			Data:        receipt.Outputs[0].Events[0].Data,
+		})
+		if err := db.Log(func(w *logdb.Writer) error {
+			return w.Write(b, tx.Receipts{receipt})
+		}); err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, b)
+		receipts = append(receipts, receipt)
+	}
+
+	pruned, err := db.PrunedBefore()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0), pruned)
+
+	assert.Nil(t, db.Prune(context.Background(), 7))
+	assert.Nil(t, db.Vacuum(context.Background()))
+
+	events, err := db.FilterEvents(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, allEvents[5:], eventLogs(events))
+
+	transfers, err := db.FilterTransfers(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Len(t, transfers, 5)
+
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// each block refers to block id, tx id, tx origin, event address, topic, transfer sender and recipient
+	var refs int
+	assert.Nil(t, conn.QueryRow("SELECT COUNT(*) FROM ref").Scan(&refs))
+	assert.Equal(t, 5*7, refs)
+
+	// the pruned height is kept and never lowered
+	assert.Nil(t, db.Prune(context.Background(), 3))
+	pruned, err = db.PrunedBefore()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(7), pruned)
+
+	// rewriting from before the pruned height, e.g. on reorg, skips logs of pruned blocks
+	if err := db.Log(func(w *logdb.Writer) error {
+		for i := 2; i < len(blocks); i++ {
+			if err := w.Write(blocks[i], tx.Receipts{receipts[i]}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	events, err = db.FilterEvents(context.Background(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, allEvents[5:], eventLogs(events))
+}
+
+func newSignedTx(pk *ecdsa.PrivateKey, nonce uint64, clauses ...*tx.Clause) *tx.Transaction {
+	builder := new(tx.Builder).Nonce(nonce)
+	for _, c := range clauses {
+		builder.Clause(c)
+	}
+	trx := builder.Build()
+	sig, _ := crypto.Sign(trx.SigningHash().Bytes(), pk)
+	return trx.WithSignature(sig)
+}
+
+func TestActivities(t *testing.T) {
+	db, err := logdb.NewMem()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	testActivities(t, db)
+}
+
+func testActivities(t *testing.T, db *logdb.LogDB) {
+	var (
+		pk, _     = crypto.GenerateKey()
+		sender    = luckyshare.Address(crypto.PubkeyToAddress(pk.PublicKey))
+		recipient = randAddress()
+		sponsor   = randAddress()
+		b         = new(block.Builder).Build()
+		all       []*logdb.Activity
+	)
+
+	for i := 0; i < 10; i++ {
+		var (
+			other, _ = crypto.GenerateKey()
+			tx1      = newSignedTx(pk, uint64(i), tx.NewClause(&recipient), tx.NewClause(&sender), tx.NewClause(nil))
+			tx2      = newSignedTx(other, 0, tx.NewClause(&recipient))
+			origin2  = luckyshare.Address(crypto.PubkeyToAddress(other.PublicKey))
+		)
+		b = new(block.Builder).
+			ParentID(b.Header().ID()).
+			Transaction(tx1).
+			Transaction(tx2).
+			Build()
+		receipts := tx.Receipts{{GasPayer: sender}, {GasPayer: sponsor}}
+		if err := db.Log(func(w *logdb.Writer) error {
+			return w.Write(b, receipts)
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		for j, a := range []struct {
+			txID  luckyshare.Bytes32
+			addr  luckyshare.Address
+			flags uint32
+		}{
+			{tx1.ID(), sender, logdb.ActivityOrigin | logdb.ActivityRecipient},
+			{tx1.ID(), recipient, logdb.ActivityRecipient},
+			{tx2.ID(), origin2, logdb.ActivityOrigin},
+			{tx2.ID(), sponsor, logdb.ActivityGasPayer},
+			{tx2.ID(), recipient, logdb.ActivityRecipient},
+		} {
+			all = append(all, &logdb.Activity{
+				BlockNumber: b.Header().Number(),
+				Index:       uint32(j),
+				BlockID:     b.Header().ID(),
+				BlockTime:   b.Header().Timestamp(),
+				TxID:        a.txID,
+				Address:     a.addr,
+				Flags:       a.flags,
+			})
+		}
+	}
+
+	filter := func(addr luckyshare.Address, flags uint32) (ret []*logdb.Activity) {
+		for _, a := range all {
+			if a.Address == addr && (flags == 0 || a.Flags&flags != 0) {
+				ret = append(ret, a)
+			}
+		}
+		return
+	}
+	reverse := func(activities []*logdb.Activity) (ret []*logdb.Activity) {
+		for i := len(activities) - 1; i >= 0; i-- {
+			ret = append(ret, activities[i])
+		}
+		return
+	}
+
+	tests := []struct {
+		name string
+		arg  *logdb.ActivityFilter
+		want []*logdb.Activity
+	}{
+		{"query all of address", &logdb.ActivityFilter{Address: sender}, filter(sender, 0)},
+		{"query all of address desc", &logdb.ActivityFilter{Address: recipient, Order: logdb.DESC}, reverse(filter(recipient, 0))},
+		{"query sent", &logdb.ActivityFilter{Address: recipient, Flags: logdb.ActivityOrigin}, nil},
+		{"query received", &logdb.ActivityFilter{Address: sender, Flags: logdb.ActivityRecipient}, filter(sender, 0)},
+		{"query gas paid", &logdb.ActivityFilter{Address: sponsor, Flags: logdb.ActivityGasPayer | logdb.ActivityOrigin}, filter(sponsor, 0)},
+		{"query range", &logdb.ActivityFilter{Address: recipient, Range: &logdb.Range{From: 3, To: 4}}, filter(recipient, 0)[2:6]},
+		{"query cursor", &logdb.ActivityFilter{Address: recipient, Options: &logdb.Options{Limit: 3, Cursor: filter(recipient, 0)[4].Cursor()}}, filter(recipient, 0)[5:8]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := db.FilterActivities(context.Background(), tt.arg)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	c := logdb.Cursor{BlockNumber: 12345, Index: 6}
+	text, err := c.MarshalText()
+	assert.Nil(t, err)
+
+	var got logdb.Cursor
+	assert.Nil(t, got.UnmarshalText(text))
+	assert.Equal(t, c, got)
+
+	assert.NotNil(t, got.UnmarshalText([]byte("0xffffffffffffffff")))
 }
@@ -5,6 +5,13 @@
 
 package logdb
 
+import (
+	"strings"
+
+	// register sqlite driver
+	_ "github.com/mattn/go-sqlite3"
+)
+
 // create a table for events
 const (
 	configTableSchema = `CREATE TABLE IF NOT EXISTS config (
@@ -55,4 +62,77 @@ CREATE INDEX IF NOT EXISTS event_i4 ON event(topic3, topic0, address) WHERE topi
 CREATE INDEX IF NOT EXISTS transfer_i0 ON transfer(txOrigin);
 CREATE INDEX IF NOT EXISTS transfer_i1 ON transfer(sender);
 CREATE INDEX IF NOT EXISTS transfer_i2 ON transfer(recipient);`
+
+	// create a table for traced calls
+	callTableSchema = `CREATE TABLE IF NOT EXISTS call (
+	seq INTEGER PRIMARY KEY NOT NULL,
+	blockID	INTEGER NOT NULL,
+	blockTime INTEGER NOT NULL,
+	txID INTEGER NOT NULL,
+	txOrigin INTEGER NOT NULL,
+	clauseIndex INTEGER NOT NULL,
+	depth INTEGER NOT NULL,
+	type TEXT NOT NULL,
+	caller INTEGER NOT NULL,
+	callee INTEGER NOT NULL,
+	selector INTEGER,
+	value BLOB(32),
+	reverted INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS call_i0 ON call(caller);
+CREATE INDEX IF NOT EXISTS call_i1 ON call(callee);
+CREATE INDEX IF NOT EXISTS call_i2 ON call(selector, callee) WHERE selector IS NOT NULL;`
+
+	// create tables for token transfers and balances accumulated from them
+	tokenTableSchema = `CREATE TABLE IF NOT EXISTS token_transfer (
+	seq INTEGER PRIMARY KEY NOT NULL,
+	blockID	INTEGER NOT NULL,
+	blockTime INTEGER NOT NULL,
+	txID INTEGER NOT NULL,
+	txOrigin INTEGER NOT NULL,
+	clauseIndex INTEGER NOT NULL,
+	token INTEGER NOT NULL,
+	sender INTEGER NOT NULL,
+	recipient INTEGER NOT NULL,
+	amount BLOB(32)
+);
+
+CREATE INDEX IF NOT EXISTS token_transfer_i0 ON token_transfer(token);
+CREATE INDEX IF NOT EXISTS token_transfer_i1 ON token_transfer(sender);
+CREATE INDEX IF NOT EXISTS token_transfer_i2 ON token_transfer(recipient);
+
+CREATE TABLE IF NOT EXISTS token_balance (
+	token INTEGER NOT NULL,
+	holder INTEGER NOT NULL,
+	balance BLOB NOT NULL,
+	PRIMARY KEY (token, holder)
+);
+
+CREATE INDEX IF NOT EXISTS token_balance_i0 ON token_balance(holder);
+CREATE INDEX IF NOT EXISTS token_balance_i1 ON token_balance(token, balance);`
+
+	// create a table for transactions related to addresses
+	activityTableSchema = `CREATE TABLE IF NOT EXISTS activity (
+	seq INTEGER PRIMARY KEY NOT NULL,
+	blockID	INTEGER NOT NULL,
+	blockTime INTEGER NOT NULL,
+	txID INTEGER NOT NULL,
+	address INTEGER NOT NULL,
+	flags INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS activity_i0 ON activity(address, seq);`
 )
+
+var sqliteDialect = &dialect{
+	driver: "sqlite3",
+	schema: configTableSchema + refTableScheme + eventTableSchema + transferTableSchema + callTableSchema + tokenTableSchema + activityTableSchema,
+	rebind: func(query string) string { return query },
+	insertOrIgnore: func(query string) string {
+		return strings.Replace(query, "INSERT", "INSERT OR IGNORE", 1)
+	},
+	insertOrReplace: func(query string, _ string, _ []string) string {
+		return strings.Replace(query, "INSERT", "INSERT OR REPLACE", 1)
+	},
+}
@@ -0,0 +1,1199 @@
+// Copyright (c) 2018 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/pkg/errors"
+)
+
+// the key to last written block id.
+const (
+	configBlockIDKey = "blockID"
+	refIDQuery       = "(SELECT id FROM ref WHERE data=?)"
+)
+
+// the key to the block number logs are pruned before.
+const configPrunedKey = "pruned"
+
+// sqlBackend stores logs in sql database. Statements are written in sqlite flavor,
+// and adapted to other databases by dialect.
+type sqlBackend struct {
+	path      string
+	db        *sql.DB
+	dialect   *dialect
+	stmtCache *stmtCache
+}
+
+func newSQLBackend(d *dialect, source, path string) (backend *sqlBackend, err error) {
+	db, err := sql.Open(d.driver, source)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = db.Close()
+		}
+	}()
+
+	if _, err := db.Exec(d.schema); err != nil {
+		return nil, err
+	}
+
+	return &sqlBackend{
+		path:      path,
+		db:        db,
+		dialect:   d,
+		stmtCache: newStmtCache(db),
+	}, nil
+}
+
+// Close close the log db.
+func (db *sqlBackend) Close() error {
+	db.stmtCache.Clear()
+	return db.db.Close()
+}
+
+func (db *sqlBackend) Path() string {
+	return db.path
+}
+
+func (db *sqlBackend) prepare(query string) *sql.Stmt {
+	return db.stmtCache.MustPrepare(db.dialect.rebind(query))
+}
+
+func (db *sqlBackend) FilterEvents(ctx context.Context, filter *EventFilter) ([]*Event, error) {
+
+	const query = `SELECT e.seq, r0.data, e.blockTime, r1.data, r2.data, e.clauseIndex, r3.data, r4.data, r5.data, r6.data, r7.data, r8.data, e.data
+FROM (%v) e
+	LEFT JOIN ref r0 ON e.blockID = r0.id
+	LEFT JOIN ref r1 ON e.txID = r1.id
+	LEFT JOIN ref r2 ON e.txOrigin = r2.id
+	LEFT JOIN ref r3 ON e.address = r3.id
+	LEFT JOIN ref r4 ON e.topic0 = r4.id
+	LEFT JOIN ref r5 ON e.topic1 = r5.id
+	LEFT JOIN ref r6 ON e.topic2 = r6.id
+	LEFT JOIN ref r7 ON e.topic3 = r7.id
+	LEFT JOIN ref r8 ON e.topic4 = r8.id`
+
+	if filter == nil {
+		filter = &EventFilter{}
+	}
+
+	cond, args := filter.toWhereCondition()
+	clause, cargs := filter.Options.toClause(filter.Order)
+	subQuery := "SELECT seq FROM event WHERE TRUE" + cond + clause
+	args = append(args, cargs...)
+
+	subQuery = "SELECT e.* FROM (" + subQuery + ") s LEFT JOIN event e ON s.seq = e.seq"
+
+	// join doesn't keep the order in all databases
+	return db.queryEvents(ctx, fmt.Sprintf(query, subQuery)+orderBy("e", filter.Order), args...)
+}
+
+func (db *sqlBackend) FilterTransfers(ctx context.Context, filter *TransferFilter) ([]*Transfer, error) {
+
+	const query = `SELECT t.seq, r0.data, t.blockTime, r1.data, r2.data, t.clauseIndex, r3.data, r4.data, t.amount
+FROM (%v) t 
+	LEFT JOIN ref r0 ON t.blockID = r0.id
+	LEFT JOIN ref r1 ON t.txID = r1.id
+	LEFT JOIN ref r2 ON t.txOrigin = r2.id
+	LEFT JOIN ref r3 ON t.sender = r3.id
+	LEFT JOIN ref r4 ON t.recipient = r4.id`
+
+	if filter == nil {
+		filter = &TransferFilter{}
+	}
+
+	cond, args := filter.toWhereCondition()
+	clause, cargs := filter.Options.toClause(filter.Order)
+	subQuery := "SELECT seq FROM transfer WHERE TRUE" + cond + clause
+	args = append(args, cargs...)
+
+	subQuery = "SELECT e.* FROM (" + subQuery + ") s LEFT JOIN transfer e ON s.seq = e.seq"
+	return db.queryTransfers(ctx, fmt.Sprintf(query, subQuery)+orderBy("t", filter.Order), args...)
+}
+
+func (db *sqlBackend) FilterCalls(ctx context.Context, filter *CallFilter) ([]*Call, error) {
+
+	const query = `SELECT c.seq, r0.data, c.blockTime, r1.data, r2.data, c.clauseIndex, c.depth, c.type, r3.data, r4.data, r5.data, c.value, c.reverted
+FROM (%v) c
+	LEFT JOIN ref r0 ON c.blockID = r0.id
+	LEFT JOIN ref r1 ON c.txID = r1.id
+	LEFT JOIN ref r2 ON c.txOrigin = r2.id
+	LEFT JOIN ref r3 ON c.caller = r3.id
+	LEFT JOIN ref r4 ON c.callee = r4.id
+	LEFT JOIN ref r5 ON c.selector = r5.id`
+
+	if filter == nil {
+		filter = &CallFilter{}
+	}
+
+	cond, args := filter.toWhereCondition()
+	clause, cargs := filter.Options.toClause(filter.Order)
+	subQuery := "SELECT seq FROM call WHERE TRUE" + cond + clause
+	args = append(args, cargs...)
+
+	subQuery = "SELECT e.* FROM (" + subQuery + ") s LEFT JOIN call e ON s.seq = e.seq"
+	return db.queryCalls(ctx, fmt.Sprintf(query, subQuery)+orderBy("c", filter.Order), args...)
+}
+
+func (db *sqlBackend) FilterTokenTransfers(ctx context.Context, filter *TokenTransferFilter) ([]*TokenTransfer, error) {
+
+	const query = `SELECT t.seq, r0.data, t.blockTime, r1.data, r2.data, t.clauseIndex, r3.data, r4.data, r5.data, t.amount
+FROM (%v) t
+	LEFT JOIN ref r0 ON t.blockID = r0.id
+	LEFT JOIN ref r1 ON t.txID = r1.id
+	LEFT JOIN ref r2 ON t.txOrigin = r2.id
+	LEFT JOIN ref r3 ON t.token = r3.id
+	LEFT JOIN ref r4 ON t.sender = r4.id
+	LEFT JOIN ref r5 ON t.recipient = r5.id`
+
+	if filter == nil {
+		filter = &TokenTransferFilter{}
+	}
+
+	cond, args := filter.toWhereCondition()
+	clause, cargs := filter.Options.toClause(filter.Order)
+	subQuery := "SELECT seq FROM token_transfer WHERE TRUE" + cond + clause
+	args = append(args, cargs...)
+
+	subQuery = "SELECT e.* FROM (" + subQuery + ") s LEFT JOIN token_transfer e ON s.seq = e.seq"
+	return db.queryTokenTransfers(ctx, fmt.Sprintf(query, subQuery)+orderBy("t", filter.Order), args...)
+}
+
+func (db *sqlBackend) FilterActivities(ctx context.Context, filter *ActivityFilter) ([]*Activity, error) {
+
+	const query = `SELECT a.seq, r0.data, a.blockTime, r1.data, r2.data, a.flags
+FROM (%v) a
+	LEFT JOIN ref r0 ON a.blockID = r0.id
+	LEFT JOIN ref r1 ON a.txID = r1.id
+	LEFT JOIN ref r2 ON a.address = r2.id`
+
+	cond, args := filter.toWhereCondition()
+	clause, cargs := filter.Options.toClause(filter.Order)
+	subQuery := "SELECT seq FROM activity WHERE TRUE" + cond + clause
+	args = append(args, cargs...)
+
+	subQuery = "SELECT e.* FROM (" + subQuery + ") s LEFT JOIN activity e ON s.seq = e.seq"
+	return db.queryActivities(ctx, fmt.Sprintf(query, subQuery)+orderBy("a", filter.Order), args...)
+}
+
+// TokenHolders returns balances of the token, in descending order of balance.
+func (db *sqlBackend) TokenHolders(ctx context.Context, token luckyshare.Address, offset, limit uint64) ([]*TokenBalance, error) {
+	const query = `SELECT r0.data, r1.data, b.balance
+FROM (SELECT * FROM token_balance WHERE token=` + refIDQuery + ` ORDER BY balance DESC, holder LIMIT ? OFFSET ?) b
+	LEFT JOIN ref r0 ON b.token = r0.id
+	LEFT JOIN ref r1 ON b.holder = r1.id
+ORDER BY b.balance DESC, b.holder`
+
+	return db.queryBalances(ctx, query, token.Bytes(), limit, offset)
+}
+
+// HolderTokens returns balances of tokens held by the holder.
+func (db *sqlBackend) HolderTokens(ctx context.Context, holder luckyshare.Address, offset, limit uint64) ([]*TokenBalance, error) {
+	const query = `SELECT r0.data, r1.data, b.balance
+FROM (SELECT * FROM token_balance WHERE holder=` + refIDQuery + ` ORDER BY token LIMIT ? OFFSET ?) b
+	LEFT JOIN ref r0 ON b.token = r0.id
+	LEFT JOIN ref r1 ON b.holder = r1.id
+ORDER BY b.token`
+
+	return db.queryBalances(ctx, query, holder.Bytes(), limit, offset)
+}
+
+// CountEvents counts events matching the filter, regardless of options and order.
+func (db *sqlBackend) CountEvents(ctx context.Context, filter *EventFilter) (uint64, error) {
+	var (
+		cond string
+		args []interface{}
+	)
+	if filter != nil {
+		cond, args = filter.toWhereCondition()
+	}
+	return db.count(ctx, "SELECT COUNT(*) FROM event WHERE TRUE"+cond, args...)
+}
+
+// CountTransfers counts transfers matching the filter, regardless of options and order.
+func (db *sqlBackend) CountTransfers(ctx context.Context, filter *TransferFilter) (uint64, error) {
+	var (
+		cond string
+		args []interface{}
+	)
+	if filter != nil {
+		cond, args = filter.toWhereCondition()
+	}
+	return db.count(ctx, "SELECT COUNT(*) FROM transfer WHERE TRUE"+cond, args...)
+}
+
+// CountCalls counts calls matching the filter, regardless of options and order.
+func (db *sqlBackend) CountCalls(ctx context.Context, filter *CallFilter) (uint64, error) {
+	var (
+		cond string
+		args []interface{}
+	)
+	if filter != nil {
+		cond, args = filter.toWhereCondition()
+	}
+	return db.count(ctx, "SELECT COUNT(*) FROM call WHERE TRUE"+cond, args...)
+}
+
+func (db *sqlBackend) count(ctx context.Context, query string, args ...interface{}) (uint64, error) {
+	var count uint64
+	if err := db.db.QueryRowContext(ctx, db.dialect.rebind(query), args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (db *sqlBackend) queryEvents(ctx context.Context, query string, args ...interface{}) ([]*Event, error) {
+	rows, err := db.db.QueryContext(ctx, db.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var events []*Event
+	for rows.Next() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		var (
+			seq         sequence
+			blockID     []byte
+			blockTime   uint64
+			txID        []byte
+			txOrigin    []byte
+			clauseIndex uint32
+			address     []byte
+			topics      [5][]byte
+			data        []byte
+		)
+		if err := rows.Scan(
+			&seq,
+			&blockID,
+			&blockTime,
+			&txID,
+			&txOrigin,
+			&clauseIndex,
+			&address,
+			&topics[0],
+			&topics[1],
+			&topics[2],
+			&topics[3],
+			&topics[4],
+			&data,
+		); err != nil {
+			return nil, err
+		}
+		event := &Event{
+			BlockNumber: seq.BlockNumber(),
+			Index:       seq.Index(),
+			BlockID:     luckyshare.BytesToBytes32(blockID),
+			BlockTime:   blockTime,
+			TxID:        luckyshare.BytesToBytes32(txID),
+			TxOrigin:    luckyshare.BytesToAddress(txOrigin),
+			ClauseIndex: clauseIndex,
+			Address:     luckyshare.BytesToAddress(address),
+			Data:        data,
+		}
+		for i, topic := range topics {
+			if len(topic) > 0 {
+				h := luckyshare.BytesToBytes32(topic)
+				event.Topics[i] = &h
+			}
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (db *sqlBackend) queryTransfers(ctx context.Context, query string, args ...interface{}) ([]*Transfer, error) {
+	rows, err := db.db.QueryContext(ctx, db.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var transfers []*Transfer
+	for rows.Next() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		var (
+			seq         sequence
+			blockID     []byte
+			blockTime   uint64
+			txID        []byte
+			txOrigin    []byte
+			clauseIndex uint32
+			sender      []byte
+			recipient   []byte
+			amount      []byte
+		)
+		if err := rows.Scan(
+			&seq,
+			&blockID,
+			&blockTime,
+			&txID,
+			&txOrigin,
+			&clauseIndex,
+			&sender,
+			&recipient,
+			&amount,
+		); err != nil {
+			return nil, err
+		}
+		trans := &Transfer{
+			BlockNumber: seq.BlockNumber(),
+			Index:       seq.Index(),
+			BlockID:     luckyshare.BytesToBytes32(blockID),
+			BlockTime:   blockTime,
+			TxID:        luckyshare.BytesToBytes32(txID),
+			TxOrigin:    luckyshare.BytesToAddress(txOrigin),
+			ClauseIndex: clauseIndex,
+			Sender:      luckyshare.BytesToAddress(sender),
+			Recipient:   luckyshare.BytesToAddress(recipient),
+			Amount:      new(big.Int).SetBytes(amount),
+		}
+		transfers = append(transfers, trans)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+func (db *sqlBackend) queryCalls(ctx context.Context, query string, args ...interface{}) ([]*Call, error) {
+	rows, err := db.db.QueryContext(ctx, db.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var calls []*Call
+	for rows.Next() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		var (
+			seq         sequence
+			blockID     []byte
+			blockTime   uint64
+			txID        []byte
+			txOrigin    []byte
+			clauseIndex uint32
+			depth       uint32
+			typ         string
+			caller      []byte
+			callee      []byte
+			selector    []byte
+			value       []byte
+			reverted    bool
+		)
+		if err := rows.Scan(
+			&seq,
+			&blockID,
+			&blockTime,
+			&txID,
+			&txOrigin,
+			&clauseIndex,
+			&depth,
+			&typ,
+			&caller,
+			&callee,
+			&selector,
+			&value,
+			&reverted,
+		); err != nil {
+			return nil, err
+		}
+		call := &Call{
+			BlockNumber: seq.BlockNumber(),
+			Index:       seq.Index(),
+			BlockID:     luckyshare.BytesToBytes32(blockID),
+			BlockTime:   blockTime,
+			TxID:        luckyshare.BytesToBytes32(txID),
+			TxOrigin:    luckyshare.BytesToAddress(txOrigin),
+			ClauseIndex: clauseIndex,
+			Depth:       depth,
+			Type:        typ,
+			Caller:      luckyshare.BytesToAddress(caller),
+			Callee:      luckyshare.BytesToAddress(callee),
+			Value:       new(big.Int).SetBytes(value),
+			Reverted:    reverted,
+		}
+		if len(selector) > 0 {
+			call.Selector = selector
+		}
+		calls = append(calls, call)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return calls, nil
+}
+
+func (db *sqlBackend) queryTokenTransfers(ctx context.Context, query string, args ...interface{}) ([]*TokenTransfer, error) {
+	rows, err := db.db.QueryContext(ctx, db.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var transfers []*TokenTransfer
+	for rows.Next() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		var (
+			seq         sequence
+			blockID     []byte
+			blockTime   uint64
+			txID        []byte
+			txOrigin    []byte
+			clauseIndex uint32
+			token       []byte
+			sender      []byte
+			recipient   []byte
+			amount      []byte
+		)
+		if err := rows.Scan(
+			&seq,
+			&blockID,
+			&blockTime,
+			&txID,
+			&txOrigin,
+			&clauseIndex,
+			&token,
+			&sender,
+			&recipient,
+			&amount,
+		); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, &TokenTransfer{
+			BlockNumber: seq.BlockNumber(),
+			Index:       seq.Index(),
+			BlockID:     luckyshare.BytesToBytes32(blockID),
+			BlockTime:   blockTime,
+			TxID:        luckyshare.BytesToBytes32(txID),
+			TxOrigin:    luckyshare.BytesToAddress(txOrigin),
+			ClauseIndex: clauseIndex,
+			Token:       luckyshare.BytesToAddress(token),
+			Sender:      luckyshare.BytesToAddress(sender),
+			Recipient:   luckyshare.BytesToAddress(recipient),
+			Amount:      new(big.Int).SetBytes(amount),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+func (db *sqlBackend) queryActivities(ctx context.Context, query string, args ...interface{}) ([]*Activity, error) {
+	rows, err := db.db.QueryContext(ctx, db.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var activities []*Activity
+	for rows.Next() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		var (
+			seq       sequence
+			blockID   []byte
+			blockTime uint64
+			txID      []byte
+			address   []byte
+			flags     uint32
+		)
+		if err := rows.Scan(
+			&seq,
+			&blockID,
+			&blockTime,
+			&txID,
+			&address,
+			&flags,
+		); err != nil {
+			return nil, err
+		}
+		activities = append(activities, &Activity{
+			BlockNumber: seq.BlockNumber(),
+			Index:       seq.Index(),
+			BlockID:     luckyshare.BytesToBytes32(blockID),
+			BlockTime:   blockTime,
+			TxID:        luckyshare.BytesToBytes32(txID),
+			Address:     luckyshare.BytesToAddress(address),
+			Flags:       flags,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+func (db *sqlBackend) queryBalances(ctx context.Context, query string, args ...interface{}) ([]*TokenBalance, error) {
+	rows, err := db.db.QueryContext(ctx, db.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var balances []*TokenBalance
+	for rows.Next() {
+		var token, holder, balance []byte
+		if err := rows.Scan(&token, &holder, &balance); err != nil {
+			return nil, err
+		}
+		balances = append(balances, &TokenBalance{
+			Token:   luckyshare.BytesToAddress(token),
+			Holder:  luckyshare.BytesToAddress(holder),
+			Balance: decodeBalance(balance),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// NewestBlockID query newest written block id.
+func (db *sqlBackend) NewestBlockID() (luckyshare.Bytes32, error) {
+	// select from config if any
+	row := db.prepare("SELECT value FROM config WHERE key=?").QueryRow(configBlockIDKey)
+	var data []byte
+	if err := row.Scan(&data); err != nil {
+		if sql.ErrNoRows != err {
+			return luckyshare.Bytes32{}, err
+		}
+
+		// no config, query newest block ID from existing records
+		for _, table := range []string{"transfer", "event"} {
+			var id []byte
+			row := db.prepare("SELECT data FROM ref WHERE id=(SELECT blockID FROM " + table + " ORDER BY seq DESC LIMIT 1)").QueryRow()
+			if err := row.Scan(&id); err != nil {
+				if sql.ErrNoRows != err {
+					return luckyshare.Bytes32{}, err
+				}
+			}
+			if bytes.Compare(id, data) > 0 {
+				data = id
+			}
+		}
+	}
+	return luckyshare.BytesToBytes32(data), nil
+}
+
+// HasBlockID query whether given block id related logs were written.
+func (db *sqlBackend) HasBlockID(id luckyshare.Bytes32) (bool, error) {
+	const query = `SELECT
+		EXISTS(SELECT seq FROM transfer WHERE seq=? AND blockID=` + refIDQuery + `)
+		OR
+		EXISTS(SELECT seq FROM event WHERE seq=? AND blockID=` + refIDQuery + `)`
+
+	seq := newSequence(block.Number(id), 0)
+	row := db.prepare(query).QueryRow(seq, id.Bytes(), seq, id.Bytes())
+	var has bool
+	if err := row.Scan(&has); err != nil {
+		// no need to check ErrNoRows
+		return false, err
+	}
+	return has, nil
+}
+
+// decodePruned decodes the pruned height from the config row, 0 if not set.
+func decodePruned(row *sql.Row) (uint32, error) {
+	var data []byte
+	if err := row.Scan(&data); err != nil {
+		if sql.ErrNoRows != err {
+			return 0, err
+		}
+		return 0, nil
+	}
+	if len(data) != 4 {
+		return 0, errors.New("invalid pruned height")
+	}
+	return binary.BigEndian.Uint32(data), nil
+}
+
+// PrunedBefore returns the block number logs are pruned before, 0 if never pruned.
+func (db *sqlBackend) PrunedBefore() (uint32, error) {
+	return decodePruned(db.prepare("SELECT value FROM config WHERE key=?").QueryRow(configPrunedKey))
+}
+
+// Prune deletes logs of blocks before the given block number, along with refs no longer used.
+// The pruned height is kept, and logs before it are not written any more.
+func (db *sqlBackend) Prune(ctx context.Context, before uint32) (err error) {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	seq := newSequence(before, 0)
+	for _, table := range []string{"event", "transfer", "call", "token_transfer", "activity"} {
+		if _, err := tx.ExecContext(ctx, db.dialect.rebind("DELETE FROM "+table+" WHERE seq < ?"), seq); err != nil {
+			return err
+		}
+	}
+
+	// NOT IN never matches if any of the values is null
+	var used []string
+	for _, rc := range refColumns {
+		for _, c := range rc.columns {
+			used = append(used, fmt.Sprintf("SELECT %v FROM %v WHERE %v IS NOT NULL", c, rc.table, c))
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM ref WHERE id NOT IN ("+strings.Join(used, " UNION ")+")"); err != nil {
+		return err
+	}
+
+	pruned, err := decodePruned(tx.QueryRowContext(ctx, db.dialect.rebind("SELECT value FROM config WHERE key=?"), configPrunedKey))
+	if err != nil {
+		return err
+	}
+	if before > pruned {
+		var data [4]byte
+		binary.BigEndian.PutUint32(data[:], before)
+		if _, err := tx.ExecContext(ctx,
+			db.dialect.rebind(db.dialect.insertOrReplace("INSERT INTO config(key, value) VALUES(?,?)", "key", []string{"value"})),
+			configPrunedKey, data[:]); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Vacuum reclaims space of deleted data.
+func (db *sqlBackend) Vacuum(ctx context.Context) error {
+	_, err := db.db.ExecContext(ctx, "VACUUM")
+	return err
+}
+
+// NewWriter creates a transactional log writer.
+func (db *sqlBackend) NewWriter() BackendWriter {
+	return &sqlWriter{db: db}
+}
+
+func topicValue(topics []luckyshare.Bytes32, i int) []byte {
+	if i < len(topics) {
+		return topics[i].Bytes()
+	}
+	return nil
+}
+
+type sqlWriter struct {
+	db          *sqlBackend
+	tx          *sql.Tx
+	len         int
+	lastBlockID luckyshare.Bytes32
+	pruned      *uint32 // loaded on demand
+}
+
+// isPruned returns whether logs of the block are pruned, which are not written any more,
+// even if the block is written again on reorg or rebuild.
+func (w *sqlWriter) isPruned(num uint32) (bool, error) {
+	if w.pruned == nil {
+		if err := w.begin(); err != nil {
+			return false, err
+		}
+		pruned, err := decodePruned(w.tx.Stmt(w.db.prepare("SELECT value FROM config WHERE key=?")).QueryRow(configPrunedKey))
+		if err != nil {
+			return false, err
+		}
+		w.pruned = &pruned
+	}
+	return num < *w.pruned, nil
+}
+
+// Write writes all logs of the given block.
+func (w *sqlWriter) Write(b *block.Block, receipts tx.Receipts) error {
+
+	var (
+		num                       = b.Header().Number()
+		id                        = b.Header().ID()
+		ts                        = b.Header().Timestamp()
+		txs                       = b.Transactions()
+		eventCount, transferCount uint32
+	)
+	w.lastBlockID = id
+
+	if num > 0 && w.len == 0 {
+		seq := newSequence(num, 0)
+		if err := w.exec("DELETE FROM event WHERE seq >= ?", seq); err != nil {
+			return err
+		}
+		if err := w.exec("DELETE FROM transfer WHERE seq >= ?", seq); err != nil {
+			return err
+		}
+		if err := w.exec("DELETE FROM call WHERE seq >= ?", seq); err != nil {
+			return err
+		}
+		if err := w.exec("DELETE FROM activity WHERE seq >= ?", seq); err != nil {
+			return err
+		}
+		if err := w.revertTokenTransfers("seq >= ?", seq); err != nil {
+			return err
+		}
+	}
+
+	if pruned, err := w.isPruned(num); err != nil {
+		return err
+	} else if pruned {
+		return nil
+	}
+
+	if len(receipts) > 0 {
+		if err := w.insertRefs(id.Bytes()); err != nil {
+			return err
+		}
+
+		for txIndex, receipt := range receipts {
+			if len(receipt.Outputs) > 0 {
+				var (
+					txID     luckyshare.Bytes32
+					txOrigin luckyshare.Address
+				)
+				if num != 0 {
+					// receipts ahead of txs are of scheduled executions
+					txID, txOrigin = tx.ExecutionMeta(num, txs, receipts, txIndex)
+				}
+
+				if err := w.insertRefs(txID.Bytes(), txOrigin.Bytes()); err != nil {
+					return err
+				}
+
+				for clauseIndex, output := range receipt.Outputs {
+					for _, ev := range output.Events {
+						if err := w.insertRefs(
+							ev.Address.Bytes(),
+							topicValue(ev.Topics, 0),
+							topicValue(ev.Topics, 1),
+							topicValue(ev.Topics, 2),
+							topicValue(ev.Topics, 3),
+							topicValue(ev.Topics, 4)); err != nil {
+							return err
+						}
+
+						if err := w.exec(
+							w.db.dialect.insertOrReplace(fmt.Sprintf(
+								"INSERT INTO event VALUES(?,%v,?,%v,%v,?,%v,%v,%v,%v,%v,%v,?)",
+								refIDQuery, refIDQuery, refIDQuery, refIDQuery, refIDQuery, refIDQuery, refIDQuery, refIDQuery, refIDQuery),
+								"seq", eventColumns),
+							newSequence(num, eventCount),
+							id.Bytes(),
+							ts,
+							txID.Bytes(),
+							txOrigin.Bytes(),
+							clauseIndex,
+							ev.Address.Bytes(),
+							topicValue(ev.Topics, 0),
+							topicValue(ev.Topics, 1),
+							topicValue(ev.Topics, 2),
+							topicValue(ev.Topics, 3),
+							topicValue(ev.Topics, 4),
+							ev.Data); err != nil {
+							return err
+						}
+
+						eventCount++
+					}
+
+					for _, tr := range output.Transfers {
+						if err := w.insertRefs(
+							tr.Sender.Bytes(),
+							tr.Recipient.Bytes()); err != nil {
+							return err
+						}
+						if err := w.exec(
+							w.db.dialect.insertOrReplace(fmt.Sprintf(
+								"INSERT INTO transfer VALUES(?,%v,?,%v,%v,?,%v,%v,?)",
+								refIDQuery, refIDQuery, refIDQuery, refIDQuery, refIDQuery),
+								"seq", transferColumns),
+							newSequence(num, transferCount),
+							id.Bytes(),
+							ts,
+							txID.Bytes(),
+							txOrigin.Bytes(),
+							clauseIndex,
+							tr.Sender.Bytes(),
+							tr.Recipient.Bytes(),
+							tr.Amount.Bytes()); err != nil {
+							return err
+						}
+
+						transferCount++
+					}
+				}
+			}
+		}
+	}
+	return w.writeActivities(b, receipts)
+}
+
+// writeActivities writes addresses related to each tx of the given block.
+func (w *sqlWriter) writeActivities(b *block.Block, receipts tx.Receipts) error {
+	var (
+		num   = b.Header().Number()
+		id    = b.Header().ID()
+		ts    = b.Header().Timestamp()
+		txs   = b.Transactions()
+		count uint32
+	)
+	if len(txs) == 0 {
+		return nil
+	}
+	if err := w.insertRefs(id.Bytes()); err != nil {
+		return err
+	}
+
+	// receipts of scheduled executions come first
+	scheduled := len(receipts) - len(txs)
+	for i, t := range txs {
+		var receipt *tx.Receipt
+		if scheduled >= 0 {
+			receipt = receipts[scheduled+i]
+		}
+		txID := t.ID()
+		if err := w.insertRefs(txID.Bytes()); err != nil {
+			return err
+		}
+		for _, role := range txActivities(t, receipt) {
+			if err := w.insertRefs(role.addr.Bytes()); err != nil {
+				return err
+			}
+			if err := w.exec(
+				w.db.dialect.insertOrReplace(fmt.Sprintf(
+					"INSERT INTO activity VALUES(?,%v,?,%v,%v,?)",
+					refIDQuery, refIDQuery, refIDQuery),
+					"seq", activityColumns),
+				newSequence(num, count),
+				id.Bytes(),
+				ts,
+				txID.Bytes(),
+				role.addr.Bytes(),
+				role.flags); err != nil {
+				return err
+			}
+			count++
+		}
+	}
+	return nil
+}
+
+// WriteCalls writes traced calls of the given block.
+func (w *sqlWriter) WriteCalls(b *block.Block, calls []*Call) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	if pruned, err := w.isPruned(b.Header().Number()); err != nil || pruned {
+		return err
+	}
+
+	var (
+		num = b.Header().Number()
+		id  = b.Header().ID()
+		ts  = b.Header().Timestamp()
+	)
+	if err := w.insertRefs(id.Bytes()); err != nil {
+		return err
+	}
+
+	for i, call := range calls {
+		if err := w.insertRefs(
+			call.TxID.Bytes(),
+			call.TxOrigin.Bytes(),
+			call.Caller.Bytes(),
+			call.Callee.Bytes(),
+			call.Selector); err != nil {
+			return err
+		}
+
+		var value []byte
+		if call.Value != nil {
+			value = call.Value.Bytes()
+		}
+		if err := w.exec(
+			w.db.dialect.insertOrReplace(fmt.Sprintf(
+				"INSERT INTO call VALUES(?,%v,?,%v,%v,?,?,?,%v,%v,%v,?,?)",
+				refIDQuery, refIDQuery, refIDQuery, refIDQuery, refIDQuery, refIDQuery),
+				"seq", callColumns),
+			newSequence(num, uint32(i)),
+			id.Bytes(),
+			ts,
+			call.TxID.Bytes(),
+			call.TxOrigin.Bytes(),
+			call.ClauseIndex,
+			call.Depth,
+			call.Type,
+			call.Caller.Bytes(),
+			call.Callee.Bytes(),
+			call.Selector,
+			value,
+			call.Reverted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTokenTransfers writes token transfers of the given block, and accumulates them into balances.
+func (w *sqlWriter) WriteTokenTransfers(b *block.Block, transfers []*TokenTransfer) error {
+	var (
+		num = b.Header().Number()
+		id  = b.Header().ID()
+		ts  = b.Header().Timestamp()
+	)
+	// balances already accumulated are kept
+	if pruned, err := w.isPruned(num); err != nil || pruned {
+		return err
+	}
+	// the block may be written again, e.g. the genesis block is written at each startup
+	if err := w.revertTokenTransfers("seq >= ? AND seq <= ?", newSequence(num, 0), newSequence(num, math.MaxInt32)); err != nil {
+		return err
+	}
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	if err := w.insertRefs(id.Bytes()); err != nil {
+		return err
+	}
+	for _, t := range transfers {
+		if err := w.insertRefs(
+			t.TxID.Bytes(),
+			t.TxOrigin.Bytes(),
+			t.Token.Bytes(),
+			t.Sender.Bytes(),
+			t.Recipient.Bytes()); err != nil {
+			return err
+		}
+		if err := w.exec(
+			w.db.dialect.insertOrReplace(fmt.Sprintf(
+				"INSERT INTO token_transfer VALUES(?,%v,?,%v,%v,?,%v,%v,%v,?)",
+				refIDQuery, refIDQuery, refIDQuery, refIDQuery, refIDQuery, refIDQuery),
+				"seq", tokenColumns),
+			newSequence(num, t.Index),
+			id.Bytes(),
+			ts,
+			t.TxID.Bytes(),
+			t.TxOrigin.Bytes(),
+			t.ClauseIndex,
+			t.Token.Bytes(),
+			t.Sender.Bytes(),
+			t.Recipient.Bytes(),
+			t.Amount.Bytes()); err != nil {
+			return err
+		}
+		if err := w.addBalance(t.Token, t.Sender, new(big.Int).Neg(t.Amount)); err != nil {
+			return err
+		}
+		if err := w.addBalance(t.Token, t.Recipient, t.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revertTokenTransfers deletes token transfers matching the condition, and takes them back from balances.
+func (w *sqlWriter) revertTokenTransfers(cond string, args ...interface{}) error {
+	rows, err := w.query(`SELECT r0.data, r1.data, r2.data, t.amount
+FROM token_transfer t
+	LEFT JOIN ref r0 ON t.token = r0.id
+	LEFT JOIN ref r1 ON t.sender = r1.id
+	LEFT JOIN ref r2 ON t.recipient = r2.id
+WHERE `+cond, args...)
+	if err != nil {
+		return err
+	}
+	var transfers []*TokenTransfer
+	for rows.Next() {
+		var token, sender, recipient, amount []byte
+		if err := rows.Scan(&token, &sender, &recipient, &amount); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		transfers = append(transfers, &TokenTransfer{
+			Token:     luckyshare.BytesToAddress(token),
+			Sender:    luckyshare.BytesToAddress(sender),
+			Recipient: luckyshare.BytesToAddress(recipient),
+			Amount:    new(big.Int).SetBytes(amount),
+		})
+	}
+	// rows should be closed before other statements in the transaction
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(transfers) == 0 {
+		return nil
+	}
+
+	for _, t := range transfers {
+		if err := w.addBalance(t.Token, t.Sender, t.Amount); err != nil {
+			return err
+		}
+		if err := w.addBalance(t.Token, t.Recipient, new(big.Int).Neg(t.Amount)); err != nil {
+			return err
+		}
+	}
+	return w.exec("DELETE FROM token_transfer WHERE "+cond, args...)
+}
+
+// addBalance adds delta to the balance of the holder. The zero address, which mints and burns
+// tokens, holds no balance. Zero balances are removed.
+func (w *sqlWriter) addBalance(token, holder luckyshare.Address, delta *big.Int) error {
+	if holder.IsZero() {
+		return nil
+	}
+	const cond = "token=" + refIDQuery + " AND holder=" + refIDQuery
+
+	rows, err := w.query("SELECT balance FROM token_balance WHERE "+cond, token.Bytes(), holder.Bytes())
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if rows.Next() {
+		if err := rows.Scan(&data); err != nil {
+			_ = rows.Close()
+			return err
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+
+	balance := new(big.Int).Add(decodeBalance(data), delta)
+	if balance.Sign() == 0 {
+		if data == nil {
+			return nil
+		}
+		return w.exec("DELETE FROM token_balance WHERE "+cond, token.Bytes(), holder.Bytes())
+	}
+	return w.exec(
+		w.db.dialect.insertOrReplace(fmt.Sprintf(
+			"INSERT INTO token_balance VALUES(%v,%v,?)", refIDQuery, refIDQuery),
+			"token, holder", []string{"balance"}),
+		token.Bytes(),
+		holder.Bytes(),
+		encodeBalance(balance))
+}
+
+// Flush commits accumulated logs.
+func (w *sqlWriter) Flush() (err error) {
+	if w.tx == nil {
+		return nil
+	}
+
+	defer func() {
+		if err != nil {
+			_ = w.tx.Rollback()
+		}
+		w.tx = nil
+		w.lastBlockID = luckyshare.Bytes32{}
+		w.len = 0
+	}()
+
+	if block.Number(w.lastBlockID) > 0 {
+		if err := w.exec(
+			w.db.dialect.insertOrReplace("INSERT INTO config(key, value) VALUES(?,?)", "key", []string{"value"}),
+			configBlockIDKey, w.lastBlockID.Bytes()); err != nil {
+			return err
+		}
+	}
+	return w.tx.Commit()
+}
+
+// Len returns count of pending logs.
+func (w *sqlWriter) Len() int {
+	return w.len
+}
+
+// Rollback discards accumulated logs.
+func (w *sqlWriter) Rollback() error {
+	if w.tx == nil {
+		return nil
+	}
+	defer func() { w.tx = nil }()
+	return w.tx.Rollback()
+}
+
+// insertRefs inserts non-nil values into ref table if not exist.
+func (w *sqlWriter) insertRefs(values ...[]byte) error {
+	var args []interface{}
+	for _, v := range values {
+		if v != nil {
+			args = append(args, v)
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	query := "INSERT INTO ref(data) VALUES(?)" + strings.Repeat(",(?)", len(args)-1)
+	return w.exec(w.db.dialect.insertOrIgnore(query), args...)
+}
+
+// begin begins the transaction if not yet.
+func (w *sqlWriter) begin() error {
+	if w.tx == nil {
+		tx, err := w.db.db.Begin()
+		if err != nil {
+			return err
+		}
+		w.tx = tx
+	}
+	return nil
+}
+
+func (w *sqlWriter) exec(query string, args ...interface{}) error {
+	if err := w.begin(); err != nil {
+		return err
+	}
+
+	if _, err := w.tx.Stmt(w.db.prepare(query)).Exec(args...); err != nil {
+		return err
+	}
+	w.len++
+	return nil
+}
+
+// query queries in the transaction, to see pending writes.
+func (w *sqlWriter) query(query string, args ...interface{}) (*sql.Rows, error) {
+	if err := w.begin(); err != nil {
+		return nil, err
+	}
+	return w.tx.Stmt(w.db.prepare(query)).Query(args...)
+}
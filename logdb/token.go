@@ -0,0 +1,181 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package logdb
+
+import (
+	"math/big"
+
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/tx"
+)
+
+// tokenTransferEventID is the id of event 'Transfer(address indexed,address indexed,uint256)'
+// of fungible tokens (ERC20/VIP180).
+var tokenTransferEventID = luckyshare.MustParseBytes32("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+// TokenTransfer represents a fungible token transfer recognized from Transfer event.
+// Sender is zero address for minting, and recipient is zero address for burning.
+type TokenTransfer struct {
+	BlockNumber uint32
+	Index       uint32 // index of the event in block
+	BlockID     luckyshare.Bytes32
+	BlockTime   uint64
+	TxID        luckyshare.Bytes32
+	TxOrigin    luckyshare.Address
+	ClauseIndex uint32
+	Token       luckyshare.Address
+	Sender      luckyshare.Address
+	Recipient   luckyshare.Address
+	Amount      *big.Int
+}
+
+// Cursor returns the cursor of the token transfer.
+func (t *TokenTransfer) Cursor() *Cursor {
+	return &Cursor{t.BlockNumber, t.Index}
+}
+
+// TokenBalance is the balance of a token holder, accumulated from indexed token transfers.
+type TokenBalance struct {
+	Token   luckyshare.Address
+	Holder  luckyshare.Address
+	Balance *big.Int
+}
+
+// TokenTransferCriteria matches token transfers with each field, if present.
+type TokenTransferCriteria struct {
+	Token     *luckyshare.Address
+	Sender    *luckyshare.Address
+	Recipient *luckyshare.Address
+}
+
+func (c *TokenTransferCriteria) toWhereCondition() (cond string, args []interface{}) {
+	cond = "TRUE"
+	for _, field := range []struct {
+		column string
+		values [][]byte
+	}{
+		{"token", addressValues(c.Token, nil)},
+		{"sender", addressValues(c.Sender, nil)},
+		{"recipient", addressValues(c.Recipient, nil)},
+	} {
+		fcond, fargs := refIDsCondition(field.column, field.values)
+		cond += fcond
+		args = append(args, fargs...)
+	}
+	return
+}
+
+type TokenTransferFilter struct {
+	CriteriaSet []*TokenTransferCriteria
+	Range       *Range
+	Options     *Options
+	Order       Order //default asc
+}
+
+func (f *TokenTransferFilter) toWhereCondition() (cond string, args []interface{}) {
+	cond, args = f.Range.toWhereCondition()
+	if len(f.CriteriaSet) > 0 {
+		cond += " AND ("
+		for i, c := range f.CriteriaSet {
+			ccond, cargs := c.toWhereCondition()
+			if i > 0 {
+				cond += " OR"
+			}
+			cond += " (" + ccond + ")"
+			args = append(args, cargs...)
+		}
+		cond += ")"
+	}
+	return
+}
+
+// tokenIndexer recognizes token transfers from events.
+type tokenIndexer struct {
+	excluded map[luckyshare.Address]bool
+}
+
+// decodeTokenTransfer returns sender, recipient and amount of the event, if it's a fungible token
+// Transfer event. NFT Transfer events, which have the token id indexed, are not matched.
+func decodeTokenTransfer(ev *tx.Event) (luckyshare.Address, luckyshare.Address, *big.Int, bool) {
+	if len(ev.Topics) != 3 || ev.Topics[0] != tokenTransferEventID || len(ev.Data) != 32 {
+		return luckyshare.Address{}, luckyshare.Address{}, nil, false
+	}
+	return luckyshare.BytesToAddress(ev.Topics[1].Bytes()),
+		luckyshare.BytesToAddress(ev.Topics[2].Bytes()),
+		new(big.Int).SetBytes(ev.Data),
+		true
+}
+
+// transfers returns token transfers in the block, indexed as their events.
+func (ti *tokenIndexer) transfers(b *block.Block, receipts tx.Receipts) (transfers []*TokenTransfer) {
+	var (
+		num        = b.Header().Number()
+		txs        = b.Transactions()
+		eventCount uint32
+	)
+	for txIndex, receipt := range receipts {
+		var (
+			txID     luckyshare.Bytes32
+			txOrigin luckyshare.Address
+		)
+		if num != 0 && len(receipt.Outputs) > 0 {
+			txID, txOrigin = tx.ExecutionMeta(num, txs, receipts, txIndex)
+		}
+		for clauseIndex, output := range receipt.Outputs {
+			for _, ev := range output.Events {
+				if sender, recipient, amount, ok := decodeTokenTransfer(ev); ok && !ti.excluded[ev.Address] {
+					transfers = append(transfers, &TokenTransfer{
+						BlockNumber: num,
+						Index:       eventCount,
+						BlockID:     b.Header().ID(),
+						BlockTime:   b.Header().Timestamp(),
+						TxID:        txID,
+						TxOrigin:    txOrigin,
+						ClauseIndex: uint32(clauseIndex),
+						Token:       ev.Address,
+						Sender:      sender,
+						Recipient:   recipient,
+						Amount:      amount,
+					})
+				}
+				eventCount++
+			}
+		}
+	}
+	return
+}
+
+// encodeBalance encodes the balance into bytes, which keep the order of values in byte-wise comparison.
+// Balances may be negative if the token changes balances without Transfer events, or the indexing
+// started after the token deployed.
+// The encoding is the sign byte (1 for non-negative), then the length and bytes of the magnitude,
+// which are inverted for negative values.
+func encodeBalance(balance *big.Int) []byte {
+	mag := new(big.Int).Abs(balance).Bytes()
+	if balance.Sign() >= 0 {
+		return append([]byte{1, byte(len(mag))}, mag...)
+	}
+	data := append([]byte{0, ^byte(len(mag))}, mag...)
+	for i := 2; i < len(data); i++ {
+		data[i] = ^data[i]
+	}
+	return data
+}
+
+func decodeBalance(data []byte) *big.Int {
+	if len(data) < 2 {
+		return &big.Int{}
+	}
+	if data[0] == 1 {
+		return new(big.Int).SetBytes(data[2:])
+	}
+	mag := make([]byte, len(data)-2)
+	for i, b := range data[2:] {
+		mag[i] = ^b
+	}
+	return new(big.Int).Neg(new(big.Int).SetBytes(mag))
+}
@@ -7,12 +7,16 @@ package logdb
 
 import (
 	"fmt"
+	"math"
 	"math/big"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/pkg/errors"
 )
 
-//Event represents tx.Event that can be stored in db.
+// Event represents tx.Event that can be stored in db.
 type Event struct {
 	BlockNumber uint32
 	Index       uint32
@@ -26,7 +30,12 @@ type Event struct {
 	Data        []byte
 }
 
-//Transfer represents tx.Transfer that can be stored in db.
+// Cursor returns the cursor of the event.
+func (e *Event) Cursor() *Cursor {
+	return &Cursor{e.BlockNumber, e.Index}
+}
+
+// Transfer represents tx.Transfer that can be stored in db.
 type Transfer struct {
 	BlockNumber uint32
 	Index       uint32
@@ -40,6 +49,41 @@ type Transfer struct {
 	Amount      *big.Int
 }
 
+// Cursor returns the cursor of the transfer.
+func (t *Transfer) Cursor() *Cursor {
+	return &Cursor{t.BlockNumber, t.Index}
+}
+
+// Cursor locates a log by block number and index in block, to page logs without offset.
+// It's presented as hex string of the sequence.
+type Cursor struct {
+	BlockNumber uint32
+	Index       uint32
+}
+
+func (c *Cursor) sequence() sequence {
+	return newSequence(c.BlockNumber, c.Index)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c Cursor) MarshalText() ([]byte, error) {
+	return []byte(hexutil.EncodeUint64(uint64(c.sequence()))), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *Cursor) UnmarshalText(text []byte) error {
+	v, err := hexutil.DecodeUint64(string(text))
+	if err != nil {
+		return err
+	}
+	if v > math.MaxInt64 {
+		return errors.New("cursor out of range")
+	}
+	seq := sequence(v)
+	*c = Cursor{seq.BlockNumber(), seq.Index()}
+	return nil
+}
+
 type Order string
 
 const (
@@ -55,29 +99,108 @@ type Range struct {
 type Options struct {
 	Offset uint64
 	Limit  uint64
+	// Cursor, if set, skips logs up to the cursor in the order.
+	Cursor *Cursor
+}
+
+func (o *Options) toClause(order Order) (clause string, args []interface{}) {
+	if o != nil && o.Cursor != nil {
+		if order == DESC {
+			clause += " AND seq < ?"
+		} else {
+			clause += " AND seq > ?"
+		}
+		args = append(args, o.Cursor.sequence())
+	}
+
+	clause += orderBy("", order)
+
+	if o != nil {
+		clause += " LIMIT ? OFFSET ?"
+		args = append(args, o.Limit, o.Offset)
+	}
+	return
+}
+
+func orderBy(table string, order Order) string {
+	column := "seq"
+	if table != "" {
+		column = table + ".seq"
+	}
+	if order == DESC {
+		return " ORDER BY " + column + " DESC"
+	}
+	return " ORDER BY " + column + " ASC"
+}
+
+func (r *Range) toWhereCondition() (cond string, args []interface{}) {
+	if r != nil {
+		cond += " AND seq >= ?"
+		args = append(args, newSequence(r.From, 0))
+		if r.To >= r.From {
+			cond += " AND seq <= ?"
+			args = append(args, newSequence(r.To, uint32(math.MaxInt32)))
+		}
+	}
+	return
+}
+
+// refIDsCondition matches the column with any of values.
+func refIDsCondition(column string, values [][]byte) (cond string, args []interface{}) {
+	switch len(values) {
+	case 0:
+		return
+	case 1:
+		cond = " AND " + column + " = " + refIDQuery
+	default:
+		cond = fmt.Sprintf(" AND %v IN (SELECT id FROM ref WHERE data IN (?%v))", column, strings.Repeat(",?", len(values)-1))
+	}
+	for _, v := range values {
+		args = append(args, v)
+	}
+	return
+}
+
+func addressValues(addr *luckyshare.Address, addrs []luckyshare.Address) (values [][]byte) {
+	if addr != nil {
+		values = append(values, addr.Bytes())
+	}
+	for _, a := range addrs {
+		values = append(values, a.Bytes())
+	}
+	return
 }
 
+// EventCriteria matches events of any address in {Address} + Addresses, and at each position,
+// topic of any in {Topics[i]} + TopicSets[i].
 type EventCriteria struct {
-	Address *luckyshare.Address // always a contract address
-	Topics  [5]*luckyshare.Bytes32
+	Address   *luckyshare.Address // always a contract address
+	Addresses []luckyshare.Address
+	Topics    [5]*luckyshare.Bytes32
+	TopicSets [5][]luckyshare.Bytes32
 }
 
 func (c *EventCriteria) toWhereCondition() (cond string, args []interface{}) {
-	cond = "1"
-	if c.Address != nil {
-		cond += " AND address = " + refIDQuery
-		args = append(args, c.Address.Bytes())
-	}
+	cond = "TRUE"
+	acond, aargs := refIDsCondition("address", addressValues(c.Address, c.Addresses))
+	cond += acond
+	args = append(args, aargs...)
 	for i, topic := range c.Topics {
+		var values [][]byte
 		if topic != nil {
-			cond += fmt.Sprintf(" AND topic%v = ", i) + refIDQuery
-			args = append(args, topic.Bytes())
+			values = append(values, topic.Bytes())
+		}
+		for _, t := range c.TopicSets[i] {
+			values = append(values, t.Bytes())
 		}
+		tcond, targs := refIDsCondition(fmt.Sprintf("topic%v", i), values)
+		cond += tcond
+		args = append(args, targs...)
 	}
 	return
 }
 
-//EventFilter filter
+// EventFilter filter
 type EventFilter struct {
 	CriteriaSet []*EventCriteria
 	Range       *Range
@@ -85,25 +208,46 @@ type EventFilter struct {
 	Order       Order //default asc
 }
 
+func (f *EventFilter) toWhereCondition() (cond string, args []interface{}) {
+	cond, args = f.Range.toWhereCondition()
+	if len(f.CriteriaSet) > 0 {
+		cond += " AND ("
+		for i, c := range f.CriteriaSet {
+			ccond, cargs := c.toWhereCondition()
+			if i > 0 {
+				cond += " OR"
+			}
+			cond += " (" + ccond + ")"
+			args = append(args, cargs...)
+		}
+		cond += ")"
+	}
+	return
+}
+
+// TransferCriteria matches transfers with each address field, if present, in {X} + Xs.
 type TransferCriteria struct {
-	TxOrigin  *luckyshare.Address //who send transaction
-	Sender    *luckyshare.Address //who transferred tokens
-	Recipient *luckyshare.Address //who recieved tokens
+	TxOrigin   *luckyshare.Address //who send transaction
+	Sender     *luckyshare.Address //who transferred tokens
+	Recipient  *luckyshare.Address //who recieved tokens
+	TxOrigins  []luckyshare.Address
+	Senders    []luckyshare.Address
+	Recipients []luckyshare.Address
 }
 
 func (c *TransferCriteria) toWhereCondition() (cond string, args []interface{}) {
-	cond = "1"
-	if c.TxOrigin != nil {
-		cond += " AND txOrigin = " + refIDQuery
-		args = append(args, c.TxOrigin.Bytes())
-	}
-	if c.Sender != nil {
-		cond += " AND sender = " + refIDQuery
-		args = append(args, c.Sender.Bytes())
-	}
-	if c.Recipient != nil {
-		cond += " AND recipient = " + refIDQuery
-		args = append(args, c.Recipient.Bytes())
+	cond = "TRUE"
+	for _, field := range []struct {
+		column string
+		values [][]byte
+	}{
+		{"txOrigin", addressValues(c.TxOrigin, c.TxOrigins)},
+		{"sender", addressValues(c.Sender, c.Senders)},
+		{"recipient", addressValues(c.Recipient, c.Recipients)},
+	} {
+		fcond, fargs := refIDsCondition(field.column, field.values)
+		cond += fcond
+		args = append(args, fargs...)
 	}
 	return
 }
@@ -114,3 +258,101 @@ type TransferFilter struct {
 	Options     *Options
 	Order       Order //default asc
 }
+
+func (f *TransferFilter) toWhereCondition() (cond string, args []interface{}) {
+	cond, args = f.Range.toWhereCondition()
+	if len(f.CriteriaSet) > 0 {
+		cond += " AND ("
+		for i, c := range f.CriteriaSet {
+			ccond, cargs := c.toWhereCondition()
+			if i > 0 {
+				cond += " OR"
+			}
+			cond += " (" + ccond + ")"
+			args = append(args, cargs...)
+		}
+		cond += ")"
+	}
+	return
+}
+
+// Call represents a message call or contract creation traced from a clause execution.
+type Call struct {
+	BlockNumber uint32
+	Index       uint32
+	BlockID     luckyshare.Bytes32
+	BlockTime   uint64
+	TxID        luckyshare.Bytes32
+	TxOrigin    luckyshare.Address
+	ClauseIndex uint32
+	// Depth is 0 for the clause itself, and increases by 1 for each internal call level.
+	Depth uint32
+	// Type is one of 'call', 'callcode', 'delegatecall', 'staticcall', 'create' and 'create2'.
+	Type   string
+	Caller luckyshare.Address
+	// Callee is the created contract for creations, or zero address if creation failed.
+	Callee luckyshare.Address
+	// Selector is the leading 4 bytes of call input, nil for creations or short inputs.
+	Selector []byte
+	Value    *big.Int
+	// Reverted is true if the call itself failed. Succeeded calls are also reverted
+	// if any of outer calls failed.
+	Reverted bool
+}
+
+// Cursor returns the cursor of the call.
+func (c *Call) Cursor() *Cursor {
+	return &Cursor{c.BlockNumber, c.Index}
+}
+
+// CallCriteria matches calls with each field, if present.
+type CallCriteria struct {
+	Caller   *luckyshare.Address
+	Callee   *luckyshare.Address
+	Selector []byte
+}
+
+func (c *CallCriteria) toWhereCondition() (cond string, args []interface{}) {
+	cond = "TRUE"
+	var selectors [][]byte
+	if c.Selector != nil {
+		selectors = append(selectors, c.Selector)
+	}
+	for _, field := range []struct {
+		column string
+		values [][]byte
+	}{
+		{"caller", addressValues(c.Caller, nil)},
+		{"callee", addressValues(c.Callee, nil)},
+		{"selector", selectors},
+	} {
+		fcond, fargs := refIDsCondition(field.column, field.values)
+		cond += fcond
+		args = append(args, fargs...)
+	}
+	return
+}
+
+type CallFilter struct {
+	CriteriaSet []*CallCriteria
+	Range       *Range
+	Options     *Options
+	Order       Order //default asc
+}
+
+func (f *CallFilter) toWhereCondition() (cond string, args []interface{}) {
+	cond, args = f.Range.toWhereCondition()
+	if len(f.CriteriaSet) > 0 {
+		cond += " AND ("
+		for i, c := range f.CriteriaSet {
+			ccond, cargs := c.toWhereCondition()
+			if i > 0 {
+				cond += " OR"
+			}
+			cond += " (" + ccond + ")"
+			args = append(args, cargs...)
+		}
+		cond += ")"
+	}
+	return
+}
@@ -13,9 +13,16 @@ import (
 
 // ForkConfig config for a fork.
 type ForkConfig struct {
-	VIP191    uint32
-	ETH_CONST uint32
-	BLOCKLIST uint32
+	VIP191       uint32
+	ETH_CONST    uint32
+	BLOCKLIST    uint32
+	ETH_IST      uint32
+	ETH_BERLIN   uint32
+	ETH_SHANGHAI uint32
+	ETH_PRAGUE   uint32
+	DYNAMIC_FEE  uint32
+	STAKING      uint32
+	SCHEDULER    uint32
 }
 
 func (fc ForkConfig) String() string {
@@ -29,30 +36,58 @@ func (fc ForkConfig) String() string {
 	push("VIP191", fc.VIP191)
 	push("ETH_CONST", fc.ETH_CONST)
 	push("BLOCKLIST", fc.BLOCKLIST)
+	push("ETH_IST", fc.ETH_IST)
+	push("ETH_BERLIN", fc.ETH_BERLIN)
+	push("ETH_SHANGHAI", fc.ETH_SHANGHAI)
+	push("ETH_PRAGUE", fc.ETH_PRAGUE)
+	push("DYNAMIC_FEE", fc.DYNAMIC_FEE)
+	push("STAKING", fc.STAKING)
+	push("SCHEDULER", fc.SCHEDULER)
 
 	return strings.Join(strs, ", ")
 }
 
 // NoFork a special config without any forks.
 var NoFork = ForkConfig{
-	VIP191:    math.MaxUint32,
-	ETH_CONST: math.MaxUint32,
-	BLOCKLIST: math.MaxUint32,
+	VIP191:       math.MaxUint32,
+	ETH_CONST:    math.MaxUint32,
+	BLOCKLIST:    math.MaxUint32,
+	ETH_IST:      math.MaxUint32,
+	ETH_BERLIN:   math.MaxUint32,
+	ETH_SHANGHAI: math.MaxUint32,
+	ETH_PRAGUE:   math.MaxUint32,
+	DYNAMIC_FEE:  math.MaxUint32,
+	STAKING:      math.MaxUint32,
+	SCHEDULER:    math.MaxUint32,
 }
 
 // for well-known networks
 var forkConfigs = map[Bytes32]ForkConfig{
 	// mainnet
 	MustParseBytes32("0x00000000851caf3cfdb6e899cf5958bfb1ac3413d346d43539627e6be7ec1b4a"): {
-		VIP191:    3337300,
-		ETH_CONST: 3337300,
-		BLOCKLIST: 4817300,
+		VIP191:       3337300,
+		ETH_CONST:    3337300,
+		BLOCKLIST:    4817300,
+		ETH_IST:      math.MaxUint32,
+		ETH_BERLIN:   math.MaxUint32,
+		ETH_SHANGHAI: math.MaxUint32,
+		ETH_PRAGUE:   math.MaxUint32,
+		DYNAMIC_FEE:  math.MaxUint32,
+		STAKING:      math.MaxUint32,
+		SCHEDULER:    math.MaxUint32,
 	},
 	// testnet
 	MustParseBytes32("0x000000000b2bce3c70bc649a02749e8687721b09ed2e15997f466536b20bb127"): {
-		VIP191:    2898800,
-		ETH_CONST: 3192500,
-		BLOCKLIST: math.MaxUint32,
+		VIP191:       2898800,
+		ETH_CONST:    3192500,
+		BLOCKLIST:    math.MaxUint32,
+		ETH_IST:      math.MaxUint32,
+		ETH_BERLIN:   math.MaxUint32,
+		ETH_SHANGHAI: math.MaxUint32,
+		ETH_PRAGUE:   math.MaxUint32,
+		DYNAMIC_FEE:  math.MaxUint32,
+		STAKING:      math.MaxUint32,
+		SCHEDULER:    math.MaxUint32,
 	},
 }
 
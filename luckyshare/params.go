@@ -35,6 +35,18 @@ const (
 	TolerableBlockPackingTime = 500 * time.Millisecond // the indicator to adjust target block gas limit
 
 	MaxStateHistory = 65535 // max guaranteed state history allowed to be accessed in EVM, presented in block number
+
+	BaseGasPriceChangeDenominator uint64 = 8 // bounds the change of dynamic base gas price between two blocks to 1/8
+	GasElasticityMultiplier       uint64 = 2 // the gas used target of a block is gas limit divided by this value
+
+	UnbondingPeriod          uint64 = 60 * 60 * 24 * 7 // (unit: second) time for undelegated tokens to be locked before withdrawable
+	DoubleSignSlashRatio     uint64 = 10               // percentage of endorsor's balance slashed for double signing
+	MaxDoubleSignEvidenceAge uint32 = 8640             // (unit: block) double signing evidence older than this is not accepted
+
+	MaxScheduledGas              uint64 = 1000 * 1000 // max gas a scheduled clause execution can consume
+	ScheduledGasLimitDenominator uint64 = 2           // scheduled executions can use up to gas limit divided by this value in a block
+	MaxScheduledPerBlock         int    = 64          // max due schedules taken out in a block, the rest are postponed
+	MaxSchedulesPerDueBlock      uint64 = 256         // max new schedules queued at the same block
 )
 
 // Keys of governance params.
@@ -43,6 +55,7 @@ var (
 	KeyRewardRatio         = BytesToBytes32([]byte("reward-ratio"))
 	KeyBaseGasPrice        = BytesToBytes32([]byte("base-gas-price"))
 	KeyProposerEndorsement = BytesToBytes32([]byte("proposer-endorsement"))
+	KeyDynamicBaseGasPrice = BytesToBytes32([]byte("dynamic-base-gas-price"))
 
 	InitialRewardRatio         = big.NewInt(3e17) // 30%
 	InitialBaseGasPrice        = big.NewInt(1e15)
@@ -12,6 +12,7 @@ var (
 	errTxNotAdoptableNow     = errors.New("tx not adoptable now")
 	errTxNotAdoptableForever = errors.New("tx not adoptable forever")
 	errKnownTx               = errors.New("known tx")
+	errBundleReverted        = errors.New("bundle reverted")
 )
 
 // IsGasLimitReached block if full of txs.
@@ -24,6 +25,11 @@ func IsTxNotAdoptableNow(err error) bool {
 	return errors.Cause(err) == errTxNotAdoptableNow
 }
 
+// IsBundleReverted some tx in the bundle reverted.
+func IsBundleReverted(err error) bool {
+	return errors.Cause(err) == errBundleReverted
+}
+
 // IsBadTx not a valid tx.
 func IsBadTx(err error) bool {
 	_, ok := errors.Cause(err).(badTxError)
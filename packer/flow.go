@@ -25,23 +25,35 @@ type Flow struct {
 	processedTxs map[luckyshare.Bytes32]bool // txID -> reverted
 	gasUsed      uint64
 	txs          tx.Transactions
-	receipts     tx.Receipts
+	receipts     tx.Receipts // in execution order, receipts of scheduled executions come first
 	features     tx.Features
 }
 
+// newFlow creates a flow, and executes scheduled clauses due at the new block.
 func newFlow(
 	packer *Packer,
 	parentHeader *block.Header,
 	runtime *runtime.Runtime,
 	features tx.Features,
-) *Flow {
+) (*Flow, error) {
+	scheduled, err := runtime.ExecuteScheduled()
+	if err != nil {
+		return nil, err
+	}
+
+	var gasUsed uint64
+	for _, r := range scheduled {
+		gasUsed += r.GasUsed
+	}
 	return &Flow{
 		packer:       packer,
 		parentHeader: parentHeader,
 		runtime:      runtime,
 		processedTxs: make(map[luckyshare.Bytes32]bool),
+		gasUsed:      gasUsed,
+		receipts:     scheduled,
 		features:     features,
-	}
+	}, nil
 }
 
 // ParentHeader returns parent block header.
@@ -59,6 +71,26 @@ func (f *Flow) TotalScore() uint64 {
 	return f.runtime.Context().TotalScore
 }
 
+// Number returns number of new block.
+func (f *Flow) Number() uint32 {
+	return f.runtime.Context().Number
+}
+
+// GasLimit returns gas limit of new block.
+func (f *Flow) GasLimit() uint64 {
+	return f.runtime.Context().GasLimit
+}
+
+// GasUsed returns gas used by scheduled executions and adopted txs.
+func (f *Flow) GasUsed() uint64 {
+	return f.gasUsed
+}
+
+// Receipts returns receipts of scheduled executions and adopted txs, in execution order.
+func (f *Flow) Receipts() tx.Receipts {
+	return f.receipts
+}
+
 func (f *Flow) findTx(txID luckyshare.Bytes32) (found bool, reverted bool, err error) {
 	if reverted, ok := f.processedTxs[txID]; ok {
 		return true, reverted, nil
@@ -137,6 +169,47 @@ func (f *Flow) Adopt(tx *tx.Transaction) error {
 	return nil
 }
 
+// AdoptBundle try to execute the given txs in order, as an atomic group.
+// The bundle is adopted only if every tx in it is adopted and not reverted,
+// otherwise all changes made by the bundle are rolled back.
+func (f *Flow) AdoptBundle(txs tx.Transactions) error {
+	var gas uint64
+	for _, tx := range txs {
+		gas += tx.Gas()
+	}
+	if f.gasUsed+gas > f.runtime.Context().GasLimit {
+		return errGasLimitReached
+	}
+
+	var (
+		checkpoint = f.runtime.State().NewCheckpoint()
+		gasUsed    = f.gasUsed
+		nTxs       = len(f.txs)
+		nReceipts  = len(f.receipts)
+	)
+	rollback := func() {
+		f.runtime.State().RevertTo(checkpoint)
+		for _, tx := range f.txs[nTxs:] {
+			delete(f.processedTxs, tx.ID())
+		}
+		f.gasUsed = gasUsed
+		f.txs = f.txs[:nTxs]
+		f.receipts = f.receipts[:nReceipts]
+	}
+
+	for i, tx := range txs {
+		if err := f.Adopt(tx); err != nil {
+			rollback()
+			return errors.WithMessagef(err, "bundle tx #%v", i)
+		}
+		if f.receipts[len(f.receipts)-1].Reverted {
+			rollback()
+			return errors.WithMessagef(errBundleReverted, "bundle tx #%v", i)
+		}
+	}
+	return nil
+}
+
 // Pack build and sign the new block.
 func (f *Flow) Pack(privateKey *ecdsa.PrivateKey) (*block.Block, *state.Stage, tx.Receipts, error) {
 	if f.packer.nodeMaster != luckyshare.Address(crypto.PubkeyToAddress(privateKey.PublicKey)) {
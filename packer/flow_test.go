@@ -0,0 +1,67 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package packer_test
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miniBamboo/luckyshare/genesis"
+	"github.com/miniBamboo/luckyshare/packer"
+	"github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRevertingTx(chainTag byte, from genesis.DevAccount) *tx.Transaction {
+	method, _ := sharer.Energy.ABI.MethodByName("transfer")
+	// transfer amount exceeds balance
+	data, _ := method.EncodeInput(genesis.DevAccounts()[9].Address, new(big.Int).Lsh(big.NewInt(1), 200))
+
+	trx := new(tx.Builder).
+		ChainTag(chainTag).
+		Clause(tx.NewClause(&sharer.Energy.Address).WithData(data)).
+		Gas(100000).Nonce(nonce).Expiration(math.MaxUint32).Build()
+	nonce++
+	sig, _ := crypto.Sign(trx.SigningHash().Bytes(), from.PrivateKey)
+	return trx.WithSignature(sig)
+}
+
+func TestAdoptBundle(t *testing.T) {
+	accs := genesis.DevAccounts()
+	flow, chainTag := newFlow(t)
+
+	tx1 := newTransferTx(chainTag, accs[0], nil)
+	tx2 := newTransferTx(chainTag, accs[1], nil)
+	assert.Nil(t, flow.AdoptBundle(tx.Transactions{tx1, tx2}))
+	gasUsed := flow.GasUsed()
+	assert.Equal(t, uint64(42000), gasUsed)
+
+	// the whole bundle rolled back if any tx reverted
+	tx3 := newTransferTx(chainTag, accs[2], nil)
+	tx4 := newRevertingTx(chainTag, accs[3])
+	err := flow.AdoptBundle(tx.Transactions{tx3, tx4})
+	assert.True(t, packer.IsBundleReverted(err))
+	assert.Equal(t, gasUsed, flow.GasUsed())
+
+	// known tx
+	err = flow.AdoptBundle(tx.Transactions{tx3, tx1})
+	assert.True(t, packer.IsKnownTx(err))
+	assert.Equal(t, gasUsed, flow.GasUsed())
+
+	// tx3 can be adopted again after rollback
+	assert.Nil(t, flow.Adopt(tx3))
+
+	blk, stage, _, err := flow.Pack(accs[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _ := stage.Commit()
+	assert.Equal(t, root, blk.Header().StateRoot())
+	assert.Equal(t, 3, len(blk.Transactions()))
+}
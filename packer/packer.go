@@ -66,32 +66,43 @@ func (p *Packer) Schedule(parent *block.Header, nowTimestamp uint64) (flow *Flow
 		features |= tx.DelegationFeature
 	}
 
+	// Before process hook of DYNAMIC_FEE, update base gas price according to parent's gas usage
+	if err := runtime.UpdateBaseGasPrice(state, parent, p.forkConfig); err != nil {
+		return nil, err
+	}
+
+	// Before process hook of STAKING, deploy sharer staker contract
+	if err := runtime.ActivateStaking(state, parent.Number()+1, p.forkConfig); err != nil {
+		return nil, err
+	}
+
+	// Before process hook of SCHEDULER, deploy sharer scheduler contract
+	if err := runtime.ActivateScheduler(state, parent.Number()+1, p.forkConfig); err != nil {
+		return nil, err
+	}
+
 	authority := sharer.Authority.Native(state)
-	endorsement, err := sharer.Params.Native(state).Get(luckyshare.KeyProposerEndorsement)
+	list, err := authority.AllCandidates()
 	if err != nil {
 		return nil, err
 	}
-	candidates, err := authority.Candidates(endorsement, luckyshare.MaxBlockProposers)
+	// endorsement counts in tokens delegated to endorsor
+	proposers, err := poal.NewCandidates(list).Pick(state, parent.Number()+1 >= p.forkConfig.STAKING)
 	if err != nil {
 		return nil, err
 	}
-	var (
-		proposers   = make([]poal.Proposer, 0, len(candidates))
-		beneficiary luckyshare.Address
-	)
+
+	var beneficiary luckyshare.Address
 	if p.beneficiary != nil {
 		beneficiary = *p.beneficiary
-	}
-
-	for _, c := range candidates {
-		if p.beneficiary == nil && c.NodeMaster == p.nodeMaster {
-			// no beneficiary not set, set it to endorsor
-			beneficiary = c.Endorsor
+	} else {
+		for _, c := range list {
+			if c.NodeMaster == p.nodeMaster {
+				// no beneficiary not set, set it to endorsor
+				beneficiary = c.Endorsor
+				break
+			}
 		}
-		proposers = append(proposers, poal.Proposer{
-			Address: c.NodeMaster,
-			Active:  c.Active,
-		})
 	}
 
 	// calc the time when it's turn to produce block
@@ -122,7 +133,7 @@ func (p *Packer) Schedule(parent *block.Header, nowTimestamp uint64) (flow *Flow
 		},
 		p.forkConfig)
 
-	return newFlow(p, parent, rt, features), nil
+	return newFlow(p, parent, rt, features)
 }
 
 // Mock create a packing flow upon given parent, but with a designated timestamp.
@@ -148,6 +159,21 @@ func (p *Packer) Mock(parent *block.Header, targetTime uint64, gasLimit uint64)
 		features |= tx.DelegationFeature
 	}
 
+	// Before process hook of DYNAMIC_FEE, update base gas price according to parent's gas usage
+	if err := runtime.UpdateBaseGasPrice(state, parent, p.forkConfig); err != nil {
+		return nil, err
+	}
+
+	// Before process hook of STAKING, deploy sharer staker contract
+	if err := runtime.ActivateStaking(state, parent.Number()+1, p.forkConfig); err != nil {
+		return nil, err
+	}
+
+	// Before process hook of SCHEDULER, deploy sharer scheduler contract
+	if err := runtime.ActivateScheduler(state, parent.Number()+1, p.forkConfig); err != nil {
+		return nil, err
+	}
+
 	gl := gasLimit
 	if gasLimit == 0 {
 		gl = p.gasLimit(parent.GasLimit())
@@ -166,7 +192,7 @@ func (p *Packer) Mock(parent *block.Header, targetTime uint64, gasLimit uint64)
 		},
 		p.forkConfig)
 
-	return newFlow(p, parent, rt, features), nil
+	return newFlow(p, parent, rt, features)
 }
 
 func (p *Packer) gasLimit(parentGasLimit uint64) uint64 {
@@ -0,0 +1,157 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package packer
+
+import (
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/tx"
+)
+
+// Strategy decides the order and the subset of txs to be adopted into a flow.
+type Strategy interface {
+	// Adopt tries to adopt txs into the flow, and returns txs that will never be adoptable.
+	Adopt(flow *Flow, txs tx.Transactions) (unadoptable tx.Transactions)
+}
+
+// DefaultStrategy adopts txs in the given order.
+// Txs from tx pool are sorted by overall gas price from high to low.
+type DefaultStrategy struct{}
+
+// Adopt implements Strategy.
+func (DefaultStrategy) Adopt(flow *Flow, txs tx.Transactions) (unadoptable tx.Transactions) {
+	for _, tx := range txs {
+		if err := flow.Adopt(tx); err != nil {
+			if IsGasLimitReached(err) {
+				break
+			}
+			if IsTxNotAdoptableNow(err) {
+				continue
+			}
+			unadoptable = append(unadoptable, tx)
+		}
+	}
+	return
+}
+
+// FairStrategy prevents a single origin from filling the whole block.
+// Txs from reserved origins (e.g. system addresses) are adopted ahead of others and are not capped,
+// then other txs are adopted with per-origin gas cap. Txs depending on each other are packed together.
+type FairStrategy struct {
+	originGasCapPercent uint64
+	reservedOrigins     map[luckyshare.Address]bool
+}
+
+// NewFairStrategy create a fair strategy. The gas cap of each origin is in percent of block gas limit,
+// 0 means no cap.
+func NewFairStrategy(originGasCapPercent uint64, reservedOrigins []luckyshare.Address) *FairStrategy {
+	reserved := make(map[luckyshare.Address]bool, len(reservedOrigins))
+	for _, addr := range reservedOrigins {
+		reserved[addr] = true
+	}
+	return &FairStrategy{
+		originGasCapPercent: originGasCapPercent,
+		reservedOrigins:     reserved,
+	}
+}
+
+// Adopt implements Strategy.
+func (s *FairStrategy) Adopt(flow *Flow, txs tx.Transactions) (unadoptable tx.Transactions) {
+	var reserved, others tx.Transactions
+	for _, tx := range orderByDependency(txs) {
+		if origin, _ := tx.Origin(); s.reservedOrigins[origin] {
+			reserved = append(reserved, tx)
+		} else {
+			others = append(others, tx)
+		}
+	}
+
+	var (
+		gasCap       = flow.GasLimit() * s.originGasCapPercent / 100
+		originGasMap = make(map[luckyshare.Address]uint64)
+	)
+
+	// returns false if block is full
+	adopt := func(txs tx.Transactions, capped bool) bool {
+		for _, tx := range txs {
+			origin, _ := tx.Origin()
+			if capped && gasCap > 0 && originGasMap[origin]+tx.Gas() > gasCap {
+				// origin's share used up
+				continue
+			}
+
+			gasUsed := flow.GasUsed()
+			if err := flow.Adopt(tx); err != nil {
+				if IsGasLimitReached(err) {
+					return false
+				}
+				if IsTxNotAdoptableNow(err) {
+					continue
+				}
+				unadoptable = append(unadoptable, tx)
+				continue
+			}
+			originGasMap[origin] += flow.GasUsed() - gasUsed
+		}
+		return true
+	}
+
+	if adopt(reserved, false) {
+		adopt(others, true)
+	}
+	return
+}
+
+// orderByDependency reorders txs so that a tx immediately follows the tx it depends on,
+// if both are in the list. Otherwise the original order is kept.
+func orderByDependency(txs tx.Transactions) tx.Transactions {
+	var (
+		ids      = make(map[luckyshare.Bytes32]bool, len(txs))
+		children = make(map[luckyshare.Bytes32]tx.Transactions)
+	)
+	for _, tx := range txs {
+		ids[tx.ID()] = true
+	}
+	for _, tx := range txs {
+		if dep := tx.DependsOn(); dep != nil && ids[*dep] {
+			children[*dep] = append(children[*dep], tx)
+		}
+	}
+	if len(children) == 0 {
+		return txs
+	}
+
+	var (
+		ordered = make(tx.Transactions, 0, len(txs))
+		visited = make(map[luckyshare.Bytes32]bool, len(txs))
+		visit   func(tx *tx.Transaction)
+	)
+	visit = func(tx *tx.Transaction) {
+		id := tx.ID()
+		if visited[id] {
+			return
+		}
+		visited[id] = true
+		ordered = append(ordered, tx)
+		for _, child := range children[id] {
+			visit(child)
+		}
+	}
+
+	for _, tx := range txs {
+		if dep := tx.DependsOn(); dep != nil && ids[*dep] {
+			// will be visited following its dependency
+			continue
+		}
+		visit(tx)
+	}
+	// txs in dependency cycle, which are impossible to be adopted
+	for _, tx := range txs {
+		if !visited[tx.ID()] {
+			ordered = append(ordered, tx)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package packer_test
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miniBamboo/luckyshare/chain"
+	"github.com/miniBamboo/luckyshare/genesis"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/muxdb"
+	"github.com/miniBamboo/luckyshare/packer"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFlow(t *testing.T) (*packer.Flow, byte) {
+	db := muxdb.NewMem()
+	stater := state.NewStater(db)
+	b0, _, _, _ := genesis.NewDevnet().Build(stater)
+	repo, _ := chain.NewRepository(db, b0)
+
+	a0 := genesis.DevAccounts()[0]
+	p := packer.New(repo, stater, a0.Address, &a0.Address, luckyshare.NoFork)
+	flow, err := p.Schedule(repo.BestBlock().Header(), uint64(time.Now().Unix()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return flow, repo.ChainTag()
+}
+
+func newTransferTx(chainTag byte, from genesis.DevAccount, dependsOn *luckyshare.Bytes32) *tx.Transaction {
+	to := genesis.DevAccounts()[9].Address
+	trx := new(tx.Builder).
+		ChainTag(chainTag).
+		Clause(tx.NewClause(&to).WithValue(big.NewInt(1))).
+		Gas(21000).Nonce(nonce).DependsOn(dependsOn).Expiration(math.MaxUint32).Build()
+	nonce++
+	sig, _ := crypto.Sign(trx.SigningHash().Bytes(), from.PrivateKey)
+	return trx.WithSignature(sig)
+}
+
+func TestFairStrategy(t *testing.T) {
+	flow, chainTag := newFlow(t)
+	accs := genesis.DevAccounts()
+
+	var txs tx.Transactions
+	for i := 0; i < 20; i++ {
+		txs = append(txs, newTransferTx(chainTag, accs[0], nil))
+	}
+	txs = append(txs, newTransferTx(chainTag, accs[1], nil))
+	reservedTx := newTransferTx(chainTag, accs[2], nil)
+	txs = append(txs, reservedTx)
+
+	strategy := packer.NewFairStrategy(1, []luckyshare.Address{accs[2].Address})
+	unadoptable := strategy.Adopt(flow, txs)
+	assert.Zero(t, len(unadoptable))
+
+	blk, _, _, err := flow.Pack(accs[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packed := blk.Transactions()
+	// reserved lane first
+	assert.Equal(t, reservedTx.ID(), packed[0].ID())
+
+	counts := make(map[luckyshare.Address]int)
+	for _, trx := range packed {
+		origin, _ := trx.Origin()
+		counts[origin]++
+	}
+	assert.Equal(t, int(flow.GasLimit()/100/21000), counts[accs[0].Address])
+	assert.Equal(t, 1, counts[accs[1].Address])
+	assert.Equal(t, 1, counts[accs[2].Address])
+}
+
+func TestFairStrategyDependency(t *testing.T) {
+	accs := genesis.DevAccounts()
+
+	build := func(chainTag byte) tx.Transactions {
+		txA := newTransferTx(chainTag, accs[0], nil)
+		id := txA.ID()
+		txB := newTransferTx(chainTag, accs[1], &id)
+		txC := newTransferTx(chainTag, accs[2], nil)
+		return tx.Transactions{txB, txC, txA}
+	}
+
+	// default strategy skips txB since its dependency not yet adopted
+	flow, chainTag := newFlow(t)
+	txs := build(chainTag)
+	packer.DefaultStrategy{}.Adopt(flow, txs)
+	blk, _, _, err := flow.Pack(accs[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, len(blk.Transactions()))
+
+	flow, chainTag = newFlow(t)
+	txs = build(chainTag)
+	packer.NewFairStrategy(0, nil).Adopt(flow, txs)
+	blk, _, _, err = flow.Pack(accs[0].PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed := blk.Transactions()
+	assert.Equal(t, 3, len(packed))
+	assert.Equal(t, []luckyshare.Bytes32{txs[1].ID(), txs[2].ID(), txs[0].ID()},
+		[]luckyshare.Bytes32{packed[0].ID(), packed[1].ID(), packed[2].ID()})
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package runtime
+
+import (
+	"math/big"
+
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	sharer "github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/state"
+)
+
+// BaseGasPrice returns the base gas price effective in the given state.
+// It's the dynamic base gas price if set, or the one governed by params.
+func BaseGasPrice(state *state.State) (*big.Int, error) {
+	params := sharer.Params.Native(state)
+	dynamic, err := params.Get(luckyshare.KeyDynamicBaseGasPrice)
+	if err != nil {
+		return nil, err
+	}
+	if dynamic.Sign() > 0 {
+		return dynamic, nil
+	}
+	return params.Get(luckyshare.KeyBaseGasPrice)
+}
+
+// NextBaseGasPrice returns the base gas price of the block next to parent.
+// The state should be the one right after parent.
+func NextBaseGasPrice(state *state.State, parent *block.Header, forkConfig luckyshare.ForkConfig) (*big.Int, error) {
+	params := sharer.Params.Native(state)
+	governed, err := params.Get(luckyshare.KeyBaseGasPrice)
+	if err != nil {
+		return nil, err
+	}
+	if parent.Number()+1 < forkConfig.DYNAMIC_FEE {
+		return governed, nil
+	}
+
+	dynamic, err := params.Get(luckyshare.KeyDynamicBaseGasPrice)
+	if err != nil {
+		return nil, err
+	}
+	if dynamic.Sign() == 0 {
+		// the first block after fork starts with the governed price
+		return governed, nil
+	}
+	return block.CalcBaseGasPrice(dynamic, governed, parent.GasUsed(), parent.GasLimit()), nil
+}
+
+// UpdateBaseGasPrice is the before process hook of DYNAMIC_FEE, it saves the base gas price
+// of the block next to parent into state.
+func UpdateBaseGasPrice(state *state.State, parent *block.Header, forkConfig luckyshare.ForkConfig) error {
+	if parent.Number()+1 < forkConfig.DYNAMIC_FEE {
+		return nil
+	}
+	next, err := NextBaseGasPrice(state, parent, forkConfig)
+	if err != nil {
+		return err
+	}
+	return sharer.Params.Native(state).Set(luckyshare.KeyDynamicBaseGasPrice, next)
+}
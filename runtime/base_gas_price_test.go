@@ -0,0 +1,53 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package runtime
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/muxdb"
+	sharer "github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaseGasPrice(t *testing.T) {
+	db := muxdb.NewMem()
+	st := state.New(db, luckyshare.Bytes32{})
+	sharer.Params.Native(st).Set(luckyshare.KeyBaseGasPrice, big.NewInt(1000))
+
+	// block #1, fully used
+	parent := new(block.Builder).GasLimit(10000).GasUsed(10000).Build().Header()
+
+	forkConfig := luckyshare.NoFork
+	forkConfig.DYNAMIC_FEE = 3
+
+	// before fork
+	assert.Nil(t, UpdateBaseGasPrice(st, parent, forkConfig))
+	bgp, err := BaseGasPrice(st)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(1000), bgp)
+
+	forkConfig.DYNAMIC_FEE = 2
+
+	// the first block after fork starts with the governed price
+	assert.Nil(t, UpdateBaseGasPrice(st, parent, forkConfig))
+	bgp, err = BaseGasPrice(st)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(1000), bgp)
+
+	next, err := NextBaseGasPrice(st, parent, forkConfig)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(1125), next)
+
+	assert.Nil(t, UpdateBaseGasPrice(st, parent, forkConfig))
+	bgp, err = BaseGasPrice(st)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(1125), bgp)
+}
@@ -99,7 +99,7 @@ func (r *ResolvedTransaction) BuyGas(state *state.State, blockTime uint64) (
 	returnGas func(uint64) error,
 	err error,
 ) {
-	if baseGasPrice, err = sharer.Params.Native(state).Get(luckyshare.KeyBaseGasPrice); err != nil {
+	if baseGasPrice, err = BaseGasPrice(state); err != nil {
 		return
 	}
 	gasPrice = r.tx.GasPrice(baseGasPrice)
@@ -28,6 +28,7 @@ import (
 var (
 	energyTransferEvent     *abi.Event
 	prototypeSetMasterEvent *abi.Event
+	schedulerExecutedEvent  *abi.Event
 	nativeCallReturnGas     uint64 = 1562 // see test case for calculation
 )
 
@@ -39,21 +40,30 @@ func init() {
 	if prototypeSetMasterEvent, found = sharer.Prototype.Events().EventByName("$Master"); !found {
 		panic("$Master event not found")
 	}
+	if schedulerExecutedEvent, found = sharer.Scheduler.ABI.EventByName("Executed"); !found {
+		panic("executed event not found")
+	}
 }
 
-var baseChainConfig = params.ChainConfig{
-	ChainID:             big.NewInt(0),
-	HomesteadBlock:      big.NewInt(0),
-	DAOForkBlock:        big.NewInt(0),
-	DAOForkSupport:      false,
-	EIP150Block:         big.NewInt(0),
-	EIP150Hash:          common.Hash{},
-	EIP155Block:         big.NewInt(0),
-	EIP158Block:         big.NewInt(0),
-	ByzantiumBlock:      big.NewInt(0),
-	ConstantinopleBlock: nil,
-	Ethash:              nil,
-	Clique:              nil,
+var baseChainConfig = vm.ChainConfig{
+	ChainConfig: params.ChainConfig{
+		ChainID:             big.NewInt(0),
+		HomesteadBlock:      big.NewInt(0),
+		DAOForkBlock:        big.NewInt(0),
+		DAOForkSupport:      false,
+		EIP150Block:         big.NewInt(0),
+		EIP150Hash:          common.Hash{},
+		EIP155Block:         big.NewInt(0),
+		EIP158Block:         big.NewInt(0),
+		ByzantiumBlock:      big.NewInt(0),
+		ConstantinopleBlock: nil,
+		Ethash:              nil,
+		Clique:              nil,
+	},
+	IstanbulBlock: nil,
+	BerlinBlock:   nil,
+	ShanghaiBlock: nil,
+	PragueBlock:   nil,
 }
 
 // Output output of clause execution.
@@ -80,10 +90,13 @@ type Runtime struct {
 	state       *state.State
 	ctx         *xenv.BlockContext
 	forkConfig  luckyshare.ForkConfig
-	chainConfig params.ChainConfig
+	chainConfig vm.ChainConfig
 }
 
 // New create a Runtime object.
+// The chain ID returned by CHAINID is the genesis ID of the chain. The chain is nil only when
+// building the genesis block, whose ID is determined by the result, so the chain ID is 0 then.
+// No fork is active at that moment, so CHAINID is not available anyway.
 func New(
 	chain *chain.Chain,
 	state *state.State,
@@ -92,6 +105,14 @@ func New(
 ) *Runtime {
 	currentChainConfig := baseChainConfig
 	currentChainConfig.ConstantinopleBlock = big.NewInt(int64(forkConfig.ETH_CONST))
+	currentChainConfig.IstanbulBlock = big.NewInt(int64(forkConfig.ETH_IST))
+	currentChainConfig.BerlinBlock = big.NewInt(int64(forkConfig.ETH_BERLIN))
+	currentChainConfig.ShanghaiBlock = big.NewInt(int64(forkConfig.ETH_SHANGHAI))
+	currentChainConfig.PragueBlock = big.NewInt(int64(forkConfig.ETH_PRAGUE))
+	if chain != nil {
+		// use genesis id as chain id
+		currentChainConfig.ChainID = new(big.Int).SetBytes(chain.GenesisID().Bytes())
+	}
 	rt := Runtime{
 		chain:       chain,
 		state:       state,
@@ -161,6 +182,20 @@ func (rt *Runtime) newEVM(stateDB *statedb.StateDB, clauseIndex uint32, txCtx *x
 			return common.Address(luckyshare.CreateContractAddress(txCtx.ID, clauseIndex, counter))
 		},
 		InterceptContractCall: func(evm *vm.EVM, contract *vm.Contract, readonly bool) ([]byte, error, bool) {
+			if addr := luckyshare.Address(contract.Address()); rt.isNativeContract(addr) {
+				// the contract is implemented natively, serve calls to it directly
+				abi, run, found := sharer.FindNativeCall(addr, contract.Input)
+				if !found {
+					// let the placeholder code revert
+					return nil, nil, false
+				}
+				if readonly && !abi.Const() {
+					return nil, vm.ErrExecutionReverted, true
+				}
+				ret, err := xenv.New(abi, rt.chain, rt.state, rt.ctx, txCtx, evm, contract, &rt.forkConfig).Call(run)
+				return ret, err, true
+			}
+
 			if evm.Depth() < 2 {
 				lastNonNativeCallGas = contract.Gas
 				// skip direct calls
@@ -195,7 +230,7 @@ func (rt *Runtime) newEVM(stateDB *statedb.StateDB, clauseIndex uint32, txCtx *x
 				panic("serious bug: native call returned gas over consumed")
 			}
 
-			ret, err := xenv.New(abi, rt.chain, rt.state, rt.ctx, txCtx, evm, contract).Call(run)
+			ret, err := xenv.New(abi, rt.chain, rt.state, rt.ctx, txCtx, evm, contract, &rt.forkConfig).Call(run)
 			return ret, err, true
 		},
 		OnCreateContract: func(_ *vm.EVM, contractAddr, caller common.Address) {
@@ -274,6 +309,18 @@ func (rt *Runtime) newEVM(stateDB *statedb.StateDB, clauseIndex uint32, txCtx *x
 	}, stateDB, &rt.chainConfig, rt.vmConfig)
 }
 
+// isNativeContract returns whether the sharer contract at addr has all methods implemented natively,
+// and is activated.
+func (rt *Runtime) isNativeContract(addr luckyshare.Address) bool {
+	switch addr {
+	case sharer.Staker.Address:
+		return rt.ctx.Number >= rt.forkConfig.STAKING
+	case sharer.Scheduler.Address:
+		return rt.ctx.Number >= rt.forkConfig.SCHEDULER
+	}
+	return false
+}
+
 // PrepareClause prepare to execute clause.
 // It allows to interrupt execution.
 func (rt *Runtime) PrepareClause(
@@ -281,9 +328,38 @@ func (rt *Runtime) PrepareClause(
 	clauseIndex uint32,
 	gas uint64,
 	txCtx *xenv.TransactionContext,
+) (exec func() (output *Output, interrupted bool, err error), interrupt func()) {
+	return rt.prepareClause(clause, clauseIndex, gas, txCtx, statedb.NewTransientStorage(), statedb.NewOriginalStorage())
+}
+
+// PrepareClauses returns a function to prepare clauses of the same tx context in order.
+// Clauses prepared by it share the transient storage and original storage values, as they do in ExecuteTransaction.
+func (rt *Runtime) PrepareClauses(txCtx *xenv.TransactionContext) func(
+	clause *tx.Clause,
+	clauseIndex uint32,
+	gas uint64,
+) (exec func() (output *Output, interrupted bool, err error), interrupt func()) {
+	var (
+		transient = statedb.NewTransientStorage()
+		original  = statedb.NewOriginalStorage()
+	)
+	return func(clause *tx.Clause, clauseIndex uint32, gas uint64) (func() (*Output, bool, error), func()) {
+		return rt.prepareClause(clause, clauseIndex, gas, txCtx, transient, original)
+	}
+}
+
+// prepareClause prepare to execute clause with the transient storage and original storage values
+// shared by clauses of the same transaction.
+func (rt *Runtime) prepareClause(
+	clause *tx.Clause,
+	clauseIndex uint32,
+	gas uint64,
+	txCtx *xenv.TransactionContext,
+	transient statedb.TransientStorage,
+	original statedb.OriginalStorage,
 ) (exec func() (output *Output, interrupted bool, err error), interrupt func()) {
 	var (
-		stateDB       = statedb.New(rt.state)
+		stateDB       = statedb.NewWithTxStorage(rt.state, transient, original)
 		evm           = rt.newEVM(stateDB, clauseIndex, txCtx)
 		data          []byte
 		leftOverGas   uint64
@@ -300,6 +376,17 @@ func (rt *Runtime) PrepareClause(
 			}
 		}()
 
+		if rt.chainConfig.IsBerlin(evm.BlockNumber) {
+			// EIP-2929: origin, target and precompiles are always warm
+			stateDB.AddAddressToAccessList(common.Address(txCtx.Origin))
+			if clause.To() != nil {
+				stateDB.AddAddressToAccessList(common.Address(*clause.To()))
+			}
+			for addr := range vm.ActivePrecompiles(rt.chainConfig.Rules(evm.BlockNumber)) {
+				stateDB.AddAddressToAccessList(addr)
+			}
+		}
+
 		if clause.To() == nil {
 			var caddr common.Address
 			data, caddr, leftOverGas, vmErr = evm.Create(vm.AccountRef(txCtx.Origin), clause.Data(), gas, clause.Value())
@@ -308,6 +395,9 @@ func (rt *Runtime) PrepareClause(
 			data, leftOverGas, vmErr = evm.Call(vm.AccountRef(txCtx.Origin), common.Address(*clause.To()), clause.Data(), gas, clause.Value())
 		}
 
+		// EIP-1153: make transient storage changes visible to following clauses
+		stateDB.CommitTransientStorage()
+
 		interrupted = atomic.LoadUint32(&interruptFlag) != 0
 		output = &Output{
 			Data:            data,
@@ -363,6 +453,9 @@ func (rt *Runtime) PrepareTransaction(tx *tx.Transaction) (*TransactionExecutor,
 	leftOverGas := tx.Gas() - resolvedTx.IntrinsicGas
 	// checkpoint to be reverted when clause failure.
 	checkpoint := rt.state.NewCheckpoint()
+	// transient storage and original storage values live through all clauses of the tx.
+	transient := statedb.NewTransientStorage()
+	original := statedb.NewOriginalStorage()
 
 	txOutputs := make([]*Tx.Output, 0, len(resolvedTx.Clauses))
 	reverted := false
@@ -376,7 +469,7 @@ func (rt *Runtime) PrepareTransaction(tx *tx.Transaction) (*TransactionExecutor,
 		HasNextClause: hasNext,
 		NextClause: func() (gasUsed uint64, output *Output, err error) {
 			nextClauseIndex := uint32(len(txOutputs))
-			exec, _ := rt.PrepareClause(resolvedTx.Clauses[nextClauseIndex], nextClauseIndex, leftOverGas, txCtx)
+			exec, _ := rt.prepareClause(resolvedTx.Clauses[nextClauseIndex], nextClauseIndex, leftOverGas, txCtx, transient, original)
 			output, _, err = exec()
 			if err != nil {
 				return 0, nil, err
@@ -427,26 +520,40 @@ func (rt *Runtime) PrepareTransaction(tx *tx.Transaction) (*TransactionExecutor,
 			}
 
 			// reward
-			rewardRatio, err := sharer.Params.Native(rt.state).Get(luckyshare.KeyRewardRatio)
-			if err != nil {
-				return nil, err
-			}
 			provedWork, err := tx.ProvedWork(rt.ctx.Number-1, rt.chain.GetBlockID)
 			if err != nil {
 				return nil, err
 			}
 			overallGasPrice := tx.OverallGasPrice(baseGasPrice, provedWork)
 
-			reward := new(big.Int).SetUint64(receipt.GasUsed)
-			reward.Mul(reward, overallGasPrice)
-			reward.Mul(reward, rewardRatio)
-			reward.Div(reward, big.NewInt(1e18))
-			if err := sharer.Energy.Native(rt.state, rt.ctx.Time).Add(rt.ctx.Beneficiary, reward); err != nil {
+			if receipt.Reward, err = rt.payReward(receipt.GasUsed, overallGasPrice); err != nil {
 				return nil, err
 			}
-
-			receipt.Reward = reward
 			return receipt, nil
 		},
 	}, nil
 }
+
+// payReward rewards the block beneficiary for the gas used, and returns the reward.
+func (rt *Runtime) payReward(gasUsed uint64, gasPrice *big.Int) (*big.Int, error) {
+	rewardRatio, err := sharer.Params.Native(rt.state).Get(luckyshare.KeyRewardRatio)
+	if err != nil {
+		return nil, err
+	}
+
+	reward := new(big.Int).SetUint64(gasUsed)
+	reward.Mul(reward, gasPrice)
+	reward.Mul(reward, rewardRatio)
+	reward.Div(reward, big.NewInt(1e18))
+
+	beneficiaryReward := reward
+	if rt.ctx.Number >= rt.forkConfig.STAKING {
+		if beneficiaryReward, err = rt.distributeReward(reward); err != nil {
+			return nil, err
+		}
+	}
+	if err := sharer.Energy.Native(rt.state, rt.ctx.Time).Add(rt.ctx.Beneficiary, beneficiaryReward); err != nil {
+		return nil, err
+	}
+	return reward, nil
+}
@@ -153,6 +153,51 @@ func TestCall(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestPrepareClauses(t *testing.T) {
+	db := muxdb.NewMem()
+
+	g := genesis.NewDevnet()
+	b0, _, _, err := g.Build(state.NewStater(db))
+	assert.Nil(t, err)
+
+	repo, _ := chain.NewRepository(db, b0)
+	state := state.New(db, b0.Header().StateRoot())
+
+	// increases the counter in transient slot 0 and returns it
+	addr := luckyshare.BytesToAddress([]byte("counter"))
+	code, _ := hex.DecodeString("60005c6001018060005d60005260206000f3")
+	state.SetCode(addr, code)
+
+	forkConfig := luckyshare.NoFork
+	forkConfig.ETH_CONST = 0
+	forkConfig.ETH_IST = 0
+	forkConfig.ETH_BERLIN = 0
+	forkConfig.ETH_SHANGHAI = 0
+	rt := runtime.New(repo.NewChain(b0.Header().ID()), state, &xenv.BlockContext{}, forkConfig)
+
+	counter := func(out *runtime.Output) uint64 {
+		return new(big.Int).SetBytes(out.Data).Uint64()
+	}
+
+	// clauses prepared separately don't share transient storage
+	for i := 0; i < 2; i++ {
+		exec, _ := rt.PrepareClause(tx.NewClause(&addr), uint32(i), 100000, &xenv.TransactionContext{})
+		out, _, err := exec()
+		assert.Nil(t, err)
+		assert.Nil(t, out.VMErr)
+		assert.Equal(t, uint64(1), counter(out))
+	}
+
+	prepare := rt.PrepareClauses(&xenv.TransactionContext{})
+	for i := 0; i < 2; i++ {
+		exec, _ := prepare(tx.NewClause(&addr), uint32(i), 100000)
+		out, _, err := exec()
+		assert.Nil(t, err)
+		assert.Nil(t, out.VMErr)
+		assert.Equal(t, uint64(i+1), counter(out))
+	}
+}
+
 func TestExecuteTransaction(t *testing.T) {
 
 	// kv, _ := lvldb.NewMem()
@@ -0,0 +1,224 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package runtime
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	sharer "github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/sharer/scheduler"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/miniBamboo/luckyshare/xenv"
+)
+
+// ActivateScheduler is the before process hook of SCHEDULER, it deploys the placeholder code of
+// Scheduler contract, whose methods are served natively since then.
+func ActivateScheduler(state *state.State, blockNum uint32, forkConfig luckyshare.ForkConfig) error {
+	fork := forkConfig.SCHEDULER
+	if fork == 0 {
+		fork = 1
+	}
+	if blockNum != fork {
+		return nil
+	}
+	return state.SetCode(sharer.Scheduler.Address, sharer.Scheduler.RuntimeBytecodes())
+}
+
+// ExecuteScheduled executes clauses scheduled at the current block. It should be called before
+// executing any tx of the block.
+// It returns one receipt for each execution, whose outputs always contain exactly one output,
+// which only has the `Executed` event of Scheduler contract if the clause reverted.
+// At most luckyshare.MaxScheduledPerBlock due schedules are taken out in a block, and executions exceeding
+// the gas quota of the block are postponed to the next block, except the first one. Postponed schedules
+// keep their due block number, and are executed ahead of those due at the next block.
+// Schedules whose energy can't pay for a run are dropped from the queue without execution.
+// A clause that cancels its own schedule pays for the gas with the refunded energy, or reverts if it can't.
+func (rt *Runtime) ExecuteScheduled() (tx.Receipts, error) {
+	if rt.ctx.Number < rt.forkConfig.SCHEDULER {
+		return nil, nil
+	}
+
+	gasPrice, err := BaseGasPrice(rt.state)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		receipts tx.Receipts
+		gasUsed  uint64
+		gasQuota = rt.ctx.GasLimit / luckyshare.ScheduledGasLimitDenominator
+	)
+	err = sharer.Scheduler.Native(rt.state).PopDue(rt.ctx.Number, luckyshare.MaxScheduledPerBlock, gasPrice,
+		func(id uint64, s *scheduler.Schedule) (bool, error) {
+			if gasUsed > 0 && gasUsed+s.Gas > gasQuota {
+				// the quota is used up, postpone the rest
+				return false, nil
+			}
+			receipt, err := rt.executeSchedule(id, s, tx.ScheduledID(rt.ctx.Number, len(receipts)), gasPrice)
+			if err != nil {
+				return false, err
+			}
+			gasUsed += receipt.GasUsed
+			receipts = append(receipts, receipt)
+			return true, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+func (rt *Runtime) executeSchedule(id uint64, s *scheduler.Schedule, execID luckyshare.Bytes32, gasPrice *big.Int) (*tx.Receipt, error) {
+	var (
+		energy    = sharer.Energy.Native(rt.state, rt.ctx.Time)
+		sch       = sharer.Scheduler.Native(rt.state)
+		output    = &tx.Output{}
+		reverted  = true
+		cancelled bool
+		gasUsed   uint64
+		paid      = &big.Int{}
+		remaining = s.Remaining - 1
+	)
+
+	if prepaid := new(big.Int).Mul(new(big.Int).SetUint64(s.Gas), gasPrice); s.Energy.Cmp(prepaid) >= 0 {
+		clause := tx.NewClause(&s.To).WithValue(s.Value).WithData(s.Data)
+		// the gas is ensured to be not less than intrinsic gas when scheduling
+		intrinsicGas, err := tx.IntrinsicGas(clause)
+		if err != nil {
+			return nil, err
+		}
+
+		checkpoint := rt.state.NewCheckpoint()
+		exec, _ := rt.PrepareClause(clause, 0, s.Gas-intrinsicGas, &xenv.TransactionContext{
+			ID:         execID,
+			Origin:     s.Owner,
+			GasPayer:   s.Owner,
+			GasPrice:   gasPrice,
+			ProvedWork: &big.Int{},
+			BlockRef:   tx.NewBlockRef(rt.ctx.Number),
+		})
+		out, _, err := exec()
+		if err != nil {
+			return nil, err
+		}
+
+		gasUsed = s.Gas - out.LeftOverGas
+		// apply refund counter, capped to half of the used gas.
+		refund := gasUsed / 2
+		if refund > out.RefundGas {
+			refund = out.RefundGas
+		}
+		gasUsed -= refund
+		paid.Mul(new(big.Int).SetUint64(gasUsed), gasPrice)
+
+		if out.VMErr == nil {
+			// the clause may call Scheduler to deposit to or cancel the schedule itself
+			cur, err := sch.Get(id)
+			if err != nil {
+				return nil, err
+			}
+			if !cur.IsEmpty() {
+				s, reverted = cur, false
+			} else if ok, err := energy.Sub(s.Owner, paid); err != nil {
+				return nil, err
+			} else if ok {
+				// cancelled, the energy left has been refunded to the owner, who pays for the gas then
+				cancelled, reverted = true, false
+			}
+		}
+
+		if reverted {
+			rt.state.RevertTo(checkpoint)
+		} else {
+			output.Events, output.Transfers = out.Events, out.Transfers
+		}
+	} else {
+		// unable to pay for the gas any more
+		remaining = 0
+	}
+
+	if cancelled {
+		remaining = 0
+	} else {
+		// gas is paid from the pre-funded energy held by Scheduler contract
+		if ok, err := energy.Sub(sharer.Scheduler.Address, paid); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, errors.New("insufficient energy for scheduled execution")
+		}
+		s.Energy = new(big.Int).Sub(s.Energy, paid)
+	}
+
+	reward, err := rt.payReward(gasUsed, gasPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cancelled:
+		// already removed by the clause
+	case remaining == 0:
+		// finished, refund the energy left
+		if s.Energy.Sign() > 0 {
+			if _, err := energy.Sub(sharer.Scheduler.Address, s.Energy); err != nil {
+				return nil, err
+			}
+			if err := energy.Add(s.Owner, s.Energy); err != nil {
+				return nil, err
+			}
+			data, err := energyTransferEvent.Encode(s.Energy)
+			if err != nil {
+				return nil, err
+			}
+			output.Events = append(output.Events, &tx.Event{
+				Address: sharer.Energy.Address,
+				Topics: []luckyshare.Bytes32{
+					energyTransferEvent.ID(),
+					luckyshare.BytesToBytes32(sharer.Scheduler.Address[:]),
+					luckyshare.BytesToBytes32(s.Owner[:]),
+				},
+				Data: data,
+			})
+		}
+		if err := sch.Set(id, &scheduler.Schedule{}); err != nil {
+			return nil, err
+		}
+	default:
+		s.Remaining = remaining
+		s.Next = rt.ctx.Number + s.Interval
+		if err := sch.Set(id, s); err != nil {
+			return nil, err
+		}
+		if err := sch.Enqueue(id, s.Next); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := schedulerExecutedEvent.Encode(remaining, reverted)
+	if err != nil {
+		return nil, err
+	}
+	output.Events = append(output.Events, &tx.Event{
+		Address: sharer.Scheduler.Address,
+		Topics: []luckyshare.Bytes32{
+			schedulerExecutedEvent.ID(),
+			luckyshare.BytesToBytes32(new(big.Int).SetUint64(id).Bytes()),
+		},
+		Data: data,
+	})
+
+	return &tx.Receipt{
+		GasUsed:  gasUsed,
+		GasPayer: s.Owner,
+		Paid:     paid,
+		Reward:   reward,
+		Reverted: reverted,
+		Outputs:  []*tx.Output{output},
+	}, nil
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package runtime
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/muxdb"
+	sharer "github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/sharer/scheduler"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/miniBamboo/luckyshare/xenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivateScheduler(t *testing.T) {
+	db := muxdb.NewMem()
+	st := state.New(db, luckyshare.Bytes32{})
+
+	forkConfig := luckyshare.NoFork
+	forkConfig.SCHEDULER = 2
+
+	assert.Nil(t, ActivateScheduler(st, 1, forkConfig))
+	code, err := st.GetCode(sharer.Scheduler.Address)
+	assert.Nil(t, err)
+	assert.Empty(t, code)
+
+	assert.Nil(t, ActivateScheduler(st, 2, forkConfig))
+	code, err = st.GetCode(sharer.Scheduler.Address)
+	assert.Nil(t, err)
+	assert.Equal(t, sharer.Scheduler.RuntimeBytecodes(), code)
+}
+
+func TestExecuteScheduled(t *testing.T) {
+	db := muxdb.NewMem()
+	st := state.New(db, luckyshare.Bytes32{})
+
+	owner := luckyshare.BytesToAddress([]byte("owner"))
+	recipient := luckyshare.BytesToAddress([]byte("recipient"))
+	beneficiary := luckyshare.BytesToAddress([]byte("beneficiary"))
+
+	sharer.Params.Native(st).Set(luckyshare.KeyBaseGasPrice, big.NewInt(1))
+	sharer.Params.Native(st).Set(luckyshare.KeyRewardRatio, big.NewInt(3e17))
+	st.SetBalance(owner, big.NewInt(100))
+	sharer.Energy.Native(st, 0).Add(sharer.Scheduler.Address, big.NewInt(100000))
+
+	sch := sharer.Scheduler.Native(st)
+	id, err := sch.Add(&scheduler.Schedule{
+		Owner:     owner,
+		To:        recipient,
+		Value:     big.NewInt(10),
+		Data:      []byte{},
+		Gas:       21000,
+		Next:      1,
+		Interval:  2,
+		Remaining: 2,
+		Energy:    big.NewInt(100000),
+	})
+	assert.Nil(t, err)
+
+	forkConfig := luckyshare.NoFork
+	forkConfig.SCHEDULER = 0
+	newRuntime := func(num uint32) *Runtime {
+		return New(nil, st, &xenv.BlockContext{
+			Beneficiary: beneficiary,
+			Number:      num,
+			GasLimit:    luckyshare.InitialGasLimit,
+		}, forkConfig)
+	}
+
+	// the first execution
+	receipts, err := newRuntime(1).ExecuteScheduled()
+	assert.Nil(t, err)
+	assert.Len(t, receipts, 1)
+	assert.Equal(t, uint64(21000), receipts[0].GasUsed)
+	assert.Equal(t, owner, receipts[0].GasPayer)
+	assert.False(t, receipts[0].Reverted)
+	assert.Equal(t, tx.Transfers{{Sender: owner, Recipient: recipient, Amount: big.NewInt(10)}}, receipts[0].Outputs[0].Transfers)
+	v, _ := st.GetBalance(recipient)
+	assert.Equal(t, big.NewInt(10), v)
+	v, _ = st.GetEnergy(beneficiary, 0)
+	assert.Equal(t, big.NewInt(6300), v)
+
+	s, err := sch.Get(id)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(3), s.Next)
+	assert.Equal(t, uint32(1), s.Remaining)
+	assert.Equal(t, big.NewInt(79000), s.Energy)
+
+	// not due
+	receipts, err = newRuntime(2).ExecuteScheduled()
+	assert.Nil(t, err)
+	assert.Len(t, receipts, 0)
+
+	// the last execution, energy left refunded
+	receipts, err = newRuntime(3).ExecuteScheduled()
+	assert.Nil(t, err)
+	assert.Len(t, receipts, 1)
+	v, _ = st.GetBalance(recipient)
+	assert.Equal(t, big.NewInt(20), v)
+	v, _ = st.GetEnergy(owner, 0)
+	assert.Equal(t, big.NewInt(58000), v)
+	v, _ = st.GetEnergy(sharer.Scheduler.Address, 0)
+	assert.Equal(t, &big.Int{}, v)
+
+	s, err = sch.Get(id)
+	assert.Nil(t, err)
+	assert.True(t, s.IsEmpty())
+}
+
+func TestExecuteScheduledCancelItself(t *testing.T) {
+	db := muxdb.NewMem()
+	st := state.New(db, luckyshare.Bytes32{})
+
+	owner := luckyshare.BytesToAddress([]byte("owner"))
+	beneficiary := luckyshare.BytesToAddress([]byte("beneficiary"))
+
+	sharer.Params.Native(st).Set(luckyshare.KeyBaseGasPrice, big.NewInt(1))
+	sharer.Params.Native(st).Set(luckyshare.KeyRewardRatio, big.NewInt(3e17))
+	// the only energy held by Scheduler
+	sharer.Energy.Native(st, 0).Add(sharer.Scheduler.Address, big.NewInt(100000))
+
+	sch := sharer.Scheduler.Native(st)
+	cancel, _ := sharer.Scheduler.ABI.MethodByName("cancel")
+	// the id to be assigned
+	data, err := cancel.EncodeInput(big.NewInt(1))
+	assert.Nil(t, err)
+	id, err := sch.Add(&scheduler.Schedule{
+		Owner:     owner,
+		To:        sharer.Scheduler.Address,
+		Value:     &big.Int{},
+		Data:      data,
+		Gas:       50000,
+		Next:      1,
+		Interval:  1,
+		Remaining: 2,
+		Energy:    big.NewInt(100000),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), id)
+
+	forkConfig := luckyshare.NoFork
+	forkConfig.SCHEDULER = 0
+	receipts, err := New(nil, st, &xenv.BlockContext{
+		Beneficiary: beneficiary,
+		Number:      1,
+		GasLimit:    luckyshare.InitialGasLimit,
+	}, forkConfig).ExecuteScheduled()
+	assert.Nil(t, err)
+	assert.Len(t, receipts, 1)
+	assert.False(t, receipts[0].Reverted)
+
+	// paid by the owner out of the refunded energy, and never refunded twice
+	v, _ := st.GetEnergy(owner, 0)
+	assert.Equal(t, new(big.Int).Sub(big.NewInt(100000), receipts[0].Paid), v)
+	v, _ = st.GetEnergy(sharer.Scheduler.Address, 0)
+	assert.Equal(t, &big.Int{}, v)
+
+	// not brought back
+	s, err := sch.Get(id)
+	assert.Nil(t, err)
+	assert.True(t, s.IsEmpty())
+	queued, err := sch.Queued(2)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), queued)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package runtime
+
+import (
+	"math/big"
+
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	sharer "github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/state"
+)
+
+// ActivateStaking is the before process hook of STAKING, it deploys the placeholder code of
+// Staker contract, whose methods are served natively since then.
+func ActivateStaking(state *state.State, blockNum uint32, forkConfig luckyshare.ForkConfig) error {
+	staking := forkConfig.STAKING
+	if staking == 0 {
+		staking = 1
+	}
+	if blockNum != staking {
+		return nil
+	}
+	return state.SetCode(sharer.Staker.Address, sharer.Staker.RuntimeBytecodes())
+}
+
+// distributeReward shares the reward among delegators of the block signer's endorsor, in proportion
+// of the delegated stake to the whole stake (delegated plus endorsor's balance).
+// The delegators' share is kept by Staker contract, and the part left for beneficiary is returned.
+func (rt *Runtime) distributeReward(reward *big.Int) (*big.Int, error) {
+	if reward.Sign() == 0 {
+		return reward, nil
+	}
+	listed, endorsor, _, _, err := sharer.Authority.Native(rt.state).Get(rt.ctx.Signer)
+	if err != nil {
+		return nil, err
+	}
+	if !listed {
+		return reward, nil
+	}
+
+	staker := sharer.Staker.Native(rt.state)
+	delegated, err := staker.Delegated(endorsor)
+	if err != nil {
+		return nil, err
+	}
+	if delegated.Sign() == 0 {
+		return reward, nil
+	}
+	bal, err := rt.state.GetBalance(endorsor)
+	if err != nil {
+		return nil, err
+	}
+
+	share := new(big.Int).Mul(reward, delegated)
+	share.Div(share, new(big.Int).Add(delegated, bal))
+	if share.Sign() == 0 {
+		return reward, nil
+	}
+	if _, err := staker.Distribute(endorsor, share); err != nil {
+		return nil, err
+	}
+	if err := sharer.Energy.Native(rt.state, rt.ctx.Time).Add(sharer.Staker.Address, share); err != nil {
+		return nil, err
+	}
+	return new(big.Int).Sub(reward, share), nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package runtime
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/muxdb"
+	sharer "github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/miniBamboo/luckyshare/xenv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActivateStaking(t *testing.T) {
+	db := muxdb.NewMem()
+	st := state.New(db, luckyshare.Bytes32{})
+
+	forkConfig := luckyshare.NoFork
+	forkConfig.STAKING = 2
+
+	assert.Nil(t, ActivateStaking(st, 1, forkConfig))
+	code, err := st.GetCode(sharer.Staker.Address)
+	assert.Nil(t, err)
+	assert.Empty(t, code)
+
+	assert.Nil(t, ActivateStaking(st, 2, forkConfig))
+	code, err = st.GetCode(sharer.Staker.Address)
+	assert.Nil(t, err)
+	assert.Equal(t, sharer.Staker.RuntimeBytecodes(), code)
+}
+
+func TestDistributeReward(t *testing.T) {
+	db := muxdb.NewMem()
+	st := state.New(db, luckyshare.Bytes32{})
+
+	master := luckyshare.BytesToAddress([]byte("master"))
+	endorsor := luckyshare.BytesToAddress([]byte("endorsor"))
+	delegator := luckyshare.BytesToAddress([]byte("delegator"))
+
+	rt := New(nil, st, &xenv.BlockContext{Signer: master}, luckyshare.NoFork)
+
+	// signer not listed
+	left, err := rt.distributeReward(big.NewInt(1000))
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(1000), left)
+
+	sharer.Authority.Native(st).Add(master, endorsor, luckyshare.Bytes32{})
+	st.SetBalance(endorsor, big.NewInt(300))
+
+	// nothing delegated
+	left, err = rt.distributeReward(big.NewInt(1000))
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(1000), left)
+
+	sharer.Staker.Native(st).Delegate(delegator, endorsor, big.NewInt(100))
+
+	left, err = rt.distributeReward(big.NewInt(1000))
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(750), left)
+
+	amount, reward, err := sharer.Staker.Native(st).Get(delegator, endorsor)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(100), amount)
+	assert.Equal(t, big.NewInt(250), reward)
+
+	eng, err := st.GetEnergy(sharer.Staker.Address, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, big.NewInt(250), eng)
+}
@@ -22,27 +22,99 @@ var codeSizeCache, _ = lru.New(32 * 1024)
 
 // StateDB implements evm.StateDB, only adapt to evm.
 type StateDB struct {
-	state *state.State
-	repo  *stackedmap.StackedMap
+	state     *state.State
+	repo      *stackedmap.StackedMap
+	transient TransientStorage
+	original  OriginalStorage
+}
+
+// TransientStorage holds EIP-1153 transient storage values.
+// It lives through all clauses of a transaction, and is discarded after the transaction.
+type TransientStorage map[common.Address]map[common.Hash]common.Hash
+
+// NewTransientStorage create an empty transient storage.
+func NewTransientStorage() TransientStorage {
+	return make(TransientStorage)
+}
+
+// Get returns the value of the given slot.
+func (t TransientStorage) Get(addr common.Address, key common.Hash) common.Hash {
+	return t[addr][key]
+}
+
+// Set sets the value of the given slot.
+func (t TransientStorage) Set(addr common.Address, key, value common.Hash) {
+	slots, ok := t[addr]
+	if !ok {
+		slots = make(map[common.Hash]common.Hash)
+		t[addr] = slots
+	}
+	slots[key] = value
+}
+
+// OriginalStorage holds values of storage slots before being modified in a transaction,
+// which are the original values of EIP-2200 net gas metering.
+// It lives through all clauses of a transaction, like TransientStorage.
+// Values are recorded on the first modification by SSTORE, so slots modified only by
+// native calls in previous clauses take the value of that moment as the original value.
+type OriginalStorage map[common.Address]map[common.Hash]common.Hash
+
+// NewOriginalStorage create an empty original storage.
+func NewOriginalStorage() OriginalStorage {
+	return make(OriginalStorage)
+}
+
+// Get returns the original value of the given slot, and whether it's recorded.
+func (o OriginalStorage) Get(addr common.Address, key common.Hash) (common.Hash, bool) {
+	v, ok := o[addr][key]
+	return v, ok
+}
+
+// Set records the original value of the given slot.
+func (o OriginalStorage) Set(addr common.Address, key, value common.Hash) {
+	slots, ok := o[addr]
+	if !ok {
+		slots = make(map[common.Hash]common.Hash)
+		o[addr] = slots
+	}
+	slots[key] = value
 }
 
 type (
-	suicideFlagKey common.Address
-	refundKey      struct{}
-	preimageKey    common.Hash
-	eventKey       struct{}
-	transferKey    struct{}
-	stateRevKey    struct{}
+	suicideFlagKey   common.Address
+	refundKey        struct{}
+	preimageKey      common.Hash
+	eventKey         struct{}
+	transferKey      struct{}
+	stateRevKey      struct{}
+	accessAddressKey common.Address
+	accessSlotKey    struct {
+		addr common.Address
+		slot common.Hash
+	}
+	transientStorageKey struct {
+		addr common.Address
+		key  common.Hash
+	}
 )
 
 // New create a statedb object.
 func New(state *state.State) *StateDB {
+	return NewWithTxStorage(state, NewTransientStorage(), NewOriginalStorage())
+}
+
+// NewWithTxStorage create a statedb object with storages living through all clauses of a transaction.
+// Transient storage changes are written back only when CommitTransientStorage is called, while
+// original values are recorded at once, since they are never changed by reverts.
+func NewWithTxStorage(state *state.State, transient TransientStorage, original OriginalStorage) *StateDB {
 	getter := func(k interface{}) (interface{}, bool, error) {
-		switch k.(type) {
-		case suicideFlagKey:
+		switch key := k.(type) {
+		case suicideFlagKey, accessAddressKey, accessSlotKey:
 			return false, true, nil
 		case refundKey:
 			return uint64(0), true, nil
+		case transientStorageKey:
+			return transient.Get(key.addr, key.key), true, nil
 		}
 		panic(fmt.Sprintf("unknown type of key %+v", k))
 	}
@@ -51,6 +123,8 @@ func New(state *state.State) *StateDB {
 	return &StateDB{
 		state,
 		repo,
+		transient,
+		original,
 	}
 }
 
@@ -202,6 +276,14 @@ func (s *StateDB) Suicide(addr common.Address) bool {
 	return true
 }
 
+// GetCommittedState returns the value of storage slot before being modified in the transaction.
+func (s *StateDB) GetCommittedState(addr common.Address, key common.Hash) common.Hash {
+	if v, ok := s.original.Get(addr, key); ok {
+		return v
+	}
+	return s.GetState(addr, key)
+}
+
 // GetState stub.
 func (s *StateDB) GetState(addr common.Address, key common.Hash) common.Hash {
 	val, err := s.state.GetStorage(luckyshare.Address(addr), luckyshare.Bytes32(key))
@@ -213,9 +295,36 @@ func (s *StateDB) GetState(addr common.Address, key common.Hash) common.Hash {
 
 // SetState stub.
 func (s *StateDB) SetState(addr common.Address, key, value common.Hash) {
+	// keep the value before the first modification
+	if _, ok := s.original.Get(addr, key); !ok {
+		s.original.Set(addr, key, s.GetState(addr, key))
+	}
 	s.state.SetStorage(luckyshare.Address(addr), luckyshare.Bytes32(key), luckyshare.Bytes32(value))
 }
 
+// GetTransientState returns the value of transient storage slot.
+func (s *StateDB) GetTransientState(addr common.Address, key common.Hash) common.Hash {
+	v, _, _ := s.repo.Get(transientStorageKey{addr, key})
+	return v.(common.Hash)
+}
+
+// SetTransientState sets the value of transient storage slot.
+// Changes are journaled, and reverted along with snapshots.
+func (s *StateDB) SetTransientState(addr common.Address, key, value common.Hash) {
+	s.repo.Put(transientStorageKey{addr, key}, value)
+}
+
+// CommitTransientStorage writes unreverted transient storage changes back into
+// the transient storage, to make them visible to following clauses.
+func (s *StateDB) CommitTransientStorage() {
+	s.repo.Journal(func(k, v interface{}) bool {
+		if key, ok := k.(transientStorageKey); ok {
+			s.transient.Set(key.addr, key.key, v.(common.Hash))
+		}
+		return true
+	})
+}
+
 // Exist stub.
 func (s *StateDB) Exist(addr common.Address) bool {
 	b, err := s.state.Exists(luckyshare.Address(addr))
@@ -237,6 +346,45 @@ func (s *StateDB) AddRefund(gas uint64) {
 	s.repo.Put(refundKey{}, total)
 }
 
+// SubRefund stub.
+func (s *StateDB) SubRefund(gas uint64) {
+	v, _, _ := s.repo.Get(refundKey{})
+	total := v.(uint64)
+	if gas > total {
+		panic(fmt.Errorf("refund counter below zero (gas: %d > refund: %d)", gas, total))
+	}
+	s.repo.Put(refundKey{}, total-gas)
+}
+
+// AddressInAccessList returns true if the given address is in the access list.
+func (s *StateDB) AddressInAccessList(addr common.Address) bool {
+	v, _, _ := s.repo.Get(accessAddressKey(addr))
+	return v.(bool)
+}
+
+// SlotInAccessList returns true if the given (address, slot)-tuple is in the access list.
+func (s *StateDB) SlotInAccessList(addr common.Address, slot common.Hash) (addressOk bool, slotOk bool) {
+	v, _, _ := s.repo.Get(accessSlotKey{addr, slot})
+	return s.AddressInAccessList(addr), v.(bool)
+}
+
+// AddAddressToAccessList adds the given address to the access list.
+// Changes are journaled, and reverted along with snapshots.
+func (s *StateDB) AddAddressToAccessList(addr common.Address) {
+	if !s.AddressInAccessList(addr) {
+		s.repo.Put(accessAddressKey(addr), true)
+	}
+}
+
+// AddSlotToAccessList adds the given (address, slot)-tuple to the access list.
+// Changes are journaled, and reverted along with snapshots.
+func (s *StateDB) AddSlotToAccessList(addr common.Address, slot common.Hash) {
+	s.AddAddressToAccessList(addr)
+	if _, ok := s.SlotInAccessList(addr, slot); !ok {
+		s.repo.Put(accessSlotKey{addr, slot}, true)
+	}
+}
+
 // AddPreimage stub.
 func (s *StateDB) AddPreimage(hash common.Hash, preimage []byte) {
 	s.repo.Put(preimageKey(hash), preimage)
@@ -36,6 +36,124 @@ func TestSnapshotRandom(t *testing.T) {
 	}
 }
 
+func TestCommittedStateAndAccessList(t *testing.T) {
+	var (
+		db      = muxdb.NewMem()
+		state   = State.New(db, luckyshare.Bytes32{})
+		addr    = common.BytesToAddress([]byte("addr"))
+		key     = common.BytesToHash([]byte("key"))
+		v1      = common.BytesToHash([]byte("v1"))
+		v2      = common.BytesToHash([]byte("v2"))
+		stateDB *statedb.StateDB
+	)
+	state.SetStorage(luckyshare.Address(addr), luckyshare.Bytes32(key), luckyshare.Bytes32(v1))
+	stateDB = statedb.New(state)
+
+	rev := stateDB.Snapshot()
+	stateDB.SetState(addr, key, v2)
+	stateDB.SetState(addr, key, common.Hash{})
+	if got := stateDB.GetCommittedState(addr, key); got != v1 {
+		t.Errorf("got GetCommittedState() == %v, want %v", got, v1)
+	}
+	if got := stateDB.GetState(addr, key); got != (common.Hash{}) {
+		t.Errorf("got GetState() == %v, want empty", got)
+	}
+
+	stateDB.AddSlotToAccessList(addr, key)
+	if addrOk, slotOk := stateDB.SlotInAccessList(addr, key); !addrOk || !slotOk {
+		t.Errorf("got SlotInAccessList() == (%v, %v), want (true, true)", addrOk, slotOk)
+	}
+	stateDB.AddRefund(10)
+	stateDB.SubRefund(4)
+	if got := stateDB.GetRefund(); got != 6 {
+		t.Errorf("got GetRefund() == %d, want 6", got)
+	}
+
+	stateDB.RevertToSnapshot(rev)
+	if stateDB.AddressInAccessList(addr) {
+		t.Error("address should be removed from access list after revert")
+	}
+	if got := stateDB.GetState(addr, key); got != v1 {
+		t.Errorf("got GetState() == %v, want %v", got, v1)
+	}
+	if got := stateDB.GetRefund(); got != 0 {
+		t.Errorf("got GetRefund() == %d, want 0", got)
+	}
+}
+
+func TestTransientStorage(t *testing.T) {
+	var (
+		db        = muxdb.NewMem()
+		state     = State.New(db, luckyshare.Bytes32{})
+		addr      = common.BytesToAddress([]byte("addr"))
+		key       = common.BytesToHash([]byte("key"))
+		v1        = common.BytesToHash([]byte("v1"))
+		v2        = common.BytesToHash([]byte("v2"))
+		transient = statedb.NewTransientStorage()
+	)
+	// clause 1
+	stateDB := statedb.NewWithTxStorage(state, transient, statedb.NewOriginalStorage())
+	stateDB.SetTransientState(addr, key, v1)
+
+	rev := stateDB.Snapshot()
+	stateDB.SetTransientState(addr, key, v2)
+	if got := stateDB.GetTransientState(addr, key); got != v2 {
+		t.Errorf("got GetTransientState() == %v, want %v", got, v2)
+	}
+	stateDB.RevertToSnapshot(rev)
+	if got := stateDB.GetTransientState(addr, key); got != v1 {
+		t.Errorf("got GetTransientState() == %v, want %v", got, v1)
+	}
+	// transient storage is never persisted
+	if got, _ := state.GetStorage(luckyshare.Address(addr), luckyshare.Bytes32(key)); !got.IsZero() {
+		t.Errorf("got GetStorage() == %v, want empty", got)
+	}
+	stateDB.CommitTransientStorage()
+
+	// clause 2 of the same tx
+	stateDB = statedb.NewWithTxStorage(state, transient, statedb.NewOriginalStorage())
+	if got := stateDB.GetTransientState(addr, key); got != v1 {
+		t.Errorf("got GetTransientState() == %v, want %v", got, v1)
+	}
+
+	// another tx
+	stateDB = statedb.New(state)
+	if got := stateDB.GetTransientState(addr, key); got != (common.Hash{}) {
+		t.Errorf("got GetTransientState() == %v, want empty", got)
+	}
+}
+
+func TestOriginalStorage(t *testing.T) {
+	var (
+		db       = muxdb.NewMem()
+		state    = State.New(db, luckyshare.Bytes32{})
+		addr     = common.BytesToAddress([]byte("addr"))
+		key      = common.BytesToHash([]byte("key"))
+		v1       = common.BytesToHash([]byte("v1"))
+		v2       = common.BytesToHash([]byte("v2"))
+		v3       = common.BytesToHash([]byte("v3"))
+		original = statedb.NewOriginalStorage()
+	)
+	state.SetStorage(luckyshare.Address(addr), luckyshare.Bytes32(key), luckyshare.Bytes32(v1))
+
+	// clause 1
+	stateDB := statedb.NewWithTxStorage(state, statedb.NewTransientStorage(), original)
+	stateDB.SetState(addr, key, v2)
+
+	// clause 2 of the same tx sees the value before the tx
+	stateDB = statedb.NewWithTxStorage(state, statedb.NewTransientStorage(), original)
+	stateDB.SetState(addr, key, v3)
+	if got := stateDB.GetCommittedState(addr, key); got != v1 {
+		t.Errorf("got GetCommittedState() == %v, want %v", got, v1)
+	}
+
+	// another tx
+	stateDB = statedb.New(state)
+	if got := stateDB.GetCommittedState(addr, key); got != v3 {
+		t.Errorf("got GetCommittedState() == %v, want %v", got, v3)
+	}
+}
+
 // A snapshotTest checks that reverting StateDB snapshots properly undoes all changes
 // captured by the snapshot. Instances of this test with pseudorandom content are created
 // by Generate.
@@ -6,6 +6,8 @@
 package sharer
 
 import (
+	"math/big"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/miniBamboo/luckyshare/luckyshare"
 	"github.com/miniBamboo/luckyshare/xenv"
@@ -120,6 +122,16 @@ func init() {
 				panic(err)
 			}
 
+			// endorsement counts in tokens delegated to endorsor since STAKING
+			if env.BlockContext().Number >= env.ForkConfig().STAKING {
+				env.UseGas(luckyshare.SloadGas)
+				delegated, err := Staker.Native(env.State()).Delegated(endorsor)
+				if err != nil {
+					panic(err)
+				}
+				bal = new(big.Int).Add(bal, delegated)
+			}
+
 			env.UseGas(luckyshare.SloadGas)
 			endorsement, err := Params.Native(env.State()).Get(luckyshare.KeyProposerEndorsement)
 			if err != nil {
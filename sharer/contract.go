@@ -35,6 +35,21 @@ func mustLoadContract(name string) *contract {
 	}
 }
 
+// mustLoadNativeContract loads contract with all methods implemented natively,
+// whose ABI is given directly rather than compiled.
+func mustLoadNativeContract(name string, abiJSON string) *contract {
+	abi, err := abi.New([]byte(abiJSON))
+	if err != nil {
+		panic(errors.Wrap(err, "load ABI for '"+name+"'"))
+	}
+
+	return &contract{
+		name,
+		luckyshare.BytesToAddress([]byte(name)),
+		abi,
+	}
+}
+
 // RuntimeBytecodes load runtime byte codes.
 func (c *contract) RuntimeBytecodes() []byte {
 	asset := "compiled/" + c.name + ".bin-runtime"
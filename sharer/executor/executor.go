@@ -0,0 +1,151 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package executor
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/state"
+)
+
+// storage slots of state variables declared in `Executor` contract.
+var (
+	approversSlot       = luckyshare.BytesToBytes32([]byte{0})
+	approverCountSlot   = luckyshare.BytesToBytes32([]byte{1})
+	votingContractsSlot = luckyshare.BytesToBytes32([]byte{2})
+	proposalsSlot       = luckyshare.BytesToBytes32([]byte{3})
+)
+
+// Executor reads states of `Executor` contract natively, following the solidity storage layout.
+// It's read-only, since the contract is driven by transactions.
+type Executor struct {
+	addr  luckyshare.Address
+	state *state.State
+}
+
+// New create a new instance.
+func New(addr luckyshare.Address, state *state.State) *Executor {
+	return &Executor{addr, state}
+}
+
+// mappingSlot computes the slot of the value keyed by key in the mapping at given slot.
+func mappingSlot(key, slot luckyshare.Bytes32) luckyshare.Bytes32 {
+	return luckyshare.Bytes32(crypto.Keccak256Hash(key[:], slot[:]))
+}
+
+// offsetSlot returns the slot of n-th member of a struct located at slot.
+func offsetSlot(slot luckyshare.Bytes32, n int64) luckyshare.Bytes32 {
+	v := new(big.Int).SetBytes(slot[:])
+	return luckyshare.BytesToBytes32(v.Add(v, big.NewInt(n)).Bytes())
+}
+
+func (e *Executor) getBytes(slot luckyshare.Bytes32) ([]byte, error) {
+	v, err := e.state.GetStorage(e.addr, slot)
+	if err != nil {
+		return nil, err
+	}
+	// short bytes (less than 32) are stored along with length*2 in the lowest byte
+	if v[31]&1 == 0 {
+		n := v[31] / 2
+		return append([]byte(nil), v[:n]...), nil
+	}
+
+	// long bytes store length*2+1 in the slot, and the content from keccak256(slot)
+	n := (new(big.Int).SetBytes(v[:]).Uint64() - 1) / 2
+	var (
+		data = make([]byte, 0, n)
+		pos  = luckyshare.Bytes32(crypto.Keccak256Hash(slot[:]))
+	)
+	for uint64(len(data)) < n {
+		word, err := e.state.GetStorage(e.addr, pos)
+		if err != nil {
+			return nil, err
+		}
+		if rest := n - uint64(len(data)); rest < 32 {
+			data = append(data, word[:rest]...)
+		} else {
+			data = append(data, word[:]...)
+		}
+		pos = offsetSlot(pos, 1)
+	}
+	return data, nil
+}
+
+// ApproverCount returns count of approvers in power.
+func (e *Executor) ApproverCount() (uint8, error) {
+	v, err := e.state.GetStorage(e.addr, approverCountSlot)
+	if err != nil {
+		return 0, err
+	}
+	return v[31], nil
+}
+
+// GetApprover returns identity of the approver, and whether it's in power.
+// Zero identity is returned for unknown approver.
+func (e *Executor) GetApprover(addr luckyshare.Address) (identity luckyshare.Bytes32, inPower bool, err error) {
+	slot := mappingSlot(luckyshare.BytesToBytes32(addr[:]), approversSlot)
+	if identity, err = e.state.GetStorage(e.addr, slot); err != nil {
+		return
+	}
+	v, err := e.state.GetStorage(e.addr, offsetSlot(slot, 1))
+	if err != nil {
+		return
+	}
+	return identity, v[31] != 0, nil
+}
+
+// IsVotingContract returns whether the contract is attached as voting contract.
+func (e *Executor) IsVotingContract(addr luckyshare.Address) (bool, error) {
+	v, err := e.state.GetStorage(e.addr, mappingSlot(luckyshare.BytesToBytes32(addr[:]), votingContractsSlot))
+	if err != nil {
+		return false, err
+	}
+	return v[31] != 0, nil
+}
+
+// GetProposal returns the proposal by id. TimeProposed of the returned proposal is zero if not found.
+func (e *Executor) GetProposal(id luckyshare.Bytes32) (*Proposal, error) {
+	slot := mappingSlot(id, proposalsSlot)
+
+	// timeProposed, proposer, quorum, approvalCount and executed are packed into the first slot,
+	// from the lowest order bytes
+	v, err := e.state.GetStorage(e.addr, slot)
+	if err != nil {
+		return nil, err
+	}
+	p := &Proposal{
+		TimeProposed:  new(big.Int).SetBytes(v[24:]).Uint64(),
+		Proposer:      luckyshare.BytesToAddress(v[4:24]),
+		Quorum:        v[3],
+		ApprovalCount: v[2],
+		Executed:      v[1] != 0,
+	}
+	if p.TimeProposed == 0 {
+		return p, nil
+	}
+
+	if v, err = e.state.GetStorage(e.addr, offsetSlot(slot, 1)); err != nil {
+		return nil, err
+	}
+	p.Target = luckyshare.BytesToAddress(v[12:])
+
+	if p.Data, err = e.getBytes(offsetSlot(slot, 2)); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// IsApproved returns whether the proposal is approved by the approver.
+func (e *Executor) IsApproved(id luckyshare.Bytes32, approver luckyshare.Address) (bool, error) {
+	approvals := offsetSlot(mappingSlot(id, proposalsSlot), 3)
+	v, err := e.state.GetStorage(e.addr, mappingSlot(luckyshare.BytesToBytes32(approver[:]), approvals))
+	if err != nil {
+		return false, err
+	}
+	return v[31] != 0, nil
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package executor
+
+import (
+	"github.com/miniBamboo/luckyshare/luckyshare"
+)
+
+// ProposalLifetime proposals can only be approved or executed within the lifetime.
+const ProposalLifetime = uint64(7 * 24 * 3600)
+
+// Proposal governance proposal stored in `Executor` contract.
+type Proposal struct {
+	TimeProposed  uint64
+	Proposer      luckyshare.Address
+	Quorum        uint8
+	ApprovalCount uint8
+	Executed      bool
+	Target        luckyshare.Address
+	Data          []byte
+}
+
+// Expired returns whether the proposal is expired at given time.
+func (p *Proposal) Expired(now uint64) bool {
+	return now-p.TimeProposed >= ProposalLifetime
+}
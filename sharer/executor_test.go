@@ -18,6 +18,7 @@ import (
 	"github.com/miniBamboo/luckyshare/muxdb"
 	"github.com/miniBamboo/luckyshare/runtime"
 	sharer "github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/sharer/executor"
 	"github.com/miniBamboo/luckyshare/state"
 	"github.com/miniBamboo/luckyshare/tx"
 	"github.com/miniBamboo/luckyshare/xenv"
@@ -257,3 +258,62 @@ func TestExecutorProposal(t *testing.T) {
 
 	assert.Equal(t, M(big.NewInt(12345), nil), M(sharer.Params.Native(test.rt.State()).Get(luckyshare.BytesToBytes32([]byte("paramKey")))))
 }
+
+func TestExecutorNative(t *testing.T) {
+	test := initExectorTest()
+	native := sharer.Executor.Native(test.rt.State())
+
+	approvers := []luckyshare.Address{
+		luckyshare.BytesToAddress([]byte("approver1")),
+		luckyshare.BytesToAddress([]byte("approver2")),
+	}
+	for _, a := range approvers {
+		test.Case("addApprover", a, luckyshare.BytesToBytes32(a.Bytes())).
+			Caller(sharer.Executor.Address).
+			Assert(t)
+	}
+	assert.Equal(t, M(uint8(2), nil), M(native.ApproverCount()))
+	assert.Equal(t, M(luckyshare.BytesToBytes32(approvers[0].Bytes()), true, nil), M(native.GetApprover(approvers[0])))
+	assert.Equal(t, M(luckyshare.Bytes32{}, false, nil), M(native.GetApprover(luckyshare.BytesToAddress([]byte("other")))))
+
+	voting := luckyshare.BytesToAddress([]byte("voting"))
+	test.Case("attachVotingContract", voting).
+		Caller(sharer.Executor.Address).
+		Assert(t)
+	assert.Equal(t, M(true, nil), M(native.IsVotingContract(voting)))
+
+	// long data stored across slots
+	data := make([]byte, 100)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	proposalID := func() luckyshare.Bytes32 {
+		var b8 [8]byte
+		binary.BigEndian.PutUint64(b8[:], test.rt.Context().Time)
+		return luckyshare.Bytes32(crypto.Keccak256Hash(b8[:], approvers[0][:]))
+	}()
+	test.Case("propose", sharer.Params.Address, data).
+		Caller(approvers[0]).
+		ShouldOutput(proposalID).
+		Assert(t)
+	test.Case("approve", proposalID).
+		Caller(approvers[1]).
+		Assert(t)
+
+	p, err := native.GetProposal(proposalID)
+	assert.Nil(t, err)
+	assert.Equal(t, &executor.Proposal{
+		TimeProposed:  test.rt.Context().Time,
+		Proposer:      approvers[0],
+		Quorum:        2,
+		ApprovalCount: 1,
+		Target:        sharer.Params.Address,
+		Data:          data,
+	}, p)
+	assert.Equal(t, M(false, nil), M(native.IsApproved(proposalID, approvers[0])))
+	assert.Equal(t, M(true, nil), M(native.IsApproved(proposalID, approvers[1])))
+
+	p, err = native.GetProposal(luckyshare.Bytes32{1})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(0), p.TimeProposed)
+}
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/miniBamboo/luckyshare/abi"
 	"github.com/miniBamboo/luckyshare/block"
 	"github.com/miniBamboo/luckyshare/chain"
@@ -50,6 +51,7 @@ type ccase struct {
 	blockRef   tx.BlockRef
 	gasPayer   luckyshare.Address
 	expiration uint32
+	value      *big.Int
 
 	output *[]interface{}
 	vmerr  error
@@ -100,6 +102,11 @@ func (c *ccase) Expiration(expiration uint32) *ccase {
 	c.expiration = expiration
 	return c
 }
+func (c *ccase) Value(value *big.Int) *ccase {
+	c.value = value
+	return c
+}
+
 func (c *ccase) ShouldVMError(err error) *ccase {
 	c.vmerr = err
 	return c
@@ -127,7 +134,11 @@ func (c *ccase) Assert(t *testing.T) *ccase {
 	data, err := method.EncodeInput(c.args...)
 	assert.Nil(t, err, "should encode input")
 
-	exec, _ := c.rt.PrepareClause(tx.NewClause(&c.to).WithData(data),
+	clause := tx.NewClause(&c.to).WithData(data)
+	if c.value != nil {
+		clause = clause.WithValue(c.value)
+	}
+	exec, _ := c.rt.PrepareClause(clause,
 		0, math.MaxUint64, &xenv.TransactionContext{
 			ID:         c.txID,
 			Origin:     c.caller,
@@ -230,6 +241,11 @@ func TestParamsNative(t *testing.T) {
 		ShouldVMError(errReverted).
 		Assert(t)
 
+	test.Case("set", luckyshare.KeyDynamicBaseGasPrice, value).
+		Caller(executor).
+		ShouldVMError(errReverted).
+		Assert(t)
+
 	test.Case("get", key).
 		ShouldOutput(value).
 		Assert(t)
@@ -1029,3 +1045,322 @@ func TestExtensionNative(t *testing.T) {
 		Assert(t)
 
 }
+
+func TestStakerNative(t *testing.T) {
+	var (
+		delegator = luckyshare.BytesToAddress([]byte("delegator"))
+		endorsor  = luckyshare.BytesToAddress([]byte("endorsor"))
+	)
+
+	db := muxdb.NewMem()
+	b0 := buildGenesis(db, func(state *state.State) error {
+		state.SetCode(sharer.Energy.Address, sharer.Energy.RuntimeBytecodes())
+		state.SetCode(sharer.Staker.Address, sharer.Staker.RuntimeBytecodes())
+		state.SetBalance(delegator, big.NewInt(1000))
+		return nil
+	})
+
+	repo, _ := chain.NewRepository(db, b0)
+	st := state.New(db, b0.Header().StateRoot())
+	chain := repo.NewChain(b0.Header().ID())
+
+	delegationEvent := func(amount *big.Int, action string) *tx.Event {
+		ev, _ := sharer.Staker.ABI.EventByName("Delegation")
+		var b32 luckyshare.Bytes32
+		copy(b32[:], action)
+		data, _ := ev.Encode(amount, b32)
+		return &tx.Event{
+			Address: sharer.Staker.Address,
+			Topics:  []luckyshare.Bytes32{ev.ID(), luckyshare.BytesToBytes32(delegator[:]), luckyshare.BytesToBytes32(endorsor[:])},
+			Data:    data,
+		}
+	}
+
+	forkConfig := luckyshare.NoFork
+	forkConfig.STAKING = 0
+	blockTime := b0.Header().Timestamp()
+	rt := runtime.New(chain, st, &xenv.BlockContext{Time: blockTime}, forkConfig)
+	test := &ctest{
+		rt:     rt,
+		abi:    sharer.Staker.ABI,
+		to:     sharer.Staker.Address,
+		caller: delegator,
+	}
+
+	test.Case("delegate", endorsor).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	test.Case("delegate", luckyshare.Address{}).
+		Value(big.NewInt(100)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	test.Case("delegate", endorsor).
+		Value(big.NewInt(100)).
+		ShouldLog(delegationEvent(big.NewInt(100), "delegated")).
+		Assert(t)
+	assert.Equal(t, M(big.NewInt(100), nil), M(st.GetBalance(sharer.Staker.Address)))
+
+	test.Case("delegated", endorsor).
+		ShouldOutput(big.NewInt(100)).
+		Assert(t)
+
+	test.Case("undelegate", endorsor, big.NewInt(101)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	test.Case("undelegate", endorsor, big.NewInt(40)).
+		ShouldLog(delegationEvent(big.NewInt(40), "undelegated")).
+		Assert(t)
+
+	test.Case("unbonding", delegator).
+		ShouldOutput(big.NewInt(40), blockTime+luckyshare.UnbondingPeriod).
+		Assert(t)
+
+	// not released yet
+	test.Case("withdraw").
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	// reward is shared by block processing
+	sharer.Energy.Native(st, blockTime).Add(sharer.Staker.Address, big.NewInt(30))
+	sharer.Staker.Native(st).Distribute(endorsor, big.NewInt(30))
+
+	test.Case("delegation", delegator, endorsor).
+		ShouldOutput(big.NewInt(60), big.NewInt(30)).
+		Assert(t)
+
+	test.Case("claimReward", endorsor).
+		ShouldOutput(big.NewInt(30)).
+		ShouldLog(delegationEvent(big.NewInt(30), "rewardClaimed")).
+		Assert(t)
+	assert.Equal(t, M(big.NewInt(30), nil), M(st.GetEnergy(delegator, blockTime)))
+
+	test.Case("delegation", delegator, endorsor).
+		ShouldOutput(big.NewInt(60), &big.Int{}).
+		Assert(t)
+}
+
+func TestStakerDoubleSign(t *testing.T) {
+	var (
+		masterKey, _ = crypto.GenerateKey()
+		master       = luckyshare.Address(crypto.PubkeyToAddress(masterKey.PublicKey))
+		endorsor     = luckyshare.BytesToAddress([]byte("endorsor"))
+		reporter     = luckyshare.BytesToAddress([]byte("reporter"))
+	)
+
+	db := muxdb.NewMem()
+	b0 := buildGenesis(db, func(state *state.State) error {
+		state.SetCode(sharer.Authority.Address, sharer.Authority.RuntimeBytecodes())
+		state.SetCode(sharer.Staker.Address, sharer.Staker.RuntimeBytecodes())
+		state.SetBalance(endorsor, big.NewInt(1000))
+		sharer.Authority.Native(state).Add(master, endorsor, luckyshare.BytesToBytes32([]byte("master")))
+		return nil
+	})
+
+	repo, _ := chain.NewRepository(db, b0)
+	st := state.New(db, b0.Header().StateRoot())
+	chain := repo.NewChain(b0.Header().ID())
+
+	encodeHeader := func(parentID luckyshare.Bytes32, ts uint64, gasLimit uint64, key *ecdsa.PrivateKey) []byte {
+		blk := new(block.Builder).ParentID(parentID).Timestamp(ts).GasLimit(gasLimit).Build()
+		sig, _ := crypto.Sign(blk.Header().SigningHash().Bytes(), key)
+		data, _ := rlp.EncodeToBytes(blk.WithSignature(sig).Header())
+		return data
+	}
+	otherKey, _ := crypto.GenerateKey()
+
+	forkConfig := luckyshare.NoFork
+	forkConfig.STAKING = 0
+	rt := runtime.New(chain, st, &xenv.BlockContext{Number: 10, Time: b0.Header().Timestamp()}, forkConfig)
+	test := &ctest{
+		rt:     rt,
+		abi:    sharer.Staker.ABI,
+		to:     sharer.Staker.Address,
+		caller: reporter,
+	}
+
+	parentID := b0.Header().ID()
+	ts := b0.Header().Timestamp() + luckyshare.BlockInterval
+
+	// same header
+	test.Case("reportDoubleSign", encodeHeader(parentID, ts, 1, masterKey), encodeHeader(parentID, ts, 1, masterKey)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	// different signers
+	test.Case("reportDoubleSign", encodeHeader(parentID, ts, 1, masterKey), encodeHeader(parentID, ts, 2, otherKey)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	// signer not listed
+	test.Case("reportDoubleSign", encodeHeader(parentID, ts, 1, otherKey), encodeHeader(parentID, ts, 2, otherKey)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	// same height but different slots
+	test.Case("reportDoubleSign", encodeHeader(parentID, ts, 1, masterKey), encodeHeader(parentID, ts+luckyshare.BlockInterval, 1, masterKey)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	// parent not on this chain
+	unknownID := luckyshare.BytesToBytes32([]byte("unknown"))
+	test.Case("reportDoubleSign", encodeHeader(unknownID, ts, 1, masterKey), encodeHeader(unknownID, ts, 2, masterKey)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	revokedEvent := func() *tx.Event {
+		ev, _ := sharer.Authority.ABI.EventByName("Candidate")
+		var action luckyshare.Bytes32
+		copy(action[:], "revoked")
+		data, _ := ev.Encode(action)
+		return &tx.Event{
+			Address: sharer.Authority.Address,
+			Topics:  []luckyshare.Bytes32{ev.ID(), luckyshare.BytesToBytes32(master[:])},
+			Data:    data,
+		}
+	}()
+	test.Case("reportDoubleSign", encodeHeader(parentID, ts, 1, masterKey), encodeHeader(parentID, ts, 2, masterKey)).
+		ShouldOutput(big.NewInt(100)).
+		ShouldLog(revokedEvent).
+		Assert(t)
+
+	assert.Equal(t, M(false, endorsor, luckyshare.BytesToBytes32([]byte("master")), false, nil), M(sharer.Authority.Native(st).Get(master)))
+	assert.Equal(t, M(big.NewInt(900), nil), M(st.GetBalance(endorsor)))
+	assert.Equal(t, M(big.NewInt(100), nil), M(st.GetBalance(reporter)))
+
+	// already punished
+	test.Case("reportDoubleSign", encodeHeader(parentID, ts, 1, masterKey), encodeHeader(parentID, ts, 2, masterKey)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	// evidence can't be reused after listed again
+	sharer.Authority.Native(st).Add(master, endorsor, luckyshare.BytesToBytes32([]byte("master")))
+	test.Case("reportDoubleSign", encodeHeader(parentID, ts, 3, masterKey), encodeHeader(parentID, ts, 4, masterKey)).
+		ShouldVMError(errReverted).
+		Assert(t)
+}
+
+func TestSchedulerNative(t *testing.T) {
+	var (
+		owner     = luckyshare.BytesToAddress([]byte("owner"))
+		other     = luckyshare.BytesToAddress([]byte("other"))
+		recipient = luckyshare.BytesToAddress([]byte("recipient"))
+	)
+
+	db := muxdb.NewMem()
+	b0 := buildGenesis(db, func(state *state.State) error {
+		state.SetCode(sharer.Energy.Address, sharer.Energy.RuntimeBytecodes())
+		state.SetCode(sharer.Scheduler.Address, sharer.Scheduler.RuntimeBytecodes())
+		return nil
+	})
+
+	repo, _ := chain.NewRepository(db, b0)
+	st := state.New(db, b0.Header().StateRoot())
+	chain := repo.NewChain(b0.Header().ID())
+
+	blockTime := b0.Header().Timestamp()
+	sharer.Energy.Native(st, blockTime).Add(owner, big.NewInt(100000))
+	sharer.Params.Native(st).Set(luckyshare.KeyBaseGasPrice, big.NewInt(1))
+
+	energyTransferEvent := func(from, to luckyshare.Address, amount *big.Int) *tx.Event {
+		ev, _ := sharer.Energy.ABI.EventByName("Transfer")
+		data, _ := ev.Encode(amount)
+		return &tx.Event{
+			Address: sharer.Energy.Address,
+			Topics:  []luckyshare.Bytes32{ev.ID(), luckyshare.BytesToBytes32(from[:]), luckyshare.BytesToBytes32(to[:])},
+			Data:    data,
+		}
+	}
+	scheduledEvent := func() *tx.Event {
+		ev, _ := sharer.Scheduler.ABI.EventByName("Scheduled")
+		data, _ := ev.Encode(uint32(11), uint32(5), uint32(2))
+		return &tx.Event{
+			Address: sharer.Scheduler.Address,
+			Topics: []luckyshare.Bytes32{
+				ev.ID(),
+				luckyshare.BytesToBytes32(big.NewInt(1).Bytes()),
+				luckyshare.BytesToBytes32(owner[:]),
+				luckyshare.BytesToBytes32(recipient[:]),
+			},
+			Data: data,
+		}
+	}()
+
+	forkConfig := luckyshare.NoFork
+	forkConfig.SCHEDULER = 0
+	rt := runtime.New(chain, st, &xenv.BlockContext{Number: 10, Time: blockTime}, forkConfig)
+	test := &ctest{
+		rt:     rt,
+		abi:    sharer.Scheduler.ABI,
+		to:     sharer.Scheduler.Address,
+		caller: owner,
+	}
+
+	// not a future block
+	test.Case("schedule", recipient, big.NewInt(10), []byte{}, uint64(21000), uint32(10), uint32(5), uint32(2), big.NewInt(500)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	// repeated without interval
+	test.Case("schedule", recipient, big.NewInt(10), []byte{}, uint64(21000), uint32(11), uint32(0), uint32(2), big.NewInt(500)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	// gas less than intrinsic gas
+	test.Case("schedule", recipient, big.NewInt(10), []byte{}, uint64(20000), uint32(11), uint32(5), uint32(2), big.NewInt(500)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	// insufficient energy
+	test.Case("schedule", recipient, big.NewInt(10), []byte{}, uint64(21000), uint32(11), uint32(5), uint32(2), big.NewInt(100001)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	// energy can't pay for a run
+	test.Case("schedule", recipient, big.NewInt(10), []byte{}, uint64(21000), uint32(11), uint32(5), uint32(2), big.NewInt(20999)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	// too many schedules queued at the block
+	for i := uint64(0); i < luckyshare.MaxSchedulesPerDueBlock; i++ {
+		assert.Nil(t, sharer.Scheduler.Native(st).Enqueue(1000+i, 12))
+	}
+	test.Case("schedule", recipient, big.NewInt(10), []byte{}, uint64(21000), uint32(12), uint32(5), uint32(2), big.NewInt(30000)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	test.Case("schedule", recipient, big.NewInt(10), []byte{}, uint64(21000), uint32(11), uint32(5), uint32(2), big.NewInt(30000)).
+		ShouldOutput(big.NewInt(1)).
+		ShouldLog(energyTransferEvent(owner, sharer.Scheduler.Address, big.NewInt(30000)), scheduledEvent).
+		Assert(t)
+
+	test.Case("get", big.NewInt(1)).
+		ShouldOutput(owner, recipient, big.NewInt(10), []byte{}, uint64(21000), uint32(11), uint32(5), uint32(2), big.NewInt(30000)).
+		Assert(t)
+
+	test.Case("deposit", big.NewInt(2), big.NewInt(100)).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	test.Case("deposit", big.NewInt(1), big.NewInt(100)).
+		ShouldLog(energyTransferEvent(owner, sharer.Scheduler.Address, big.NewInt(100))).
+		Assert(t)
+
+	// only owner can cancel
+	test.Case("cancel", big.NewInt(1)).
+		Caller(other).
+		ShouldVMError(errReverted).
+		Assert(t)
+
+	test.Case("cancel", big.NewInt(1)).
+		ShouldOutput(big.NewInt(30100)).
+		ShouldLog(energyTransferEvent(sharer.Scheduler.Address, owner, big.NewInt(30100))).
+		Assert(t)
+	assert.Equal(t, M(big.NewInt(100000), nil), M(st.GetEnergy(owner, blockTime)))
+
+	test.Case("get", big.NewInt(1)).
+		ShouldOutput(luckyshare.Address{}, luckyshare.Address{}, &big.Int{}, []byte{}, uint64(0), uint32(0), uint32(0), uint32(0), &big.Int{}).
+		Assert(t)
+}
@@ -45,6 +45,11 @@ func init() {
 			}
 			env.ParseArgs(&args)
 
+			// the dynamic base gas price is derived from block utilization, not governed
+			if luckyshare.Bytes32(args.Key) == luckyshare.KeyDynamicBaseGasPrice {
+				env.Revert()
+			}
+
 			env.UseGas(luckyshare.SstoreSetGas)
 			if err := Params.Native(env.State()).Set(luckyshare.Bytes32(args.Key), args.Value); err != nil {
 				panic(err)
@@ -0,0 +1,248 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package scheduler
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/state"
+)
+
+var (
+	countKey       = luckyshare.Blake2b([]byte("count"))
+	cursorKey      = luckyshare.Blake2b([]byte("cursor"))
+	schedulePrefix = []byte("schedule")
+	duePrefix      = []byte("due")
+	dueEntryPrefix = []byte("due-entry")
+)
+
+// bounds the entries of removed, rescheduled or underfunded schedules scanned in a block,
+// in multiples of the limit of ids taken out.
+const scanFactor = 4
+
+// dueQueue is the range of entries queued at a block, each of which is stored in its own slot.
+type dueQueue struct {
+	Head uint64
+	Tail uint64
+}
+
+// Scheduler implements native methods of `Scheduler` contract.
+// Accounts register clauses to be executed at future blocks, once or repeatedly,
+// and the due executions are performed at the beginning of each block.
+type Scheduler struct {
+	addr  luckyshare.Address
+	state *state.State
+}
+
+// New create a new instance.
+func New(addr luckyshare.Address, state *state.State) *Scheduler {
+	return &Scheduler{addr, state}
+}
+
+func scheduleKey(id uint64) luckyshare.Bytes32 {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], id)
+	return luckyshare.Blake2b(schedulePrefix, b[:])
+}
+
+func dueKey(blockNum uint32) luckyshare.Bytes32 {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], blockNum)
+	return luckyshare.Blake2b(duePrefix, b[:])
+}
+
+func dueEntryKey(blockNum uint32, index uint64) luckyshare.Bytes32 {
+	var b [12]byte
+	binary.BigEndian.PutUint32(b[:], blockNum)
+	binary.BigEndian.PutUint64(b[4:], index)
+	return luckyshare.Blake2b(dueEntryPrefix, b[:])
+}
+
+func (s *Scheduler) getUint64(key luckyshare.Bytes32) (v uint64, err error) {
+	err = s.state.DecodeStorage(s.addr, key, func(raw []byte) error {
+		if len(raw) == 0 {
+			return nil
+		}
+		return rlp.DecodeBytes(raw, &v)
+	})
+	return
+}
+
+func (s *Scheduler) setUint64(key luckyshare.Bytes32, v uint64) error {
+	return s.state.EncodeStorage(s.addr, key, func() ([]byte, error) {
+		if v == 0 {
+			return nil, nil
+		}
+		return rlp.EncodeToBytes(v)
+	})
+}
+
+func (s *Scheduler) getQueue(blockNum uint32) (q dueQueue, err error) {
+	err = s.state.DecodeStorage(s.addr, dueKey(blockNum), func(raw []byte) error {
+		if len(raw) == 0 {
+			return nil
+		}
+		return rlp.DecodeBytes(raw, &q)
+	})
+	return
+}
+
+func (s *Scheduler) setQueue(blockNum uint32, q dueQueue) error {
+	return s.state.EncodeStorage(s.addr, dueKey(blockNum), func() ([]byte, error) {
+		if q.Head == q.Tail {
+			return nil, nil
+		}
+		return rlp.EncodeToBytes(&q)
+	})
+}
+
+// Get returns the schedule by id. An empty schedule returned if not found.
+func (s *Scheduler) Get(id uint64) (*Schedule, error) {
+	sch := Schedule{Value: &big.Int{}, Energy: &big.Int{}}
+	if err := s.state.DecodeStorage(s.addr, scheduleKey(id), func(raw []byte) error {
+		if len(raw) == 0 {
+			return nil
+		}
+		return rlp.DecodeBytes(raw, &sch)
+	}); err != nil {
+		return nil, err
+	}
+	return &sch, nil
+}
+
+// Set updates the schedule. Setting an empty schedule removes it.
+func (s *Scheduler) Set(id uint64, sch *Schedule) error {
+	return s.state.EncodeStorage(s.addr, scheduleKey(id), func() ([]byte, error) {
+		if sch.IsEmpty() {
+			return nil, nil
+		}
+		return rlp.EncodeToBytes(sch)
+	})
+}
+
+// Add registers a new schedule, and queues it for execution at sch.Next.
+// It returns id of the schedule.
+func (s *Scheduler) Add(sch *Schedule) (uint64, error) {
+	var count uint64
+	if err := s.state.DecodeStorage(s.addr, countKey, func(raw []byte) error {
+		if len(raw) == 0 {
+			return nil
+		}
+		return rlp.DecodeBytes(raw, &count)
+	}); err != nil {
+		return 0, err
+	}
+
+	id := count + 1
+	if err := s.state.EncodeStorage(s.addr, countKey, func() ([]byte, error) {
+		return rlp.EncodeToBytes(id)
+	}); err != nil {
+		return 0, err
+	}
+	if err := s.Set(id, sch); err != nil {
+		return 0, err
+	}
+	if err := s.Enqueue(id, sch.Next); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Enqueue queues the schedule for execution at the given block.
+// The count of schedules queued at a block is not bounded here, and should be checked
+// by the caller registering a new schedule.
+func (s *Scheduler) Enqueue(id uint64, blockNum uint32) error {
+	q, err := s.getQueue(blockNum)
+	if err != nil {
+		return err
+	}
+	if err := s.setUint64(dueEntryKey(blockNum, q.Tail), id); err != nil {
+		return err
+	}
+	q.Tail++
+	return s.setQueue(blockNum, q)
+}
+
+// Queued returns the count of entries queued at the given block.
+func (s *Scheduler) Queued(blockNum uint32) (uint64, error) {
+	q, err := s.getQueue(blockNum)
+	if err != nil {
+		return 0, err
+	}
+	return q.Tail - q.Head, nil
+}
+
+// PopDue takes out ids of schedules queued at blocks up to the given one, in the order they were queued,
+// and calls fn with each of them, until limit ids are taken out or fn returns false.
+// Entries of removed or rescheduled schedules are skipped, and those of schedules whose energy can't pay
+// for a run at gasPrice are dropped, leaving the schedules to be cancelled by their owners. None of them
+// count to the limit.
+// The entries left, including the one fn returns false for, are taken out ahead of those queued at
+// the next block, without being moved.
+func (s *Scheduler) PopDue(blockNum uint32, limit int, gasPrice *big.Int, fn func(id uint64, sch *Schedule) (bool, error)) error {
+	cursor, err := s.getUint64(cursorKey)
+	if err != nil {
+		return err
+	}
+	from := uint32(cursor)
+	if cursor == 0 || from > blockNum {
+		from = blockNum
+	}
+
+	var (
+		taken   int
+		scanned int
+	)
+	for num := from; num <= blockNum; num++ {
+		q, err := s.getQueue(num)
+		if err != nil {
+			return err
+		}
+		for ; q.Head < q.Tail; q.Head++ {
+			if taken >= limit || scanned >= limit*scanFactor {
+				if err := s.setQueue(num, q); err != nil {
+					return err
+				}
+				return s.setUint64(cursorKey, uint64(num))
+			}
+			scanned++
+
+			key := dueEntryKey(num, q.Head)
+			id, err := s.getUint64(key)
+			if err != nil {
+				return err
+			}
+			sch, err := s.Get(id)
+			if err != nil {
+				return err
+			}
+			if !sch.IsEmpty() && sch.Next == num &&
+				sch.Energy.Cmp(new(big.Int).Mul(new(big.Int).SetUint64(sch.Gas), gasPrice)) >= 0 {
+				ok, err := fn(id, sch)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					if err := s.setQueue(num, q); err != nil {
+						return err
+					}
+					return s.setUint64(cursorKey, uint64(num))
+				}
+				taken++
+			}
+			if err := s.setUint64(key, 0); err != nil {
+				return err
+			}
+		}
+		if err := s.setQueue(num, q); err != nil {
+			return err
+		}
+	}
+	return s.setUint64(cursorKey, uint64(blockNum)+1)
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package scheduler
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/muxdb"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func M(a ...interface{}) []interface{} {
+	return a
+}
+
+func TestScheduler(t *testing.T) {
+	db := muxdb.NewMem()
+	st := state.New(db, luckyshare.Bytes32{})
+
+	sch := New(luckyshare.BytesToAddress([]byte("sch")), st)
+
+	s1 := &Schedule{
+		Owner:     luckyshare.BytesToAddress([]byte("o1")),
+		To:        luckyshare.BytesToAddress([]byte("to")),
+		Value:     big.NewInt(1),
+		Data:      []byte{1, 2, 3},
+		Gas:       50000,
+		Next:      10,
+		Interval:  5,
+		Remaining: 3,
+		Energy:    big.NewInt(1000),
+	}
+	s2 := &Schedule{
+		Owner:     luckyshare.BytesToAddress([]byte("o2")),
+		Value:     &big.Int{},
+		Data:      []byte{},
+		Next:      10,
+		Remaining: 1,
+		Energy:    &big.Int{},
+	}
+	empty := &Schedule{Value: &big.Int{}, Energy: &big.Int{}}
+
+	// pop collects ids taken out, and stops after stopAfter ids if positive
+	pop := func(blockNum uint32, limit int, gasPrice int64, stopAfter int) ([]uint64, error) {
+		var ids []uint64
+		err := sch.PopDue(blockNum, limit, big.NewInt(gasPrice), func(id uint64, _ *Schedule) (bool, error) {
+			if stopAfter > 0 && len(ids) >= stopAfter {
+				return false, nil
+			}
+			ids = append(ids, id)
+			return true, nil
+		})
+		return ids, err
+	}
+
+	tests := []struct {
+		ret      interface{}
+		expected interface{}
+	}{
+		{M(sch.Get(1)), M(empty, nil)},
+		{M(sch.Add(s1)), M(uint64(1), nil)},
+		{M(sch.Add(s2)), M(uint64(2), nil)},
+		{M(sch.Get(1)), M(s1, nil)},
+		{M(sch.Queued(10)), M(uint64(2), nil)},
+		{M(pop(9, 10, 0, 0)), M([]uint64(nil), nil)},
+		{M(pop(10, 10, 0, 0)), M([]uint64{1, 2}, nil)},
+		{M(sch.Queued(10)), M(uint64(0), nil)},
+		{M(pop(10, 10, 0, 0)), M([]uint64(nil), nil)},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.ret)
+	}
+
+	// removed or rescheduled ones are skipped
+	s1.Next = 15
+	assert.Nil(t, sch.Set(1, s1))
+	assert.Nil(t, sch.Enqueue(1, 15))
+	assert.Nil(t, sch.Enqueue(2, 15))
+	assert.Nil(t, sch.Set(2, empty))
+	assert.Equal(t, M([]uint64{1}, nil), M(pop(15, 10, 0, 0)))
+
+	// underfunded ones are dropped
+	assert.Nil(t, sch.Set(2, &Schedule{Owner: s2.Owner, Value: &big.Int{}, Data: []byte{}, Gas: 21000, Next: 20, Remaining: 1, Energy: big.NewInt(20999)}))
+	assert.Nil(t, sch.Enqueue(2, 20))
+	assert.Equal(t, M([]uint64(nil), nil), M(pop(20, 10, 1, 0)))
+	assert.Equal(t, M(uint64(0), nil), M(sch.Queued(20)))
+
+	// over the limit or stopped, taken out ahead of those queued at the next block
+	for id := uint64(3); id <= 5; id++ {
+		assert.Nil(t, sch.Set(id, &Schedule{Owner: s2.Owner, Value: &big.Int{}, Data: []byte{}, Next: 30, Remaining: 1, Energy: &big.Int{}}))
+		assert.Nil(t, sch.Enqueue(id, 30))
+	}
+	assert.Nil(t, sch.Set(6, &Schedule{Owner: s2.Owner, Value: &big.Int{}, Data: []byte{}, Next: 31, Remaining: 1, Energy: &big.Int{}}))
+	assert.Nil(t, sch.Enqueue(6, 31))
+
+	assert.Equal(t, M([]uint64{3}, nil), M(pop(30, 1, 0, 0)))
+	assert.Equal(t, M([]uint64{4}, nil), M(pop(31, 10, 0, 1)))
+	assert.Equal(t, M([]uint64{5, 6}, nil), M(pop(32, 10, 0, 0)))
+	assert.Equal(t, M([]uint64(nil), nil), M(pop(33, 10, 0, 0)))
+}
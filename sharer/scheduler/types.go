@@ -0,0 +1,30 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package scheduler
+
+import (
+	"math/big"
+
+	"github.com/miniBamboo/luckyshare/luckyshare"
+)
+
+// Schedule a clause registered by owner to be executed at future blocks.
+type Schedule struct {
+	Owner     luckyshare.Address
+	To        luckyshare.Address
+	Value     *big.Int
+	Data      []byte
+	Gas       uint64
+	Next      uint32   // number of the block to execute the clause next time
+	Interval  uint32   // (unit: block) interval between two executions, 0 for one-shot schedule
+	Remaining uint32   // count of executions left
+	Energy    *big.Int // pre-funded energy left for paying gas
+}
+
+// IsEmpty returns whether the schedule is absent.
+func (s *Schedule) IsEmpty() bool {
+	return s.Owner.IsZero()
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package sharer
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/miniBamboo/luckyshare/abi"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/sharer/scheduler"
+	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/miniBamboo/luckyshare/xenv"
+)
+
+// schedulerABI ABI of `Scheduler` contract. All methods of it are implemented natively,
+// and invoked directly by the runtime, so it has no solidity source.
+const schedulerABI = `[
+{"constant":false,"inputs":[{"name":"_to","type":"address"},{"name":"_value","type":"uint256"},{"name":"_data","type":"bytes"},{"name":"_gas","type":"uint64"},{"name":"_start","type":"uint32"},{"name":"_interval","type":"uint32"},{"name":"_times","type":"uint32"},{"name":"_energy","type":"uint256"}],"name":"schedule","outputs":[{"name":"id","type":"uint256"}],"payable":false,"stateMutability":"nonpayable","type":"function"},
+{"constant":false,"inputs":[{"name":"_id","type":"uint256"}],"name":"cancel","outputs":[{"name":"refunded","type":"uint256"}],"payable":false,"stateMutability":"nonpayable","type":"function"},
+{"constant":false,"inputs":[{"name":"_id","type":"uint256"},{"name":"_energy","type":"uint256"}],"name":"deposit","outputs":[],"payable":false,"stateMutability":"nonpayable","type":"function"},
+{"constant":true,"inputs":[{"name":"_id","type":"uint256"}],"name":"get","outputs":[{"name":"owner","type":"address"},{"name":"to","type":"address"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"},{"name":"gas","type":"uint64"},{"name":"next","type":"uint32"},{"name":"interval","type":"uint32"},{"name":"remaining","type":"uint32"},{"name":"energy","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"},
+{"anonymous":false,"inputs":[{"indexed":true,"name":"_id","type":"uint256"},{"indexed":true,"name":"_owner","type":"address"},{"indexed":true,"name":"_to","type":"address"},{"indexed":false,"name":"_start","type":"uint32"},{"indexed":false,"name":"_interval","type":"uint32"},{"indexed":false,"name":"_times","type":"uint32"}],"name":"Scheduled","type":"event"},
+{"anonymous":false,"inputs":[{"indexed":true,"name":"_id","type":"uint256"},{"indexed":false,"name":"_refunded","type":"uint256"}],"name":"Cancelled","type":"event"},
+{"anonymous":false,"inputs":[{"indexed":true,"name":"_id","type":"uint256"},{"indexed":false,"name":"_remaining","type":"uint32"},{"indexed":false,"name":"_reverted","type":"bool"}],"name":"Executed","type":"event"}
+]`
+
+// schedulerRuntimeBytecodes placeholder code of `Scheduler` contract, which simply reverts.
+var schedulerRuntimeBytecodes = []byte{0x60, 0x00, 0x80, 0xfd} // PUSH1 0x00 DUP1 REVERT
+
+func init() {
+	mustEventByName := func(name string) *abi.Event {
+		if event, found := Scheduler.ABI.EventByName(name); found {
+			return event
+		}
+		panic("event not found")
+	}
+
+	scheduledEvent := mustEventByName("Scheduled")
+	cancelledEvent := mustEventByName("Cancelled")
+	energyTransferEvent, _ := Energy.ABI.EventByName("Transfer")
+
+	// transferEnergy moves energy between accounts, and reverts if insufficient.
+	transferEnergy := func(env *xenv.Environment, sender, recipient luckyshare.Address, amount *big.Int) {
+		if amount.Sign() == 0 {
+			return
+		}
+		env.UseGas(luckyshare.GetBalanceGas * 2)
+		energy := Energy.Native(env.State(), env.BlockContext().Time)
+		ok, err := energy.Sub(sender, amount)
+		if err != nil {
+			panic(err)
+		}
+		if !ok {
+			env.Revert()
+		}
+		if err := energy.Add(recipient, amount); err != nil {
+			panic(err)
+		}
+		env.Log(energyTransferEvent, Energy.Address, []luckyshare.Bytes32{
+			luckyshare.BytesToBytes32(sender[:]),
+			luckyshare.BytesToBytes32(recipient[:]),
+		}, amount)
+	}
+
+	// baseGasPrice returns the base gas price effective in the current state.
+	baseGasPrice := func(env *xenv.Environment) *big.Int {
+		params := Params.Native(env.State())
+		dynamic, err := params.Get(luckyshare.KeyDynamicBaseGasPrice)
+		if err != nil {
+			panic(err)
+		}
+		if dynamic.Sign() > 0 {
+			return dynamic
+		}
+		governed, err := params.Get(luckyshare.KeyBaseGasPrice)
+		if err != nil {
+			panic(err)
+		}
+		return governed
+	}
+
+	// getSchedule returns the schedule with the given id, and reverts if not exist.
+	getSchedule := func(env *xenv.Environment, id *big.Int) (uint64, *scheduler.Schedule) {
+		if !id.IsUint64() {
+			env.Revert()
+		}
+		env.UseGas(luckyshare.SloadGas)
+		sch, err := Scheduler.Native(env.State()).Get(id.Uint64())
+		if err != nil {
+			panic(err)
+		}
+		if sch.IsEmpty() {
+			env.Revert()
+		}
+		return id.Uint64(), sch
+	}
+
+	defines := []struct {
+		name string
+		run  func(env *xenv.Environment) []interface{}
+	}{
+		{"schedule", func(env *xenv.Environment) []interface{} {
+			var args struct {
+				To       common.Address
+				Value    *big.Int
+				Data     []byte
+				Gas      uint64
+				Start    uint32
+				Interval uint32
+				Times    uint32
+				Energy   *big.Int
+			}
+			env.ParseArgs(&args)
+			if env.Value().Sign() != 0 {
+				env.Revert()
+			}
+
+			to := luckyshare.Address(args.To)
+			intrinsicGas, err := tx.IntrinsicGas(tx.NewClause(&to).WithValue(args.Value).WithData(args.Data))
+			if err != nil {
+				env.Revert()
+			}
+			switch {
+			case args.Start <= env.BlockContext().Number,
+				args.Times == 0,
+				args.Times > 1 && args.Interval == 0,
+				args.Gas < intrinsicGas,
+				args.Gas > luckyshare.MaxScheduledGas:
+				env.Revert()
+			}
+
+			// the energy should pay for at least one run
+			env.UseGas(luckyshare.SloadGas * 2)
+			if args.Energy.Cmp(new(big.Int).Mul(new(big.Int).SetUint64(args.Gas), baseGasPrice(env))) < 0 {
+				env.Revert()
+			}
+
+			env.UseGas(luckyshare.SloadGas)
+			queued, err := Scheduler.Native(env.State()).Queued(args.Start)
+			if err != nil {
+				panic(err)
+			}
+			if queued >= luckyshare.MaxSchedulesPerDueBlock {
+				env.Revert()
+			}
+
+			transferEnergy(env, env.Caller(), Scheduler.Address, args.Energy)
+
+			// the count, the schedule, the queued id and the due queue
+			env.UseGas(luckyshare.SloadGas)
+			env.UseGas(luckyshare.SstoreSetGas * (5 + uint64(len(args.Data)+31)/32))
+			env.UseGas(luckyshare.SstoreResetGas)
+			id, err := Scheduler.Native(env.State()).Add(&scheduler.Schedule{
+				Owner:     env.Caller(),
+				To:        to,
+				Value:     args.Value,
+				Data:      args.Data,
+				Gas:       args.Gas,
+				Next:      args.Start,
+				Interval:  args.Interval,
+				Remaining: args.Times,
+				Energy:    args.Energy,
+			})
+			if err != nil {
+				panic(err)
+			}
+
+			bigID := new(big.Int).SetUint64(id)
+			env.Log(scheduledEvent, Scheduler.Address, []luckyshare.Bytes32{
+				luckyshare.BytesToBytes32(bigID.Bytes()),
+				luckyshare.BytesToBytes32(env.Caller().Bytes()),
+				luckyshare.BytesToBytes32(to[:]),
+			}, args.Start, args.Interval, args.Times)
+			return []interface{}{bigID}
+		}},
+		{"cancel", func(env *xenv.Environment) []interface{} {
+			var bigID *big.Int
+			env.ParseArgs(&bigID)
+			if env.Value().Sign() != 0 {
+				env.Revert()
+			}
+
+			id, sch := getSchedule(env, bigID)
+			if sch.Owner != env.Caller() {
+				env.Revert()
+			}
+
+			env.UseGas(luckyshare.SstoreResetGas)
+			if err := Scheduler.Native(env.State()).Set(id, &scheduler.Schedule{}); err != nil {
+				panic(err)
+			}
+			transferEnergy(env, Scheduler.Address, sch.Owner, sch.Energy)
+			env.Log(cancelledEvent, Scheduler.Address, []luckyshare.Bytes32{luckyshare.BytesToBytes32(bigID.Bytes())}, sch.Energy)
+			return []interface{}{sch.Energy}
+		}},
+		{"deposit", func(env *xenv.Environment) []interface{} {
+			var args struct {
+				ID     *big.Int
+				Energy *big.Int
+			}
+			env.ParseArgs(&args)
+			if env.Value().Sign() != 0 || args.Energy.Sign() == 0 {
+				env.Revert()
+			}
+
+			id, sch := getSchedule(env, args.ID)
+			transferEnergy(env, env.Caller(), Scheduler.Address, args.Energy)
+
+			env.UseGas(luckyshare.SstoreResetGas)
+			sch.Energy = new(big.Int).Add(sch.Energy, args.Energy)
+			if err := Scheduler.Native(env.State()).Set(id, sch); err != nil {
+				panic(err)
+			}
+			return nil
+		}},
+		{"get", func(env *xenv.Environment) []interface{} {
+			var bigID *big.Int
+			env.ParseArgs(&bigID)
+
+			env.UseGas(luckyshare.SloadGas)
+			sch := &scheduler.Schedule{Value: &big.Int{}, Energy: &big.Int{}}
+			if bigID.IsUint64() {
+				var err error
+				if sch, err = Scheduler.Native(env.State()).Get(bigID.Uint64()); err != nil {
+					panic(err)
+				}
+			}
+			return []interface{}{sch.Owner, sch.To, sch.Value, sch.Data, sch.Gas, sch.Next, sch.Interval, sch.Remaining, sch.Energy}
+		}},
+	}
+	for _, def := range defines {
+		if method, found := Scheduler.ABI.MethodByName(def.name); found {
+			nativeMethods[methodKey{Scheduler.Address, method.ID()}] = &nativeMethod{
+				abi: method,
+				run: def.run,
+			}
+		} else {
+			panic("method not found: " + def.name)
+		}
+	}
+}
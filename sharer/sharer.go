@@ -10,9 +10,12 @@ import (
 	"github.com/miniBamboo/luckyshare/luckyshare"
 	"github.com/miniBamboo/luckyshare/sharer/authority"
 	"github.com/miniBamboo/luckyshare/sharer/energy"
+	"github.com/miniBamboo/luckyshare/sharer/executor"
 	"github.com/miniBamboo/luckyshare/sharer/gen"
 	"github.com/miniBamboo/luckyshare/sharer/params"
 	"github.com/miniBamboo/luckyshare/sharer/prototype"
+	"github.com/miniBamboo/luckyshare/sharer/scheduler"
+	"github.com/miniBamboo/luckyshare/sharer/staker"
 	"github.com/miniBamboo/luckyshare/state"
 	"github.com/miniBamboo/luckyshare/xenv"
 	"github.com/pkg/errors"
@@ -29,7 +32,9 @@ var (
 		mustLoadContract("Extension"),
 		mustLoadContract("ExtensionV2"),
 	}
-	Measure = mustLoadContract("Measure")
+	Measure   = mustLoadContract("Measure")
+	Staker    = &stakerContract{mustLoadNativeContract("Staker", stakerABI)}
+	Scheduler = &schedulerContract{mustLoadNativeContract("Scheduler", schedulerABI)}
 )
 
 type (
@@ -38,6 +43,8 @@ type (
 	energyContract    struct{ *contract }
 	executorContract  struct{ *contract }
 	prototypeContract struct{ *contract }
+	stakerContract    struct{ *contract }
+	schedulerContract struct{ *contract }
 	extensionContract struct {
 		*contract
 		V2 *contract
@@ -56,10 +63,32 @@ func (e *energyContract) Native(state *state.State, blockTime uint64) *energy.En
 	return energy.New(e.Address, state, blockTime)
 }
 
+func (e *executorContract) Native(state *state.State) *executor.Executor {
+	return executor.New(e.Address, state)
+}
+
 func (p *prototypeContract) Native(state *state.State) *prototype.Prototype {
 	return prototype.New(p.Address, state)
 }
 
+func (s *stakerContract) Native(state *state.State) *staker.Staker {
+	return staker.New(s.Address, state)
+}
+
+// RuntimeBytecodes returns the placeholder code, since `Staker` is not compiled from solidity.
+func (s *stakerContract) RuntimeBytecodes() []byte {
+	return append([]byte(nil), stakerRuntimeBytecodes...)
+}
+
+func (s *schedulerContract) Native(state *state.State) *scheduler.Scheduler {
+	return scheduler.New(s.Address, state)
+}
+
+// RuntimeBytecodes returns the placeholder code, since `Scheduler` is not compiled from solidity.
+func (s *schedulerContract) RuntimeBytecodes() []byte {
+	return append([]byte(nil), schedulerRuntimeBytecodes...)
+}
+
 func (p *prototypeContract) Events() *abi.ABI {
 	asset := "compiled/PrototypeEvent.abi"
 	data := gen.MustAsset(asset)
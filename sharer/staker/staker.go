@@ -0,0 +1,291 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package staker
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/state"
+)
+
+var (
+	delegationPrefix = []byte("delegation")
+	poolPrefix       = []byte("pool")
+	unbondingPrefix  = []byte("unbonding")
+	evidencePrefix   = []byte("evidence")
+
+	rewardPrecision = big.NewInt(1e18)
+)
+
+// Staker implements native methods of `Staker` contract.
+// Token holders lock tokens in the contract and delegate them to an endorsor,
+// and share the block reward earned by the endorsor's node pro rata.
+type Staker struct {
+	addr  luckyshare.Address
+	state *state.State
+}
+
+// New create a new instance.
+func New(addr luckyshare.Address, state *state.State) *Staker {
+	return &Staker{addr, state}
+}
+
+func (s *Staker) getDelegation(delegator, endorsor luckyshare.Address) (*delegation, error) {
+	d := delegation{&big.Int{}, &big.Int{}, &big.Int{}}
+	if err := s.state.DecodeStorage(s.addr, luckyshare.Blake2b(delegationPrefix, delegator[:], endorsor[:]), func(raw []byte) error {
+		if len(raw) == 0 {
+			return nil
+		}
+		return rlp.DecodeBytes(raw, &d)
+	}); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *Staker) setDelegation(delegator, endorsor luckyshare.Address, d *delegation) error {
+	return s.state.EncodeStorage(s.addr, luckyshare.Blake2b(delegationPrefix, delegator[:], endorsor[:]), func() ([]byte, error) {
+		if d.IsEmpty() {
+			return nil, nil
+		}
+		return rlp.EncodeToBytes(d)
+	})
+}
+
+func (s *Staker) getPool(endorsor luckyshare.Address) (*pool, error) {
+	p := pool{&big.Int{}, &big.Int{}}
+	if err := s.state.DecodeStorage(s.addr, luckyshare.Blake2b(poolPrefix, endorsor[:]), func(raw []byte) error {
+		if len(raw) == 0 {
+			return nil
+		}
+		return rlp.DecodeBytes(raw, &p)
+	}); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (s *Staker) setPool(endorsor luckyshare.Address, p *pool) error {
+	return s.state.EncodeStorage(s.addr, luckyshare.Blake2b(poolPrefix, endorsor[:]), func() ([]byte, error) {
+		if p.IsEmpty() {
+			return nil, nil
+		}
+		return rlp.EncodeToBytes(p)
+	})
+}
+
+func (s *Staker) getUnbonding(delegator luckyshare.Address) (*unbonding, error) {
+	u := unbonding{&big.Int{}, 0}
+	if err := s.state.DecodeStorage(s.addr, luckyshare.Blake2b(unbondingPrefix, delegator[:]), func(raw []byte) error {
+		if len(raw) == 0 {
+			return nil
+		}
+		return rlp.DecodeBytes(raw, &u)
+	}); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *Staker) setUnbonding(delegator luckyshare.Address, u *unbonding) error {
+	return s.state.EncodeStorage(s.addr, luckyshare.Blake2b(unbondingPrefix, delegator[:]), func() ([]byte, error) {
+		if u.IsEmpty() {
+			return nil, nil
+		}
+		return rlp.EncodeToBytes(u)
+	})
+}
+
+func evidenceKey(signer luckyshare.Address, parentID luckyshare.Bytes32, timestamp uint64) luckyshare.Bytes32 {
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], timestamp)
+	return luckyshare.Blake2b(evidencePrefix, signer[:], parentID[:], ts[:])
+}
+
+// accrued returns the reward accrued by amount of stake.
+func accrued(amount, accRewardPerStake *big.Int) *big.Int {
+	r := new(big.Int).Mul(amount, accRewardPerStake)
+	return r.Div(r, rewardPrecision)
+}
+
+// settle moves reward accrued since last settlement into the unclaimed reward.
+func (d *delegation) settle(p *pool) {
+	r := accrued(d.Amount, p.AccRewardPerStake)
+	r.Sub(r, d.RewardDebt)
+	d.Reward = new(big.Int).Add(d.Reward, r)
+	d.RewardDebt = accrued(d.Amount, p.AccRewardPerStake)
+}
+
+// Delegated returns total amount of tokens delegated to the endorsor.
+func (s *Staker) Delegated(endorsor luckyshare.Address) (*big.Int, error) {
+	p, err := s.getPool(endorsor)
+	if err != nil {
+		return nil, err
+	}
+	return p.Delegated, nil
+}
+
+// Get returns amount of tokens the delegator delegated to the endorsor, and the unclaimed reward.
+func (s *Staker) Get(delegator, endorsor luckyshare.Address) (amount *big.Int, reward *big.Int, err error) {
+	d, err := s.getDelegation(delegator, endorsor)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := s.getPool(endorsor)
+	if err != nil {
+		return nil, nil, err
+	}
+	d.settle(p)
+	return d.Amount, d.Reward, nil
+}
+
+// Unbonding returns amount of undelegated tokens of the delegator, and the time they can be withdrawn.
+func (s *Staker) Unbonding(delegator luckyshare.Address) (amount *big.Int, releaseTime uint64, err error) {
+	u, err := s.getUnbonding(delegator)
+	if err != nil {
+		return nil, 0, err
+	}
+	return u.Amount, u.ReleaseTime, nil
+}
+
+// Delegate delegates amount of tokens to the endorsor.
+// The tokens are expected to be already transferred to the contract.
+func (s *Staker) Delegate(delegator, endorsor luckyshare.Address, amount *big.Int) error {
+	d, err := s.getDelegation(delegator, endorsor)
+	if err != nil {
+		return err
+	}
+	p, err := s.getPool(endorsor)
+	if err != nil {
+		return err
+	}
+
+	d.settle(p)
+	d.Amount = new(big.Int).Add(d.Amount, amount)
+	d.RewardDebt = accrued(d.Amount, p.AccRewardPerStake)
+	p.Delegated = new(big.Int).Add(p.Delegated, amount)
+
+	if err := s.setDelegation(delegator, endorsor, d); err != nil {
+		return err
+	}
+	return s.setPool(endorsor, p)
+}
+
+// Undelegate takes back amount of tokens from the endorsor, and puts them into unbonding state until releaseTime.
+// Undelegating again before withdrawal accumulates the amount and postpones the release time.
+// It returns false if the delegated amount is insufficient.
+func (s *Staker) Undelegate(delegator, endorsor luckyshare.Address, amount *big.Int, releaseTime uint64) (bool, error) {
+	d, err := s.getDelegation(delegator, endorsor)
+	if err != nil {
+		return false, err
+	}
+	if d.Amount.Cmp(amount) < 0 {
+		return false, nil
+	}
+	p, err := s.getPool(endorsor)
+	if err != nil {
+		return false, err
+	}
+	u, err := s.getUnbonding(delegator)
+	if err != nil {
+		return false, err
+	}
+
+	d.settle(p)
+	d.Amount = new(big.Int).Sub(d.Amount, amount)
+	d.RewardDebt = accrued(d.Amount, p.AccRewardPerStake)
+	p.Delegated = new(big.Int).Sub(p.Delegated, amount)
+	u.Amount = new(big.Int).Add(u.Amount, amount)
+	u.ReleaseTime = releaseTime
+
+	if err := s.setDelegation(delegator, endorsor, d); err != nil {
+		return false, err
+	}
+	if err := s.setPool(endorsor, p); err != nil {
+		return false, err
+	}
+	if err := s.setUnbonding(delegator, u); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Withdraw releases unbonding tokens of the delegator if the release time reached.
+// It returns the released amount, which is expected to be transferred back to the delegator.
+func (s *Staker) Withdraw(delegator luckyshare.Address, blockTime uint64) (*big.Int, error) {
+	u, err := s.getUnbonding(delegator)
+	if err != nil {
+		return nil, err
+	}
+	if u.Amount.Sign() == 0 || blockTime < u.ReleaseTime {
+		return &big.Int{}, nil
+	}
+	if err := s.setUnbonding(delegator, &unbonding{&big.Int{}, 0}); err != nil {
+		return nil, err
+	}
+	return u.Amount, nil
+}
+
+// ClaimReward clears the unclaimed reward of the delegator earned from the endorsor.
+// It returns the reward amount, which is expected to be transferred to the delegator.
+func (s *Staker) ClaimReward(delegator, endorsor luckyshare.Address) (*big.Int, error) {
+	d, err := s.getDelegation(delegator, endorsor)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.getPool(endorsor)
+	if err != nil {
+		return nil, err
+	}
+
+	d.settle(p)
+	reward := d.Reward
+	d.Reward = &big.Int{}
+
+	if err := s.setDelegation(delegator, endorsor, d); err != nil {
+		return nil, err
+	}
+	return reward, nil
+}
+
+// Distribute shares reward among delegators of the endorsor, in proportion to their delegated amount.
+// The reward is expected to be already added to the contract. It returns false if no tokens delegated.
+func (s *Staker) Distribute(endorsor luckyshare.Address, reward *big.Int) (bool, error) {
+	p, err := s.getPool(endorsor)
+	if err != nil {
+		return false, err
+	}
+	if p.Delegated.Sign() == 0 {
+		return false, nil
+	}
+
+	inc := new(big.Int).Mul(reward, rewardPrecision)
+	inc.Div(inc, p.Delegated)
+	p.AccRewardPerStake = new(big.Int).Add(p.AccRewardPerStake, inc)
+
+	if err := s.setPool(endorsor, p); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// IsEvidenceUsed returns whether the evidence of the signer double signing in the slot,
+// given by the parent id and the timestamp, is already reported.
+func (s *Staker) IsEvidenceUsed(signer luckyshare.Address, parentID luckyshare.Bytes32, timestamp uint64) (bool, error) {
+	v, err := s.state.GetStorage(s.addr, evidenceKey(signer, parentID, timestamp))
+	if err != nil {
+		return false, err
+	}
+	return !v.IsZero(), nil
+}
+
+// UseEvidence marks the evidence of the signer double signing in the slot as reported.
+func (s *Staker) UseEvidence(signer luckyshare.Address, parentID luckyshare.Bytes32, timestamp uint64) {
+	s.state.SetStorage(s.addr, evidenceKey(signer, parentID, timestamp), luckyshare.BytesToBytes32([]byte{1}))
+}
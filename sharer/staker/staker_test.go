@@ -0,0 +1,72 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package staker
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/muxdb"
+	"github.com/miniBamboo/luckyshare/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func M(a ...interface{}) []interface{} {
+	return a
+}
+
+func TestStaker(t *testing.T) {
+	db := muxdb.NewMem()
+	st := state.New(db, luckyshare.Bytes32{})
+
+	d1 := luckyshare.BytesToAddress([]byte("d1"))
+	d2 := luckyshare.BytesToAddress([]byte("d2"))
+	e1 := luckyshare.BytesToAddress([]byte("e1"))
+	e2 := luckyshare.BytesToAddress([]byte("e2"))
+
+	stk := New(luckyshare.BytesToAddress([]byte("stk")), st)
+	tests := []struct {
+		ret      interface{}
+		expected interface{}
+	}{
+		{M(stk.Distribute(e1, big.NewInt(100))), M(false, nil)},
+		{M(stk.Delegate(d1, e1, big.NewInt(100))), M(nil)},
+		{M(stk.Delegate(d2, e1, big.NewInt(300))), M(nil)},
+		{M(stk.Delegated(e1)), M(big.NewInt(400), nil)},
+		{M(stk.Delegated(e2)), M(&big.Int{}, nil)},
+		{M(stk.Distribute(e1, big.NewInt(1000))), M(true, nil)},
+		{M(stk.Get(d1, e1)), M(big.NewInt(100), big.NewInt(250), nil)},
+		{M(stk.Get(d2, e1)), M(big.NewInt(300), big.NewInt(750), nil)},
+		// delegate more does not affect earned reward
+		{M(stk.Delegate(d1, e1, big.NewInt(200))), M(nil)},
+		{M(stk.Get(d1, e1)), M(big.NewInt(300), big.NewInt(250), nil)},
+		{M(stk.Distribute(e1, big.NewInt(600))), M(true, nil)},
+		{M(stk.Get(d1, e1)), M(big.NewInt(300), big.NewInt(550), nil)},
+		{M(stk.ClaimReward(d1, e1)), M(big.NewInt(550), nil)},
+		{M(stk.Get(d1, e1)), M(big.NewInt(300), &big.Int{}, nil)},
+		{M(stk.Undelegate(d1, e1, big.NewInt(301), 100)), M(false, nil)},
+		{M(stk.Undelegate(d1, e1, big.NewInt(100), 100)), M(true, nil)},
+		{M(stk.Undelegate(d1, e1, big.NewInt(100), 200)), M(true, nil)},
+		{M(stk.Delegated(e1)), M(big.NewInt(400), nil)},
+		{M(stk.Unbonding(d1)), M(big.NewInt(200), uint64(200), nil)},
+		{M(stk.Withdraw(d1, 199)), M(&big.Int{}, nil)},
+		{M(stk.Withdraw(d1, 200)), M(big.NewInt(200), nil)},
+		{M(stk.Unbonding(d1)), M(&big.Int{}, uint64(0), nil)},
+		{M(stk.Get(d2, e1)), M(big.NewInt(300), big.NewInt(1050), nil)},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.ret)
+	}
+
+	parentID := luckyshare.BytesToBytes32([]byte("parent"))
+	assert.Equal(t, M(false, nil), M(stk.IsEvidenceUsed(e1, parentID, 10)))
+	stk.UseEvidence(e1, parentID, 10)
+	assert.Equal(t, M(true, nil), M(stk.IsEvidenceUsed(e1, parentID, 10)))
+	assert.Equal(t, M(false, nil), M(stk.IsEvidenceUsed(e1, parentID, 20)))
+	assert.Equal(t, M(false, nil), M(stk.IsEvidenceUsed(e2, parentID, 10)))
+}
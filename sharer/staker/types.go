@@ -0,0 +1,43 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package staker
+
+import (
+	"math/big"
+)
+
+type (
+	// delegation tokens locked by a delegator to an endorsor.
+	delegation struct {
+		Amount     *big.Int
+		RewardDebt *big.Int // reward already accounted, in unit of accRewardPerStake * Amount
+		Reward     *big.Int // settled but unclaimed reward
+	}
+
+	// pool stake delegated to an endorsor.
+	pool struct {
+		Delegated         *big.Int
+		AccRewardPerStake *big.Int // accumulated reward per staked token, scaled by rewardPrecision
+	}
+
+	// unbonding tokens undelegated and waiting for release.
+	unbonding struct {
+		Amount      *big.Int
+		ReleaseTime uint64
+	}
+)
+
+func (d *delegation) IsEmpty() bool {
+	return d.Amount.Sign() == 0 && d.RewardDebt.Sign() == 0 && d.Reward.Sign() == 0
+}
+
+func (p *pool) IsEmpty() bool {
+	return p.Delegated.Sign() == 0 && p.AccRewardPerStake.Sign() == 0
+}
+
+func (u *unbonding) IsEmpty() bool {
+	return u.Amount.Sign() == 0 && u.ReleaseTime == 0
+}
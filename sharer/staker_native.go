@@ -0,0 +1,325 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package sharer
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethparams "github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/miniBamboo/luckyshare/abi"
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/xenv"
+)
+
+// stakerABI ABI of `Staker` contract. All methods of it are implemented natively,
+// and invoked directly by the runtime, so it has no solidity source.
+const stakerABI = `[
+{"constant":false,"inputs":[{"name":"_endorsor","type":"address"}],"name":"delegate","outputs":[],"payable":true,"stateMutability":"payable","type":"function"},
+{"constant":false,"inputs":[{"name":"_endorsor","type":"address"},{"name":"_amount","type":"uint256"}],"name":"undelegate","outputs":[],"payable":false,"stateMutability":"nonpayable","type":"function"},
+{"constant":false,"inputs":[],"name":"withdraw","outputs":[{"name":"amount","type":"uint256"}],"payable":false,"stateMutability":"nonpayable","type":"function"},
+{"constant":false,"inputs":[{"name":"_endorsor","type":"address"}],"name":"claimReward","outputs":[{"name":"reward","type":"uint256"}],"payable":false,"stateMutability":"nonpayable","type":"function"},
+{"constant":true,"inputs":[{"name":"_endorsor","type":"address"}],"name":"delegated","outputs":[{"name":"","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"},
+{"constant":true,"inputs":[{"name":"_delegator","type":"address"},{"name":"_endorsor","type":"address"}],"name":"delegation","outputs":[{"name":"amount","type":"uint256"},{"name":"reward","type":"uint256"}],"payable":false,"stateMutability":"view","type":"function"},
+{"constant":false,"inputs":[{"name":"_header1","type":"bytes"},{"name":"_header2","type":"bytes"}],"name":"reportDoubleSign","outputs":[{"name":"slashed","type":"uint256"}],"payable":false,"stateMutability":"nonpayable","type":"function"},
+{"constant":true,"inputs":[{"name":"_delegator","type":"address"}],"name":"unbonding","outputs":[{"name":"amount","type":"uint256"},{"name":"releaseTime","type":"uint64"}],"payable":false,"stateMutability":"view","type":"function"},
+{"anonymous":false,"inputs":[{"indexed":true,"name":"_delegator","type":"address"},{"indexed":true,"name":"_endorsor","type":"address"},{"indexed":false,"name":"_amount","type":"uint256"},{"indexed":false,"name":"_action","type":"bytes32"}],"name":"Delegation","type":"event"},
+{"anonymous":false,"inputs":[{"indexed":true,"name":"_delegator","type":"address"},{"indexed":false,"name":"_amount","type":"uint256"}],"name":"Withdrawal","type":"event"},
+{"anonymous":false,"inputs":[{"indexed":true,"name":"_nodeMaster","type":"address"},{"indexed":true,"name":"_endorsor","type":"address"},{"indexed":false,"name":"_slashed","type":"uint256"},{"indexed":false,"name":"_reporter","type":"address"}],"name":"DoubleSign","type":"event"}
+]`
+
+// stakerRuntimeBytecodes placeholder code of `Staker` contract, which simply reverts.
+// It makes the contract account exist, while calls are served natively.
+var stakerRuntimeBytecodes = []byte{0x60, 0x00, 0x80, 0xfd} // PUSH1 0x00 DUP1 REVERT
+
+// verifyDoubleSign checks whether the two RLP encoded headers are different blocks signed
+// by the same signer in the same slot on top of the same parent, and returns the signer and one of the headers.
+// The caller should check that the parent is a block of its chain.
+func verifyDoubleSign(data1, data2 []byte, blockNum uint32) (luckyshare.Address, *block.Header, bool) {
+	var h1, h2 block.Header
+	if err := rlp.DecodeBytes(data1, &h1); err != nil {
+		return luckyshare.Address{}, nil, false
+	}
+	if err := rlp.DecodeBytes(data2, &h2); err != nil {
+		return luckyshare.Address{}, nil, false
+	}
+	if h1.ParentID() != h2.ParentID() || h1.Timestamp() != h2.Timestamp() || h1.ID() == h2.ID() {
+		return luckyshare.Address{}, nil, false
+	}
+	// evidence should be neither from future nor too old
+	if h1.Number() >= blockNum || blockNum-h1.Number() > luckyshare.MaxDoubleSignEvidenceAge {
+		return luckyshare.Address{}, nil, false
+	}
+
+	signer1, err := h1.Signer()
+	if err != nil {
+		return luckyshare.Address{}, nil, false
+	}
+	signer2, err := h2.Signer()
+	if err != nil {
+		return luckyshare.Address{}, nil, false
+	}
+	return signer1, &h1, signer1 == signer2
+}
+
+func init() {
+	mustEventByName := func(name string) *abi.Event {
+		if event, found := Staker.ABI.EventByName(name); found {
+			return event
+		}
+		panic("event not found")
+	}
+
+	delegationEvent := mustEventByName("Delegation")
+	withdrawalEvent := mustEventByName("Withdrawal")
+	doubleSignEvent := mustEventByName("DoubleSign")
+	energyTransferEvent, _ := Energy.ABI.EventByName("Transfer")
+	candidateEvent, _ := Authority.ABI.EventByName("Candidate")
+
+	logDelegation := func(env *xenv.Environment, endorsor common.Address, amount *big.Int, action string) {
+		var b32 luckyshare.Bytes32
+		copy(b32[:], action)
+		env.Log(delegationEvent, Staker.Address, []luckyshare.Bytes32{
+			luckyshare.BytesToBytes32(env.Caller().Bytes()),
+			luckyshare.BytesToBytes32(endorsor[:]),
+		}, amount, b32)
+	}
+
+	nonPayable := func(env *xenv.Environment) {
+		if env.Value().Sign() != 0 {
+			env.Revert()
+		}
+	}
+
+	defines := []struct {
+		name string
+		run  func(env *xenv.Environment) []interface{}
+	}{
+		{"delegate", func(env *xenv.Environment) []interface{} {
+			var endorsor common.Address
+			env.ParseArgs(&endorsor)
+
+			// the value is already transferred to the contract
+			amount := env.Value()
+			if amount.Sign() == 0 || endorsor == (common.Address{}) {
+				env.Revert()
+			}
+
+			env.UseGas(luckyshare.SloadGas * 2)
+			env.UseGas(luckyshare.SstoreSetGas * 2)
+			if err := Staker.Native(env.State()).Delegate(env.Caller(), luckyshare.Address(endorsor), amount); err != nil {
+				panic(err)
+			}
+			logDelegation(env, endorsor, amount, "delegated")
+			return nil
+		}},
+		{"undelegate", func(env *xenv.Environment) []interface{} {
+			var args struct {
+				Endorsor common.Address
+				Amount   *big.Int
+			}
+			env.ParseArgs(&args)
+			nonPayable(env)
+			if args.Amount.Sign() == 0 {
+				env.Revert()
+			}
+
+			env.UseGas(luckyshare.SloadGas * 3)
+			ok, err := Staker.Native(env.State()).Undelegate(
+				env.Caller(),
+				luckyshare.Address(args.Endorsor),
+				args.Amount,
+				env.BlockContext().Time+luckyshare.UnbondingPeriod)
+			if err != nil {
+				panic(err)
+			}
+			if !ok {
+				env.Revert()
+			}
+			env.UseGas(luckyshare.SstoreResetGas * 2)
+			env.UseGas(luckyshare.SstoreSetGas)
+			logDelegation(env, args.Endorsor, args.Amount, "undelegated")
+			return nil
+		}},
+		{"withdraw", func(env *xenv.Environment) []interface{} {
+			nonPayable(env)
+
+			env.UseGas(luckyshare.SloadGas)
+			amount, err := Staker.Native(env.State()).Withdraw(env.Caller(), env.BlockContext().Time)
+			if err != nil {
+				panic(err)
+			}
+			if amount.Sign() == 0 {
+				env.Revert()
+			}
+
+			env.UseGas(luckyshare.SstoreResetGas)
+			env.UseGas(luckyshare.GetBalanceGas * 2)
+			env.Transfer(Staker.Address, env.Caller(), amount)
+			env.Log(withdrawalEvent, Staker.Address, []luckyshare.Bytes32{luckyshare.BytesToBytes32(env.Caller().Bytes())}, amount)
+			return []interface{}{amount}
+		}},
+		{"claimReward", func(env *xenv.Environment) []interface{} {
+			var endorsor common.Address
+			env.ParseArgs(&endorsor)
+			nonPayable(env)
+
+			env.UseGas(luckyshare.SloadGas * 2)
+			reward, err := Staker.Native(env.State()).ClaimReward(env.Caller(), luckyshare.Address(endorsor))
+			if err != nil {
+				panic(err)
+			}
+			if reward.Sign() == 0 {
+				return []interface{}{reward}
+			}
+
+			env.UseGas(luckyshare.SstoreResetGas)
+			env.UseGas(luckyshare.GetBalanceGas * 2)
+			energy := Energy.Native(env.State(), env.BlockContext().Time)
+			ok, err := energy.Sub(Staker.Address, reward)
+			if err != nil {
+				panic(err)
+			}
+			if !ok {
+				// should never happen
+				panic("insufficient energy for delegators' reward")
+			}
+			if err := energy.Add(env.Caller(), reward); err != nil {
+				panic(err)
+			}
+			env.Log(energyTransferEvent, Energy.Address, []luckyshare.Bytes32{
+				luckyshare.BytesToBytes32(Staker.Address.Bytes()),
+				luckyshare.BytesToBytes32(env.Caller().Bytes()),
+			}, reward)
+			logDelegation(env, endorsor, reward, "rewardClaimed")
+			return []interface{}{reward}
+		}},
+		{"reportDoubleSign", func(env *xenv.Environment) []interface{} {
+			var args struct {
+				Header1 []byte
+				Header2 []byte
+			}
+			env.ParseArgs(&args)
+			nonPayable(env)
+
+			env.UseGas(ethparams.EcrecoverGas * 2)
+			nodeMaster, header, ok := verifyDoubleSign(args.Header1, args.Header2, env.BlockContext().Number)
+			if !ok {
+				env.Revert()
+			}
+			parentID := header.ParentID()
+
+			// conflicting blocks should be built on this chain
+			env.UseGas(luckyshare.SloadGas)
+			id, err := env.Chain().GetBlockID(block.Number(parentID))
+			if err != nil {
+				panic(err)
+			}
+			if id != parentID {
+				env.Revert()
+			}
+
+			// each evidence can be reported only once, even if the node master is listed again
+			env.UseGas(luckyshare.SloadGas)
+			staker := Staker.Native(env.State())
+			used, err := staker.IsEvidenceUsed(nodeMaster, parentID, header.Timestamp())
+			if err != nil {
+				panic(err)
+			}
+			if used {
+				env.Revert()
+			}
+
+			env.UseGas(luckyshare.SloadGas * 2)
+			authority := Authority.Native(env.State())
+			listed, endorsor, _, _, err := authority.Get(nodeMaster)
+			if err != nil {
+				panic(err)
+			}
+			if !listed {
+				// already punished
+				env.Revert()
+			}
+
+			env.UseGas(luckyshare.SstoreSetGas)
+			staker.UseEvidence(nodeMaster, parentID, header.Timestamp())
+
+			env.UseGas(luckyshare.SstoreResetGas * 3)
+			if _, err := authority.Revoke(nodeMaster); err != nil {
+				panic(err)
+			}
+			var action luckyshare.Bytes32
+			copy(action[:], "revoked")
+			env.Log(candidateEvent, Authority.Address, []luckyshare.Bytes32{luckyshare.BytesToBytes32(nodeMaster[:])}, action)
+
+			// part of endorsor's stake is redistributed to the reporter
+			env.UseGas(luckyshare.GetBalanceGas)
+			bal, err := env.State().GetBalance(endorsor)
+			if err != nil {
+				panic(err)
+			}
+			slashed := new(big.Int).Mul(bal, new(big.Int).SetUint64(luckyshare.DoubleSignSlashRatio))
+			slashed.Div(slashed, big.NewInt(100))
+			if slashed.Sign() > 0 {
+				env.UseGas(luckyshare.GetBalanceGas)
+				env.Transfer(endorsor, env.Caller(), slashed)
+			}
+			env.Log(doubleSignEvent, Staker.Address, []luckyshare.Bytes32{
+				luckyshare.BytesToBytes32(nodeMaster[:]),
+				luckyshare.BytesToBytes32(endorsor[:]),
+			}, slashed, env.Caller())
+			return []interface{}{slashed}
+		}},
+		{"delegated", func(env *xenv.Environment) []interface{} {
+			var endorsor common.Address
+			env.ParseArgs(&endorsor)
+
+			env.UseGas(luckyshare.SloadGas)
+			delegated, err := Staker.Native(env.State()).Delegated(luckyshare.Address(endorsor))
+			if err != nil {
+				panic(err)
+			}
+			return []interface{}{delegated}
+		}},
+		{"delegation", func(env *xenv.Environment) []interface{} {
+			var args struct {
+				Delegator common.Address
+				Endorsor  common.Address
+			}
+			env.ParseArgs(&args)
+
+			env.UseGas(luckyshare.SloadGas * 2)
+			amount, reward, err := Staker.Native(env.State()).Get(luckyshare.Address(args.Delegator), luckyshare.Address(args.Endorsor))
+			if err != nil {
+				panic(err)
+			}
+			return []interface{}{amount, reward}
+		}},
+		{"unbonding", func(env *xenv.Environment) []interface{} {
+			var delegator common.Address
+			env.ParseArgs(&delegator)
+
+			env.UseGas(luckyshare.SloadGas)
+			amount, releaseTime, err := Staker.Native(env.State()).Unbonding(luckyshare.Address(delegator))
+			if err != nil {
+				panic(err)
+			}
+			return []interface{}{amount, releaseTime}
+		}},
+	}
+	for _, def := range defines {
+		if method, found := Staker.ABI.MethodByName(def.name); found {
+			nativeMethods[methodKey{Staker.Address, method.ID()}] = &nativeMethod{
+				abi: method,
+				run: def.run,
+			}
+		} else {
+			panic("method not found: " + def.name)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package tx
+
+import (
+	"encoding/binary"
+
+	"github.com/miniBamboo/luckyshare/luckyshare"
+)
+
+// ScheduledID returns the id of the index-th scheduled clause execution in the block,
+// which identifies the execution in place of tx id.
+//
+// Scheduled clauses are executed ahead of txs, so receipts of scheduled executions
+// come first in a block, and the receipt at index is of the execution.
+func ScheduledID(blockNum uint32, index int) luckyshare.Bytes32 {
+	var b [12]byte
+	binary.BigEndian.PutUint32(b[:], blockNum)
+	binary.BigEndian.PutUint64(b[4:], uint64(index))
+	return luckyshare.Blake2b([]byte("scheduled"), b[:])
+}
+
+// ExecutionMeta returns id and origin of the execution reported by the i-th receipt of a block,
+// which is either a scheduled execution or a tx.
+func ExecutionMeta(blockNum uint32, txs Transactions, receipts Receipts, i int) (luckyshare.Bytes32, luckyshare.Address) {
+	if scheduled := len(receipts) - len(txs); i >= scheduled {
+		// origin of tx in block is always valid
+		origin, _ := txs[i-scheduled].Origin()
+		return txs[i-scheduled].ID(), origin
+	}
+	// the owner of scheduled clause pays for gas
+	return ScheduledID(blockNum, i), receipts[i].GasPayer
+}
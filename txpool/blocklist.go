@@ -7,25 +7,108 @@ package txpool
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/pkg/errors"
 )
 
+// merge modes of multi-source blocklist.
+const (
+	BlocklistMergeUnion        = "union"
+	BlocklistMergeIntersection = "intersection"
+)
+
+// the suffix appended to source url to locate the detached signature.
+const blocklistSignatureSuffix = ".sig"
+
+// a source failed to be fetched for so long is expired, and its list dropped until fetched again,
+// so that addresses it has since removed don't stay blocked forever.
+const blocklistMaxStale = 24 * time.Hour
+
+// BlocklistSourceInfo describes the state of a blocklist source.
+type BlocklistSourceInfo struct {
+	URL       string
+	Signer    *luckyshare.Address
+	Len       int
+	UpdatedAt time.Time
+	Error     string
+	Expired   bool
+}
+
+// BlocklistInfo describes the active blocklist and where it comes from.
+type BlocklistInfo struct {
+	MergeMode string
+	Addresses []luckyshare.Address
+	Sources   []BlocklistSourceInfo
+}
+
+// blocklistFeed is a remote source of blocklist.
+type blocklistFeed struct {
+	url       string
+	eTag      string
+	list      map[luckyshare.Address]bool // nil if never fetched
+	signer    *luckyshare.Address
+	content   []byte // raw list and its signature, kept for the signed cache
+	sig       []byte
+	updatedAt time.Time
+	checkedAt time.Time // of the last successful fetch, or the creation of the blocklist
+	expired   bool
+	err       error
+}
+
+// signedCacheEntry is a source list cached along with its detached signature.
+type signedCacheEntry struct {
+	URL       string `json:"url"`
+	Content   string `json:"content"`
+	Signature string `json:"signature"`
+}
+
 // blocklist is a address list contains addresses that are blocked.
 type blocklist struct {
-	list map[luckyshare.Address]bool
-	lock sync.Mutex
+	list      map[luckyshare.Address]bool
+	feeds     []*blocklistFeed
+	signers   []luckyshare.Address
+	mergeMode string
+	maxStale  time.Duration
+	lock      sync.Mutex
+}
+
+// newBlocklist create a blocklist merged from given source urls.
+// If signers is not empty, lists are required to be signed by one of them.
+func newBlocklist(urls []string, signers []luckyshare.Address, mergeMode string) *blocklist {
+	if mergeMode != BlocklistMergeIntersection {
+		mergeMode = BlocklistMergeUnion
+	}
+
+	bl := &blocklist{
+		signers:   signers,
+		mergeMode: mergeMode,
+		maxStale:  blocklistMaxStale,
+	}
+	now := time.Now()
+	for _, url := range urls {
+		bl.feeds = append(bl.feeds, &blocklistFeed{url: url, checkedAt: now})
+	}
+	return bl
 }
 
 // Load load list from local file.
+// If signers are set, the file is expected to be saved in signed form, and each
+// cached source list is verified before use.
 func (bl *blocklist) Load(path string) error {
 	file, err := os.Open(path)
 	if err != nil {
@@ -33,6 +116,10 @@ func (bl *blocklist) Load(path string) error {
 	}
 	defer file.Close()
 
+	if len(bl.signers) > 0 {
+		return bl.loadSigned(file)
+	}
+
 	newList, err := bl.readList(file)
 	if err != nil {
 		return err
@@ -45,7 +132,44 @@ func (bl *blocklist) Load(path string) error {
 	return nil
 }
 
+func (bl *blocklist) loadSigned(r io.Reader) error {
+	var entries []signedCacheEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return errors.Wrap(err, "decode signed cache")
+	}
+
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	for _, entry := range entries {
+		for _, feed := range bl.feeds {
+			if feed.url != entry.URL {
+				continue
+			}
+			content := []byte(entry.Content)
+			signer, err := verifyBlocklistSignature(content, []byte(entry.Signature), bl.signers)
+			if err != nil {
+				return errors.WithMessage(err, entry.URL)
+			}
+			list, err := bl.readList(bytes.NewReader(content))
+			if err != nil {
+				return errors.WithMessage(err, entry.URL)
+			}
+			feed.list = list
+			feed.signer = &signer
+			feed.content = content
+			feed.sig = []byte(entry.Signature)
+		}
+	}
+	if list := bl.merge(); list != nil {
+		bl.list = list
+	}
+	return nil
+}
+
 // Save save list to local file.
+// If signers are set, source lists are saved along with their signatures instead
+// of the merged list, so that they can be verified again when loading.
 func (bl *blocklist) Save(path string) error {
 	file, err := os.Create(path)
 	if err != nil {
@@ -53,14 +177,26 @@ func (bl *blocklist) Save(path string) error {
 	}
 	defer file.Close()
 
-	var listToSave []luckyshare.Address
+	var (
+		listToSave []luckyshare.Address
+		entries    []signedCacheEntry
+	)
 
 	bl.lock.Lock()
 	for addr := range bl.list {
 		listToSave = append(listToSave, addr)
 	}
+	for _, feed := range bl.feeds {
+		if feed.content != nil {
+			entries = append(entries, signedCacheEntry{feed.url, string(feed.content), string(feed.sig)})
+		}
+	}
 	bl.lock.Unlock()
 
+	if len(bl.signers) > 0 {
+		return json.NewEncoder(file).Encode(entries)
+	}
+
 	for _, addr := range listToSave {
 		if _, err := file.WriteString(addr.String() + "\n"); err != nil {
 			return err
@@ -69,43 +205,163 @@ func (bl *blocklist) Save(path string) error {
 	return nil
 }
 
-// Fetch fetch list from remote url.
-func (bl *blocklist) Fetch(ctx context.Context, url string, eTag *string) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return err
+// Fetch fetch lists from all sources and merge them.
+// Sources failed to be fetched keep their last fetched lists, until they are expired.
+// It returns whether the merged list is updated.
+func (bl *blocklist) Fetch(ctx context.Context) (bool, error) {
+	var (
+		updated bool
+		errs    []string
+	)
+	for _, feed := range bl.feeds {
+		fetched, err := bl.fetchFeed(ctx, feed.url, feed.eTag)
+		if err == context.Canceled {
+			return false, err
+		}
+
+		bl.lock.Lock()
+		feed.err = err
+		if err == nil {
+			feed.checkedAt = time.Now()
+			feed.expired = false
+		}
+		if fetched != nil {
+			feed.list = fetched.list
+			feed.signer = fetched.signer
+			feed.content = fetched.content
+			feed.sig = fetched.sig
+			feed.eTag = fetched.eTag
+			feed.updatedAt = time.Now()
+			updated = true
+		}
+		bl.lock.Unlock()
+
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%v: %v", feed.url, err))
+		}
 	}
-	if eTag != nil && *eTag != "" {
-		req.Header.Add("if-none-match", *eTag)
+
+	bl.lock.Lock()
+	if bl.expire(time.Now()) {
+		updated = true
 	}
-	resp, err := http.DefaultClient.Do(req)
+	bl.lock.Unlock()
+
+	if updated {
+		bl.lock.Lock()
+		if list := bl.merge(); list != nil {
+			bl.list = list
+		} else {
+			updated = false
+		}
+		bl.lock.Unlock()
+	}
+
+	if len(errs) > 0 {
+		return updated, errors.New(strings.Join(errs, "; "))
+	}
+	return updated, nil
+}
+
+// fetchFeed fetches the list from url. A nil feed returned if not modified.
+func (bl *blocklist) fetchFeed(ctx context.Context, url string, eTag string) (*blocklistFeed, error) {
+	body, newETag, err := httpGet(ctx, url, eTag)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if body == nil {
+		// not modified
+		return nil, nil
 	}
-	defer resp.Body.Close()
-	defer io.Copy(ioutil.Discard, resp.Body)
 
-	if resp.StatusCode == http.StatusNotModified {
-		return nil
+	feed := &blocklistFeed{url: url, eTag: newETag}
+	if len(bl.signers) > 0 {
+		sig, _, err := httpGet(ctx, url+blocklistSignatureSuffix, "")
+		if err != nil {
+			return nil, errors.WithMessage(err, "fetch signature")
+		}
+		signer, err := verifyBlocklistSignature(body, sig, bl.signers)
+		if err != nil {
+			return nil, err
+		}
+		feed.signer = &signer
+		feed.content = body
+		feed.sig = sig
 	}
 
-	if resp.StatusCode/100 != 2 {
-		return fmt.Errorf("status %v", resp.Status)
+	if feed.list, err = bl.readList(bytes.NewReader(body)); err != nil {
+		return nil, err
 	}
+	return feed, nil
+}
 
-	newList, err := bl.readList(resp.Body)
-	if err != nil {
-		return err
+// expire drops lists of sources that failed to be fetched for longer than maxStale.
+// Should be called with lock held. It returns whether any source is newly expired.
+func (bl *blocklist) expire(now time.Time) bool {
+	var expired bool
+	for _, feed := range bl.feeds {
+		if feed.expired || feed.err == nil || now.Sub(feed.checkedAt) <= bl.maxStale {
+			continue
+		}
+		log.Warn("blocklist source expired", "url", feed.url, "since", feed.checkedAt, "error", feed.err)
+		feed.expired = true
+		feed.list = nil
+		feed.signer = nil
+		feed.content = nil
+		feed.sig = nil
+		// to fetch the full list again
+		feed.eTag = ""
+		expired = true
 	}
+	return expired
+}
 
-	bl.lock.Lock()
-	bl.list = newList
-	bl.lock.Unlock()
+// merge merges lists of feeds. Should be called with lock held.
+// Sources that have never been fetched are represented by the previous list, so that
+// a single available source can't decide the list in intersection mode.
+// Expired sources are left out.
+// It returns nil if the list can't be merged yet.
+func (bl *blocklist) merge() map[luckyshare.Address]bool {
+	merged := make(map[luckyshare.Address]bool)
+	var lists []map[luckyshare.Address]bool
+	for _, feed := range bl.feeds {
+		switch {
+		case feed.expired:
+			// left out
+		case feed.list != nil:
+			lists = append(lists, feed.list)
+		case bl.list != nil:
+			lists = append(lists, bl.list)
+		case bl.mergeMode == BlocklistMergeIntersection:
+			return nil
+		}
+	}
+	if len(lists) == 0 {
+		return merged
+	}
 
-	if eTag != nil {
-		*eTag = resp.Header.Get("etag")
+	if bl.mergeMode == BlocklistMergeIntersection {
+		for addr := range lists[0] {
+			all := true
+			for _, list := range lists[1:] {
+				if !list[addr] {
+					all = false
+					break
+				}
+			}
+			if all {
+				merged[addr] = true
+			}
+		}
+		return merged
 	}
-	return nil
+
+	for _, list := range lists {
+		for addr := range list {
+			merged[addr] = true
+		}
+	}
+	return merged
 }
 
 // Contains returns whether the given address is listed.
@@ -123,6 +379,38 @@ func (bl *blocklist) Len() int {
 	return len(bl.list)
 }
 
+// Info returns the active list and the state of sources.
+func (bl *blocklist) Info() *BlocklistInfo {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	info := &BlocklistInfo{
+		MergeMode: bl.mergeMode,
+		Addresses: make([]luckyshare.Address, 0, len(bl.list)),
+	}
+	for addr := range bl.list {
+		info.Addresses = append(info.Addresses, addr)
+	}
+	sort.Slice(info.Addresses, func(i, j int) bool {
+		return bytes.Compare(info.Addresses[i].Bytes(), info.Addresses[j].Bytes()) < 0
+	})
+
+	for _, feed := range bl.feeds {
+		src := BlocklistSourceInfo{
+			URL:       feed.url,
+			Signer:    feed.signer,
+			Len:       len(feed.list),
+			UpdatedAt: feed.updatedAt,
+			Expired:   feed.expired,
+		}
+		if feed.err != nil {
+			src.Error = feed.err.Error()
+		}
+		info.Sources = append(info.Sources, src)
+	}
+	return info
+}
+
 func (bl *blocklist) readList(r io.Reader) (map[luckyshare.Address]bool, error) {
 	scanner := bufio.NewScanner(r)
 	list := make(map[luckyshare.Address]bool)
@@ -143,3 +431,63 @@ func (bl *blocklist) readList(r io.Reader) (map[luckyshare.Address]bool, error)
 	}
 	return list, nil
 }
+
+// verifyBlocklistSignature verifies the detached signature, which is a hex encoded
+// 65 bytes secp256k1 signature of the blake2b hash of the list content.
+func verifyBlocklistSignature(body []byte, sig []byte, signers []luckyshare.Address) (luckyshare.Address, error) {
+	rawSig, err := hexutil.Decode(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return luckyshare.Address{}, errors.WithMessage(err, "decode signature")
+	}
+	if len(rawSig) != 65 {
+		return luckyshare.Address{}, errors.New("invalid signature length")
+	}
+	pub, err := crypto.SigToPub(luckyshare.Blake2b(body).Bytes(), rawSig)
+	if err != nil {
+		return luckyshare.Address{}, errors.WithMessage(err, "recover signer")
+	}
+	signer := luckyshare.Address(crypto.PubkeyToAddress(*pub))
+	for _, s := range signers {
+		if s == signer {
+			return signer, nil
+		}
+	}
+	return luckyshare.Address{}, fmt.Errorf("untrusted signer %v", signer)
+}
+
+// httpGet gets content of url. A nil body returned if not modified.
+func httpGet(ctx context.Context, url string, eTag string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if eTag != "" {
+		req.Header.Add("if-none-match", eTag)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			return nil, "", context.Canceled
+		}
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	defer io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, eTag, nil
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return nil, "", fmt.Errorf("status %v", resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if body == nil {
+		body = []byte{}
+	}
+	return body, resp.Header.Get("etag"), nil
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package txpool
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miniBamboo/luckyshare/genesis"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBlocklistServer(lists map[string]string, signer genesis.DevAccount) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if strings.HasSuffix(path, blocklistSignatureSuffix) {
+			body, ok := lists[strings.TrimSuffix(path, blocklistSignatureSuffix)]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			sig, _ := crypto.Sign(luckyshare.Blake2b([]byte(body)).Bytes(), signer.PrivateKey)
+			w.Write([]byte(hexutil.Encode(sig)))
+			return
+		}
+		body, ok := lists[path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		eTag := luckyshare.Blake2b([]byte(body)).String()
+		if r.Header.Get("if-none-match") == eTag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("etag", eTag)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestBlocklistMerge(t *testing.T) {
+	addr1 := luckyshare.BytesToAddress([]byte{1})
+	addr2 := luckyshare.BytesToAddress([]byte{2})
+	addr3 := luckyshare.BytesToAddress([]byte{3})
+
+	srv := newBlocklistServer(map[string]string{
+		"/a": addr1.String() + "\n" + addr2.String() + "\n",
+		"/b": addr2.String() + "\n" + addr3.String() + "\n",
+	}, genesis.DevAccounts()[0])
+	defer srv.Close()
+
+	union := newBlocklist([]string{srv.URL + "/a", srv.URL + "/b"}, nil, "")
+	updated, err := union.Fetch(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, 3, union.Len())
+	assert.True(t, union.Contains(addr1))
+	assert.True(t, union.Contains(addr3))
+
+	// not modified
+	updated, err = union.Fetch(context.Background())
+	assert.Nil(t, err)
+	assert.False(t, updated)
+	assert.Equal(t, 3, union.Len())
+
+	intersection := newBlocklist([]string{srv.URL + "/a", srv.URL + "/b"}, nil, BlocklistMergeIntersection)
+	_, err = intersection.Fetch(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, intersection.Len())
+	assert.True(t, intersection.Contains(addr2))
+
+	info := intersection.Info()
+	assert.Equal(t, BlocklistMergeIntersection, info.MergeMode)
+	assert.Equal(t, []luckyshare.Address{addr2}, info.Addresses)
+	assert.Equal(t, 2, len(info.Sources))
+	assert.Equal(t, 2, info.Sources[0].Len)
+	assert.Nil(t, info.Sources[0].Signer)
+}
+
+func TestBlocklistFailedSource(t *testing.T) {
+	addr1 := luckyshare.BytesToAddress([]byte{1})
+	addr2 := luckyshare.BytesToAddress([]byte{2})
+	addr3 := luckyshare.BytesToAddress([]byte{3})
+
+	srv := newBlocklistServer(map[string]string{
+		"/a": addr1.String() + "\n" + addr2.String() + "\n",
+	}, genesis.DevAccounts()[0])
+	defer srv.Close()
+
+	bl := newBlocklist([]string{srv.URL + "/a", srv.URL + "/missing"}, nil, BlocklistMergeIntersection)
+	updated, err := bl.Fetch(context.Background())
+	assert.NotNil(t, err)
+	// a single available source can't decide the list
+	assert.False(t, updated)
+	assert.False(t, bl.Contains(addr1))
+
+	info := bl.Info()
+	assert.Equal(t, "", info.Sources[0].Error)
+	assert.NotEqual(t, "", info.Sources[1].Error)
+
+	// the previous list stands in for the failed source
+	dir, err := ioutil.TempDir("", "blocklist")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cache")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(addr1.String()+"\n"+addr3.String()+"\n"), 0600))
+
+	bl = newBlocklist([]string{srv.URL + "/a", srv.URL + "/missing"}, nil, BlocklistMergeIntersection)
+	assert.Nil(t, bl.Load(path))
+	updated, err = bl.Fetch(context.Background())
+	assert.NotNil(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, []luckyshare.Address{addr1}, bl.Info().Addresses)
+}
+
+func TestBlocklistExpiredSource(t *testing.T) {
+	addr1 := luckyshare.BytesToAddress([]byte{1})
+	addr2 := luckyshare.BytesToAddress([]byte{2})
+
+	srv := newBlocklistServer(map[string]string{
+		"/a": addr1.String() + "\n",
+	}, genesis.DevAccounts()[0])
+	defer srv.Close()
+
+	var failing int32
+	srvB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(addr2.String() + "\n"))
+	}))
+	defer srvB.Close()
+
+	bl := newBlocklist([]string{srv.URL + "/a", srvB.URL + "/b"}, nil, "")
+	_, err := bl.Fetch(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, bl.Contains(addr2))
+
+	// the failed source keeps its list for a while
+	atomic.StoreInt32(&failing, 1)
+	_, err = bl.Fetch(context.Background())
+	assert.NotNil(t, err)
+	assert.True(t, bl.Contains(addr2))
+	assert.False(t, bl.Info().Sources[1].Expired)
+
+	// then expired
+	bl.feeds[1].checkedAt = bl.feeds[1].checkedAt.Add(-blocklistMaxStale - time.Minute)
+	updated, err := bl.Fetch(context.Background())
+	assert.NotNil(t, err)
+	assert.True(t, updated)
+	assert.True(t, bl.Contains(addr1))
+	assert.False(t, bl.Contains(addr2))
+	assert.True(t, bl.Info().Sources[1].Expired)
+
+	// and restored once fetched again
+	atomic.StoreInt32(&failing, 0)
+	updated, err = bl.Fetch(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, updated)
+	assert.True(t, bl.Contains(addr2))
+	assert.False(t, bl.Info().Sources[1].Expired)
+}
+
+func TestBlocklistSigned(t *testing.T) {
+	addr1 := luckyshare.BytesToAddress([]byte{1})
+	signer := genesis.DevAccounts()[0]
+
+	srv := newBlocklistServer(map[string]string{
+		"/a": addr1.String() + "\n",
+	}, signer)
+	defer srv.Close()
+
+	trusted := newBlocklist([]string{srv.URL + "/a"}, []luckyshare.Address{signer.Address}, "")
+	_, err := trusted.Fetch(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, trusted.Contains(addr1))
+	assert.Equal(t, &signer.Address, trusted.Info().Sources[0].Signer)
+
+	untrusted := newBlocklist([]string{srv.URL + "/a"}, []luckyshare.Address{genesis.DevAccounts()[1].Address}, "")
+	updated, err := untrusted.Fetch(context.Background())
+	assert.NotNil(t, err)
+	assert.False(t, updated)
+	assert.False(t, untrusted.Contains(addr1))
+}
+
+func TestBlocklistSignedCache(t *testing.T) {
+	addr1 := luckyshare.BytesToAddress([]byte{1})
+	signer := genesis.DevAccounts()[0]
+	signers := []luckyshare.Address{signer.Address}
+
+	srv := newBlocklistServer(map[string]string{
+		"/a": addr1.String() + "\n",
+	}, signer)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "blocklist")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cache")
+
+	bl := newBlocklist([]string{srv.URL + "/a"}, signers, "")
+	_, err = bl.Fetch(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, bl.Save(path))
+
+	loaded := newBlocklist([]string{srv.URL + "/a"}, signers, "")
+	assert.Nil(t, loaded.Load(path))
+	assert.True(t, loaded.Contains(addr1))
+	assert.Equal(t, &signer.Address, loaded.Info().Sources[0].Signer)
+
+	// cache signed by others
+	loaded = newBlocklist([]string{srv.URL + "/a"}, []luckyshare.Address{genesis.DevAccounts()[1].Address}, "")
+	assert.NotNil(t, loaded.Load(path))
+	assert.False(t, loaded.Contains(addr1))
+
+	// tampered cache
+	data, _ := ioutil.ReadFile(path)
+	addr2 := luckyshare.BytesToAddress([]byte{2})
+	assert.Nil(t, ioutil.WriteFile(path, []byte(strings.Replace(string(data), addr1.String(), addr2.String(), 1)), 0600))
+	loaded = newBlocklist([]string{srv.URL + "/a"}, signers, "")
+	assert.NotNil(t, loaded.Load(path))
+	assert.False(t, loaded.Contains(addr2))
+
+	// unsigned cache
+	assert.Nil(t, ioutil.WriteFile(path, []byte(addr2.String()+"\n"), 0600))
+	assert.NotNil(t, loaded.Load(path))
+	assert.False(t, loaded.Contains(addr2))
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package txpool
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/tx"
+)
+
+const (
+	// max count of txs in a bundle
+	maxBundleTxs = 16
+	// max count of bundles in pool
+	maxBundles = 1000
+	// max count of bundles in pool sent by the same origin
+	maxBundlesPerOrigin = 16
+	// max count of blocks a bundle can be packed in
+	maxBundleBlockRange = 30
+	// max times a bundle can fail to be adopted before dropped
+	maxBundleFailures = 3
+)
+
+// Bundle is an ordered group of txs, which are packed together in the same block or not at all.
+type Bundle struct {
+	Txs      tx.Transactions
+	MinBlock uint32 // the lowest block number the bundle can be packed in
+	MaxBlock uint32 // the highest block number the bundle can be packed in
+
+	timeAdded int64
+	origins   []luckyshare.Address // distinct origins of txs
+	failures  int
+}
+
+// ID returns the bundle ID, which is the hash of tx IDs.
+func (b *Bundle) ID() luckyshare.Bytes32 {
+	hw := luckyshare.NewBlake2b()
+	for _, tx := range b.Txs {
+		id := tx.ID()
+		hw.Write(id[:])
+	}
+	var id luckyshare.Bytes32
+	hw.Sum(id[:0])
+	return id
+}
+
+// Gas returns total gas of txs.
+func (b *Bundle) Gas() (gas uint64) {
+	for _, tx := range b.Txs {
+		gas += tx.Gas()
+	}
+	return
+}
+
+// bundleMap to maintain bundles by ID, and origin quota.
+type bundleMap struct {
+	lock    sync.RWMutex
+	bundles map[luckyshare.Bytes32]*Bundle
+	quota   map[luckyshare.Address]int
+}
+
+func newBundleMap() *bundleMap {
+	return &bundleMap{
+		bundles: make(map[luckyshare.Bytes32]*Bundle),
+		quota:   make(map[luckyshare.Address]int),
+	}
+}
+
+func (m *bundleMap) Add(b *Bundle) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	id := b.ID()
+	if _, found := m.bundles[id]; found {
+		return nil
+	}
+	if len(m.bundles) >= maxBundles {
+		return errors.New("bundle pool is full")
+	}
+	for _, origin := range b.origins {
+		if m.quota[origin] >= maxBundlesPerOrigin {
+			return errors.New("account quota exceeded")
+		}
+	}
+
+	for _, origin := range b.origins {
+		m.quota[origin]++
+	}
+	b.timeAdded = time.Now().UnixNano()
+	m.bundles[id] = b
+	return nil
+}
+
+func (m *bundleMap) Get(id luckyshare.Bytes32) *Bundle {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return m.bundles[id]
+}
+
+func (m *bundleMap) Remove(id luckyshare.Bytes32) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if b, found := m.bundles[id]; found {
+		m.remove(id, b)
+		return true
+	}
+	return false
+}
+
+// Fail counts a failed adoption of the bundle, and removes it once failed too many times.
+// It returns true if the bundle is removed.
+func (m *bundleMap) Fail(id luckyshare.Bytes32) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if b, found := m.bundles[id]; found {
+		b.failures++
+		if b.failures >= maxBundleFailures {
+			m.remove(id, b)
+			return true
+		}
+	}
+	return false
+}
+
+func (m *bundleMap) remove(id luckyshare.Bytes32, b *Bundle) {
+	for _, origin := range b.origins {
+		if m.quota[origin] > 1 {
+			m.quota[origin]--
+		} else {
+			delete(m.quota, origin)
+		}
+	}
+	delete(m.bundles, id)
+}
+
+func (m *bundleMap) Len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return len(m.bundles)
+}
+
+// ToBundles returns bundles in the order they were added.
+func (m *bundleMap) ToBundles() []*Bundle {
+	m.lock.RLock()
+	bundles := make([]*Bundle, 0, len(m.bundles))
+	for _, b := range m.bundles {
+		bundles = append(bundles, b)
+	}
+	m.lock.RUnlock()
+
+	sort.Slice(bundles, func(i, j int) bool {
+		return bundles[i].timeAdded < bundles[j].timeAdded
+	})
+	return bundles
+}
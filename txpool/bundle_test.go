@@ -0,0 +1,115 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package txpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/genesis"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddBundle(t *testing.T) {
+	pool := newPool(LIMIT, LIMIT_PER_ACCOUNT)
+	defer pool.Close()
+
+	accs := genesis.DevAccounts()
+	chainTag := pool.repo.ChainTag()
+	tx1 := newTx(chainTag, nil, 21000, tx.BlockRef{}, 100, nil, tx.Features(0), accs[0])
+	tx2 := newTx(chainTag, nil, 21000, tx.BlockRef{}, 100, nil, tx.Features(0), accs[1])
+
+	key, _ := crypto.GenerateKey()
+	poor := genesis.DevAccount{Address: luckyshare.Address(crypto.PubkeyToAddress(key.PublicKey)), PrivateKey: key}
+
+	tests := []struct {
+		bundle *Bundle
+		errStr string
+	}{
+		{&Bundle{MinBlock: 1, MaxBlock: 2}, "bad tx: empty bundle"},
+		{&Bundle{Txs: tx.Transactions{tx1}, MinBlock: 2, MaxBlock: 1}, "bad tx: invalid block range"},
+		{&Bundle{Txs: tx.Transactions{tx1}, MinBlock: 0, MaxBlock: 0}, "tx rejected: bundle expired"},
+		{&Bundle{Txs: tx.Transactions{tx1}, MinBlock: 100, MaxBlock: 100}, "tx rejected: block range out of schedule"},
+		{&Bundle{Txs: tx.Transactions{tx1}, MinBlock: 1, MaxBlock: 31}, "tx rejected: block range too large"},
+		{&Bundle{Txs: tx.Transactions{tx1, tx1}, MinBlock: 1, MaxBlock: 2}, "bad tx: tx #1: duplicated"},
+		{&Bundle{Txs: tx.Transactions{tx1, newTx(chainTag+1, nil, 21000, tx.BlockRef{}, 100, nil, tx.Features(0), accs[1])}, MinBlock: 1, MaxBlock: 2}, "bad tx: tx #1: chain tag mismatch"},
+		{&Bundle{Txs: tx.Transactions{tx1, newTx(chainTag, nil, 21000, tx.BlockRef{}, 100, nil, tx.Features(0), poor)}, MinBlock: 1, MaxBlock: 2}, "tx rejected: tx #1: insufficient energy"},
+		{&Bundle{Txs: tx.Transactions{tx1, tx2}, MinBlock: 1, MaxBlock: 2}, ""},
+	}
+
+	for _, tt := range tests {
+		err := pool.AddBundle(tt.bundle)
+		if tt.errStr == "" {
+			assert.Nil(t, err)
+		} else {
+			assert.EqualError(t, err, tt.errStr)
+		}
+	}
+
+	bundle := &Bundle{Txs: tx.Transactions{tx1, tx2}}
+	assert.NotNil(t, pool.GetBundle(bundle.ID()))
+	assert.Equal(t, 1, len(pool.Bundles(1)))
+	assert.Equal(t, 1, len(pool.Bundles(2)))
+	assert.Equal(t, 0, len(pool.Bundles(3)))
+	// bundle txs are not in the tx pool
+	assert.Nil(t, pool.Get(tx1.ID()))
+}
+
+func TestWashBundles(t *testing.T) {
+	pool := newPool(LIMIT, LIMIT_PER_ACCOUNT)
+	defer pool.Close()
+
+	accs := genesis.DevAccounts()
+	chainTag := pool.repo.ChainTag()
+	tx1 := newTx(chainTag, nil, 21000, tx.BlockRef{}, 100, nil, tx.Features(0), accs[0])
+	tx2 := newTx(chainTag, nil, 21000, tx.BlockRef{}, 100, nil, tx.Features(0), accs[1])
+
+	assert.Nil(t, pool.AddBundle(&Bundle{Txs: tx.Transactions{tx1}, MinBlock: 1, MaxBlock: 1}))
+	assert.Nil(t, pool.AddBundle(&Bundle{Txs: tx.Transactions{tx2}, MinBlock: 1, MaxBlock: 2}))
+	assert.Equal(t, 0, pool.washBundles(pool.repo.BestBlock().Header()))
+
+	b1 := new(block.Builder).
+		ParentID(pool.repo.GenesisBlock().Header().ID()).
+		Timestamp(uint64(time.Now().Unix())).
+		TotalScore(100).
+		GasLimit(10000000).
+		StateRoot(pool.repo.GenesisBlock().Header().StateRoot()).
+		Build()
+	pool.repo.AddBlock(b1, nil)
+
+	// the first one expired
+	assert.Equal(t, 1, pool.washBundles(b1.Header()))
+	assert.Equal(t, 1, len(pool.Bundles(2)))
+}
+
+func TestBundleLimits(t *testing.T) {
+	pool := newPool(LIMIT, LIMIT_PER_ACCOUNT)
+	defer pool.Close()
+
+	accs := genesis.DevAccounts()
+	chainTag := pool.repo.ChainTag()
+
+	for i := 0; i < maxBundlesPerOrigin; i++ {
+		trx := newTx(chainTag, nil, 21000, tx.BlockRef{}, 100, nil, tx.Features(0), accs[0])
+		assert.Nil(t, pool.AddBundle(&Bundle{Txs: tx.Transactions{trx}, MinBlock: 1, MaxBlock: 2}))
+	}
+	trx := newTx(chainTag, nil, 21000, tx.BlockRef{}, 100, nil, tx.Features(0), accs[0])
+	assert.EqualError(t, pool.AddBundle(&Bundle{Txs: tx.Transactions{trx}, MinBlock: 1, MaxBlock: 2}), "tx rejected: account quota exceeded")
+
+	// dropped after failed several times, and the quota released
+	id := pool.Bundles(1)[0].ID()
+	for i := 1; i < maxBundleFailures; i++ {
+		assert.False(t, pool.FailBundle(id))
+		assert.NotNil(t, pool.GetBundle(id))
+	}
+	assert.True(t, pool.FailBundle(id))
+	assert.Nil(t, pool.GetBundle(id))
+	assert.Nil(t, pool.AddBundle(&Bundle{Txs: tx.Transactions{trx}, MinBlock: 1, MaxBlock: 2}))
+}
@@ -0,0 +1,391 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package txpool
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/pkg/errors"
+)
+
+// Policy is an admission rule applied to txs before they enter the pool.
+type Policy interface {
+	// Name returns the policy type name.
+	Name() string
+	// Check returns an error describing the reason if the tx should be rejected.
+	Check(tx *tx.Transaction, origin luckyshare.Address) error
+}
+
+// Reserver is optionally implemented by policies which keep state of
+// admitted txs, e.g. quotas. Such state is reserved in Check, so concurrent adds
+// can't pass it together, and Release is called if the tx passed the policy but is
+// finally rejected, so rejected txs never consume such state.
+type Reserver interface {
+	Release(tx *tx.Transaction, origin luckyshare.Address)
+}
+
+// Inheritor is optionally implemented by policies which keep state, to take over
+// the state of the policy of the same type it replaces when policies are reloaded.
+type Inheritor interface {
+	Inherit(prev Policy)
+}
+
+// PolicyFactory creates a policy from its raw config entry.
+type PolicyFactory func(config json.RawMessage) (Policy, error)
+
+var (
+	policyFactoriesLock sync.Mutex
+	policyFactories     = map[string]PolicyFactory{
+		"minGasPriceCoef":   newMinGasPriceCoefPolicy,
+		"maxClauses":        newMaxClausesPolicy,
+		"allowTargets":      newAllowTargetsPolicy,
+		"denyTargets":       newDenyTargetsPolicy,
+		"rateLimit":         newRateLimitPolicy,
+		"requireDelegation": newRequireDelegationPolicy,
+	}
+)
+
+// RegisterPolicy registers a policy factory with the given type name,
+// which can then be referenced in policy files.
+func RegisterPolicy(name string, factory PolicyFactory) {
+	policyFactoriesLock.Lock()
+	defer policyFactoriesLock.Unlock()
+
+	policyFactories[name] = factory
+}
+
+// PolicyChain is an ordered list of policies. A tx is admitted only if all policies pass.
+type PolicyChain []Policy
+
+// Check applies policies in order and returns the first rejection.
+// If it passes, Release should be called in case the tx is rejected afterwards.
+func (pc PolicyChain) Check(tx *tx.Transaction, origin luckyshare.Address) error {
+	for i, p := range pc {
+		if err := p.Check(tx, origin); err != nil {
+			pc[:i].Release(tx, origin)
+			return errors.WithMessage(err, p.Name())
+		}
+	}
+	return nil
+}
+
+// Release notifies policies that the tx passed Check is rejected.
+func (pc PolicyChain) Release(tx *tx.Transaction, origin luckyshare.Address) {
+	for _, p := range pc {
+		if r, ok := p.(Reserver); ok {
+			r.Release(tx, origin)
+		}
+	}
+}
+
+// inherit lets policies take over the state of those of the same type in prev, paired in order.
+func (pc PolicyChain) inherit(prev PolicyChain) {
+	used := make([]bool, len(prev))
+	for _, p := range pc {
+		inheritor, ok := p.(Inheritor)
+		if !ok {
+			continue
+		}
+		for i, old := range prev {
+			if !used[i] && old.Name() == p.Name() {
+				used[i] = true
+				inheritor.Inherit(old)
+				break
+			}
+		}
+	}
+}
+
+// LoadPolicies reads policy chain from a JSON file.
+func LoadPolicies(path string) (PolicyChain, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadPolicies(file)
+}
+
+// ReadPolicies decodes policy chain from JSON, which is an array of objects with
+// a 'type' field and type specific fields, e.g.
+//
+//	[
+//	  {"type": "minGasPriceCoef", "value": 20},
+//	  {"type": "rateLimit", "count": 10, "period": "1m"}
+//	]
+func ReadPolicies(r io.Reader) (PolicyChain, error) {
+	var entries []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, errors.Wrap(err, "decode policies")
+	}
+
+	chain := make(PolicyChain, 0, len(entries))
+	for i, entry := range entries {
+		var head struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(entry, &head); err != nil {
+			return nil, errors.Wrapf(err, "policy #%v", i)
+		}
+
+		policyFactoriesLock.Lock()
+		factory := policyFactories[head.Type]
+		policyFactoriesLock.Unlock()
+
+		if factory == nil {
+			return nil, fmt.Errorf("policy #%v: unknown type %q", i, head.Type)
+		}
+		policy, err := factory(entry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "policy #%v(%v)", i, head.Type)
+		}
+		chain = append(chain, policy)
+	}
+	return chain, nil
+}
+
+type minGasPriceCoefPolicy struct {
+	Value uint8 `json:"value"`
+}
+
+func newMinGasPriceCoefPolicy(config json.RawMessage) (Policy, error) {
+	var p minGasPriceCoefPolicy
+	if err := json.Unmarshal(config, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (p *minGasPriceCoefPolicy) Name() string { return "minGasPriceCoef" }
+
+func (p *minGasPriceCoefPolicy) Check(tx *tx.Transaction, origin luckyshare.Address) error {
+	if tx.GasPriceCoef() < p.Value {
+		return fmt.Errorf("gas price coef %v less than %v", tx.GasPriceCoef(), p.Value)
+	}
+	return nil
+}
+
+type maxClausesPolicy struct {
+	Value int `json:"value"`
+}
+
+func newMaxClausesPolicy(config json.RawMessage) (Policy, error) {
+	var p maxClausesPolicy
+	if err := json.Unmarshal(config, &p); err != nil {
+		return nil, err
+	}
+	if p.Value <= 0 {
+		return nil, errors.New("value should be positive")
+	}
+	return &p, nil
+}
+
+func (p *maxClausesPolicy) Name() string { return "maxClauses" }
+
+func (p *maxClausesPolicy) Check(tx *tx.Transaction, origin luckyshare.Address) error {
+	if n := len(tx.Clauses()); n > p.Value {
+		return fmt.Errorf("clause count %v exceeds %v", n, p.Value)
+	}
+	return nil
+}
+
+// addressSet decodes 'addresses' field of a policy config entry.
+type addressSet map[luckyshare.Address]bool
+
+func readAddressSet(config json.RawMessage) (addressSet, error) {
+	var obj struct {
+		Addresses []luckyshare.Address `json:"addresses"`
+	}
+	if err := json.Unmarshal(config, &obj); err != nil {
+		return nil, err
+	}
+	set := make(addressSet, len(obj.Addresses))
+	for _, addr := range obj.Addresses {
+		set[addr] = true
+	}
+	return set, nil
+}
+
+type allowTargetsPolicy struct {
+	targets addressSet
+}
+
+func newAllowTargetsPolicy(config json.RawMessage) (Policy, error) {
+	targets, err := readAddressSet(config)
+	if err != nil {
+		return nil, err
+	}
+	return &allowTargetsPolicy{targets}, nil
+}
+
+func (p *allowTargetsPolicy) Name() string { return "allowTargets" }
+
+func (p *allowTargetsPolicy) Check(tx *tx.Transaction, origin luckyshare.Address) error {
+	for i, clause := range tx.Clauses() {
+		to := clause.To()
+		if to == nil {
+			return fmt.Errorf("clause #%v: contract creation not allowed", i)
+		}
+		if !p.targets[*to] {
+			return fmt.Errorf("clause #%v: target %v not allowed", i, to)
+		}
+	}
+	return nil
+}
+
+type denyTargetsPolicy struct {
+	targets addressSet
+}
+
+func newDenyTargetsPolicy(config json.RawMessage) (Policy, error) {
+	targets, err := readAddressSet(config)
+	if err != nil {
+		return nil, err
+	}
+	return &denyTargetsPolicy{targets}, nil
+}
+
+func (p *denyTargetsPolicy) Name() string { return "denyTargets" }
+
+func (p *denyTargetsPolicy) Check(tx *tx.Transaction, origin luckyshare.Address) error {
+	for i, clause := range tx.Clauses() {
+		if to := clause.To(); to != nil && p.targets[*to] {
+			return fmt.Errorf("clause #%v: target %v denied", i, to)
+		}
+	}
+	return nil
+}
+
+type requireDelegationPolicy struct {
+	origins addressSet
+}
+
+func newRequireDelegationPolicy(config json.RawMessage) (Policy, error) {
+	origins, err := readAddressSet(config)
+	if err != nil {
+		return nil, err
+	}
+	return &requireDelegationPolicy{origins}, nil
+}
+
+func (p *requireDelegationPolicy) Name() string { return "requireDelegation" }
+
+func (p *requireDelegationPolicy) Check(tx *tx.Transaction, origin luckyshare.Address) error {
+	if !p.origins[origin] {
+		return nil
+	}
+	delegator, err := tx.Delegator()
+	if err != nil {
+		return err
+	}
+	if delegator == nil {
+		return errors.New("delegation required")
+	}
+	return nil
+}
+
+// rateLimitPolicy limits the count of txs per origin in a fixed time window.
+// Quota is reserved on check and released if the tx is rejected, so only admitted txs are counted.
+type rateLimitPolicy struct {
+	count  int
+	period time.Duration
+
+	state *rateState
+	now   func() time.Time
+}
+
+// rateState is the windows of origins, which is taken over by the policy reloaded.
+type rateState struct {
+	windows   map[luckyshare.Address]*rateWindow
+	lastPrune time.Time
+	lock      sync.Mutex
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateLimitPolicy(config json.RawMessage) (Policy, error) {
+	var obj struct {
+		Count  int    `json:"count"`
+		Period string `json:"period"`
+	}
+	if err := json.Unmarshal(config, &obj); err != nil {
+		return nil, err
+	}
+	if obj.Count <= 0 {
+		return nil, errors.New("count should be positive")
+	}
+	period, err := time.ParseDuration(obj.Period)
+	if err != nil {
+		return nil, errors.Wrap(err, "period")
+	}
+	if period <= 0 {
+		return nil, errors.New("period should be positive")
+	}
+	return &rateLimitPolicy{
+		count:  obj.Count,
+		period: period,
+		state:  &rateState{windows: make(map[luckyshare.Address]*rateWindow)},
+		now:    time.Now,
+	}, nil
+}
+
+func (p *rateLimitPolicy) Name() string { return "rateLimit" }
+
+func (p *rateLimitPolicy) Check(tx *tx.Transaction, origin luckyshare.Address) error {
+	s := p.state
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := p.now()
+	if now.Sub(s.lastPrune) > p.period {
+		for addr, w := range s.windows {
+			if now.Sub(w.start) > p.period {
+				delete(s.windows, addr)
+			}
+		}
+		s.lastPrune = now
+	}
+
+	w := s.windows[origin]
+	if w == nil || now.Sub(w.start) > p.period {
+		w = &rateWindow{start: now}
+		s.windows[origin] = w
+	}
+	if w.count >= p.count {
+		return fmt.Errorf("origin exceeds %v txs per %v", p.count, p.period)
+	}
+	w.count++
+	return nil
+}
+
+func (p *rateLimitPolicy) Release(tx *tx.Transaction, origin luckyshare.Address) {
+	s := p.state
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if w := s.windows[origin]; w != nil && w.count > 0 {
+		w.count--
+	}
+}
+
+// Inherit takes over windows of the previous rate limit policy, so reloading doesn't reset quotas.
+// The state is shared rather than copied, since txs checked by the previous one may still be released.
+func (p *rateLimitPolicy) Inherit(prev Policy) {
+	if old, ok := prev.(*rateLimitPolicy); ok {
+		p.state = old.state
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package txpool
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miniBamboo/luckyshare/block"
+	"github.com/miniBamboo/luckyshare/genesis"
+	"github.com/miniBamboo/luckyshare/luckyshare"
+	"github.com/miniBamboo/luckyshare/tx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadPolicies(t *testing.T) {
+	_, err := ReadPolicies(strings.NewReader(`[{"type": "foo"}]`))
+	assert.EqualError(t, err, `policy #0: unknown type "foo"`)
+
+	_, err = ReadPolicies(strings.NewReader(`[{"type": "rateLimit", "count": 1, "period": "x"}]`))
+	assert.NotNil(t, err)
+
+	chain, err := ReadPolicies(strings.NewReader(`[
+		{"type": "minGasPriceCoef", "value": 10},
+		{"type": "maxClauses", "value": 2},
+		{"type": "denyTargets", "addresses": ["0x0000000000000000000000000000000000000001"]},
+		{"type": "requireDelegation", "addresses": []},
+		{"type": "rateLimit", "count": 10, "period": "1m"}
+	]`))
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(chain))
+	assert.Equal(t, "minGasPriceCoef", chain[0].Name())
+	assert.Equal(t, "rateLimit", chain[4].Name())
+}
+
+func TestPolicies(t *testing.T) {
+	acc := genesis.DevAccounts()[0]
+	to1 := luckyshare.BytesToAddress([]byte{1})
+	to2 := luckyshare.BytesToAddress([]byte{2})
+
+	build := func(coef uint8, to ...luckyshare.Address) *tx.Transaction {
+		builder := new(tx.Builder).GasPriceCoef(coef).Gas(1000000)
+		for i := range to {
+			builder.Clause(tx.NewClause(&to[i]))
+		}
+		return signTx(builder.Build(), acc)
+	}
+
+	tests := []struct {
+		config string
+		tx     *tx.Transaction
+		errStr string
+	}{
+		{`[{"type": "minGasPriceCoef", "value": 10}]`, build(10), ""},
+		{`[{"type": "minGasPriceCoef", "value": 10}]`, build(9), "minGasPriceCoef: gas price coef 9 less than 10"},
+		{`[{"type": "maxClauses", "value": 1}]`, build(0, to1), ""},
+		{`[{"type": "maxClauses", "value": 1}]`, build(0, to1, to2), "maxClauses: clause count 2 exceeds 1"},
+		{`[{"type": "allowTargets", "addresses": ["` + to1.String() + `"]}]`, build(0, to1), ""},
+		{`[{"type": "allowTargets", "addresses": ["` + to1.String() + `"]}]`, build(0, to1, to2), "allowTargets: clause #1: target " + to2.String() + " not allowed"},
+		{`[{"type": "denyTargets", "addresses": ["` + to1.String() + `"]}]`, build(0, to2), ""},
+		{`[{"type": "denyTargets", "addresses": ["` + to1.String() + `"]}]`, build(0, to1), "denyTargets: clause #0: target " + to1.String() + " denied"},
+		{`[{"type": "requireDelegation", "addresses": ["` + to1.String() + `"]}]`, build(0), ""},
+		{`[{"type": "requireDelegation", "addresses": ["` + acc.Address.String() + `"]}]`, build(0), "requireDelegation: delegation required"},
+	}
+
+	for _, tt := range tests {
+		chain, err := ReadPolicies(strings.NewReader(tt.config))
+		assert.Nil(t, err)
+
+		err = chain.Check(tt.tx, acc.Address)
+		if tt.errStr == "" {
+			assert.Nil(t, err, tt.config)
+		} else {
+			assert.EqualError(t, err, tt.errStr, tt.config)
+		}
+	}
+}
+
+func TestRateLimitPolicy(t *testing.T) {
+	chain, err := ReadPolicies(strings.NewReader(`[{"type": "rateLimit", "count": 2, "period": "1m"}]`))
+	assert.Nil(t, err)
+
+	policy := chain[0].(*rateLimitPolicy)
+	now := time.Unix(1000, 0)
+	policy.now = func() time.Time { return now }
+
+	acc1 := genesis.DevAccounts()[0].Address
+	acc2 := genesis.DevAccounts()[1].Address
+
+	// released quota can be reserved again
+	assert.Nil(t, chain.Check(nil, acc1))
+	assert.Nil(t, chain.Check(nil, acc1))
+	assert.NotNil(t, chain.Check(nil, acc1))
+	chain.Release(nil, acc1)
+	assert.Nil(t, chain.Check(nil, acc1))
+	assert.NotNil(t, chain.Check(nil, acc1))
+	assert.Nil(t, chain.Check(nil, acc2))
+
+	// windows are taken over on reload
+	reloaded, err := ReadPolicies(strings.NewReader(`[{"type": "rateLimit", "count": 2, "period": "1m"}]`))
+	assert.Nil(t, err)
+	reloaded[0].(*rateLimitPolicy).now = policy.now
+	reloaded.inherit(chain)
+	assert.NotNil(t, reloaded.Check(nil, acc1))
+
+	now = now.Add(time.Minute + time.Second)
+	assert.Nil(t, reloaded.Check(nil, acc1))
+}
+
+func TestAddWithPolicies(t *testing.T) {
+	pool := newPool(LIMIT, LIMIT_PER_ACCOUNT)
+	defer pool.Close()
+	b1 := new(block.Builder).
+		ParentID(pool.repo.GenesisBlock().Header().ID()).
+		Timestamp(uint64(time.Now().Unix())).
+		TotalScore(100).
+		GasLimit(10000000).
+		StateRoot(pool.repo.GenesisBlock().Header().StateRoot()).
+		Build()
+	pool.repo.AddBlock(b1, nil)
+	pool.repo.SetBestBlockID(b1.Header().ID())
+
+	chain, err := ReadPolicies(strings.NewReader(`[{"type": "maxClauses", "value": 1}]`))
+	assert.Nil(t, err)
+	pool.SetPolicies(chain)
+
+	to := luckyshare.BytesToAddress([]byte{1})
+	clauses := []*tx.Clause{tx.NewClause(&to), tx.NewClause(&to)}
+	err = pool.Add(newTx(pool.repo.ChainTag(), clauses, 50000, tx.BlockRef{}, 100, nil, tx.Features(0), genesis.DevAccounts()[0]))
+	assert.True(t, IsTxRejected(err))
+	assert.EqualError(t, err, "tx rejected: maxClauses: clause count 2 exceeds 1")
+
+	pool.SetPolicies(nil)
+	assert.Nil(t, pool.Add(newTx(pool.repo.ChainTag(), clauses, 50000, tx.BlockRef{}, 100, nil, tx.Features(0), genesis.DevAccounts()[0])))
+
+	chain, err = ReadPolicies(strings.NewReader(`[{"type": "rateLimit", "count": 1, "period": "1m"}]`))
+	assert.Nil(t, err)
+	pool.SetPolicies(chain)
+
+	acc := genesis.DevAccounts()[1]
+	// rejected by the pool after passing policies, quota not consumed
+	err = pool.StrictlyAdd(newTx(pool.repo.ChainTag(), nil, 21000, tx.NewBlockRef(1000), 100, nil, tx.Features(0), acc))
+	assert.True(t, IsTxRejected(err))
+	assert.Nil(t, pool.Add(newTx(pool.repo.ChainTag(), nil, 21000, tx.BlockRef{}, 100, nil, tx.Features(0), acc)))
+	err = pool.Add(newTx(pool.repo.ChainTag(), nil, 21000, tx.BlockRef{}, 100, nil, tx.Features(0), acc))
+	assert.EqualError(t, err, "tx rejected: rateLimit: origin exceeds 1 txs per 1m0s")
+
+	// quota kept after reload
+	chain, err = ReadPolicies(strings.NewReader(`[{"type": "rateLimit", "count": 1, "period": "1m"}]`))
+	assert.Nil(t, err)
+	pool.SetPolicies(chain)
+	err = pool.Add(newTx(pool.repo.ChainTag(), nil, 21000, tx.BlockRef{}, 100, nil, tx.Features(0), acc))
+	assert.EqualError(t, err, "tx rejected: rateLimit: origin exceeds 1 txs per 1m0s")
+}
@@ -7,6 +7,7 @@ package txpool
 
 import (
 	"context"
+	"fmt"
 	"math/rand"
 	"os"
 	"sync/atomic"
@@ -20,7 +21,7 @@ import (
 	"github.com/miniBamboo/luckyshare/chain"
 	"github.com/miniBamboo/luckyshare/common/co"
 	"github.com/miniBamboo/luckyshare/luckyshare"
-	sharer "github.com/miniBamboo/luckyshare/sharer"
+	"github.com/miniBamboo/luckyshare/runtime"
 	"github.com/miniBamboo/luckyshare/state"
 	"github.com/miniBamboo/luckyshare/tx"
 )
@@ -41,12 +42,17 @@ type Options struct {
 	MaxLifetime            time.Duration
 	BlocklistCacheFilePath string
 	BlocklistFetchURL      string
+	BlocklistSources       []string             // additional sources to BlocklistFetchURL
+	BlocklistSigners       []luckyshare.Address // if set, lists must carry detached signature signed by one of them
+	BlocklistMergeMode     string               // BlocklistMergeUnion (default) or BlocklistMergeIntersection
+	ForkConfig             luckyshare.ForkConfig
 }
 
-// TxEvent will be posted when tx is added or status changed.
+// TxEvent will be posted when tx is added, status changed or removed.
 type TxEvent struct {
 	Tx         *tx.Transaction
 	Executable *bool
+	Removed    bool
 }
 
 // TxPool maintains unprocessed transactions.
@@ -54,10 +60,12 @@ type TxPool struct {
 	options   Options
 	repo      *chain.Repository
 	stater    *state.Stater
-	blocklist blocklist
+	blocklist *blocklist
+	policies  atomic.Value
 
 	executables    atomic.Value
 	all            *txObjectMap
+	bundles        *bundleMap
 	addedAfterWash uint32
 
 	ctx    context.Context
@@ -71,13 +79,19 @@ type TxPool struct {
 // Shutdown is required to be called at end.
 func New(repo *chain.Repository, stater *state.Stater, options Options) *TxPool {
 	ctx, cancel := context.WithCancel(context.Background())
+	sources := options.BlocklistSources
+	if options.BlocklistFetchURL != "" {
+		sources = append([]string{options.BlocklistFetchURL}, sources...)
+	}
 	pool := &TxPool{
-		options: options,
-		repo:    repo,
-		stater:  stater,
-		all:     newTxObjectMap(),
-		ctx:     ctx,
-		cancel:  cancel,
+		options:   options,
+		repo:      repo,
+		stater:    stater,
+		blocklist: newBlocklist(sources, options.BlocklistSigners, options.BlocklistMergeMode),
+		all:       newTxObjectMap(),
+		bundles:   newBundleMap(),
+		ctx:       ctx,
+		cancel:    cancel,
 	}
 
 	pool.goes.Go(pool.housekeeping)
@@ -103,6 +117,9 @@ func (p *TxPool) housekeeping() {
 			if newHeadBlock := p.repo.BestBlock().Header(); newHeadBlock.ID() != headBlock.ID() {
 				headBlock = newHeadBlock
 				headBlockChanged = true
+				if removed := p.washBundles(headBlock); removed > 0 {
+					log.Debug("bundles washed", "removed", removed)
+				}
 			}
 			if !isChainSynced(uint64(time.Now().Unix()), headBlock.Timestamp()) {
 				// skip washing txs if not synced
@@ -141,10 +158,7 @@ func (p *TxPool) housekeeping() {
 }
 
 func (p *TxPool) fetchBlocklistLoop() {
-	var (
-		path = p.options.BlocklistCacheFilePath
-		url  = p.options.BlocklistFetchURL
-	)
+	path := p.options.BlocklistCacheFilePath
 
 	if path != "" {
 		if err := p.blocklist.Load(path); err != nil {
@@ -155,18 +169,19 @@ func (p *TxPool) fetchBlocklistLoop() {
 			log.Debug("blocklist loaded", "len", p.blocklist.Len())
 		}
 	}
-	if url == "" {
+	if len(p.blocklist.feeds) == 0 {
 		return
 	}
 
-	var eTag string
 	fetch := func() {
-		if err := p.blocklist.Fetch(p.ctx, url, &eTag); err != nil {
+		updated, err := p.blocklist.Fetch(p.ctx)
+		if err != nil {
 			if err == context.Canceled {
 				return
 			}
-			log.Warn("blocklist fetch failed", "error", err, "url", url)
-		} else {
+			log.Warn("blocklist fetch failed", "error", err)
+		}
+		if updated {
 			log.Debug("blocklist fetched", "len", p.blocklist.Len())
 			if path != "" {
 				if err := p.blocklist.Save(path); err != nil {
@@ -200,7 +215,7 @@ func (p *TxPool) Close() {
 	log.Debug("closed")
 }
 
-//SubscribeTxEvent receivers will receive a tx
+// SubscribeTxEvent receivers will receive a tx
 func (p *TxPool) SubscribeTxEvent(ch chan *TxEvent) event.Subscription {
 	return p.scope.Track(p.txFeed.Subscribe(ch))
 }
@@ -236,8 +251,22 @@ func (p *TxPool) add(newTx *tx.Transaction, rejectNonexecutable bool, localSubmi
 		return badTxError{err.Error()}
 	}
 
+	policies := p.Policies()
+	if err := policies.Check(newTx, txObj.Origin()); err != nil {
+		return txRejectedError{err.Error()}
+	}
+	admitted := false
+	defer func() {
+		if !admitted {
+			policies.Release(newTx, txObj.Origin())
+		}
+	}()
+
 	if isChainSynced(uint64(time.Now().Unix()), headBlock.Timestamp()) {
-		state := p.stater.NewState(headBlock.StateRoot())
+		state, err := p.nextState(headBlock)
+		if err != nil {
+			return err
+		}
 		executable, err := txObj.Executable(p.repo.NewChain(headBlock.ID()), state, headBlock)
 		if err != nil {
 			return txRejectedError{err.Error()}
@@ -252,8 +281,9 @@ func (p *TxPool) add(newTx *tx.Transaction, rejectNonexecutable bool, localSubmi
 		}
 
 		txObj.executable = executable
+		admitted = true
 		p.goes.Go(func() {
-			p.txFeed.Send(&TxEvent{newTx, &executable})
+			p.txFeed.Send(&TxEvent{newTx, &executable, false})
 		})
 		log.Debug("tx added", "id", newTx.ID(), "executable", executable)
 	} else {
@@ -266,8 +296,9 @@ func (p *TxPool) add(newTx *tx.Transaction, rejectNonexecutable bool, localSubmi
 		if err := p.all.Add(txObj, p.options.LimitPerAccount); err != nil {
 			return txRejectedError{err.Error()}
 		}
+		admitted = true
 		log.Debug("tx added", "id", newTx.ID())
-		p.txFeed.Send(&TxEvent{newTx, nil})
+		p.txFeed.Send(&TxEvent{newTx, nil, false})
 	}
 	atomic.AddUint32(&p.addedAfterWash, 1)
 	return nil
@@ -284,6 +315,26 @@ func (p *TxPool) AddLocal(newTx *tx.Transaction) error {
 	return p.add(newTx, false, true)
 }
 
+// SetPolicies replaces the admission policy chain.
+// Txs already in the pool are not affected, and state of replaced policies, e.g. quotas, is taken over.
+func (p *TxPool) SetPolicies(policies PolicyChain) {
+	policies.inherit(p.Policies())
+	p.policies.Store(policies)
+}
+
+// Policies returns the current admission policy chain.
+func (p *TxPool) Policies() PolicyChain {
+	if policies := p.policies.Load(); policies != nil {
+		return policies.(PolicyChain)
+	}
+	return nil
+}
+
+// Blocklist returns the active blocklist and its provenance.
+func (p *TxPool) Blocklist() *BlocklistInfo {
+	return p.blocklist.Info()
+}
+
 // Get get pooled tx by id.
 func (p *TxPool) Get(id luckyshare.Bytes32) *tx.Transaction {
 	if txObj := p.all.GetByID(id); txObj != nil {
@@ -299,13 +350,178 @@ func (p *TxPool) StrictlyAdd(newTx *tx.Transaction) error {
 
 // Remove removes tx from pool by its Hash.
 func (p *TxPool) Remove(txHash luckyshare.Bytes32, txID luckyshare.Bytes32) bool {
+	txObj := p.all.GetByID(txID)
 	if p.all.RemoveByHash(txHash) {
 		log.Debug("tx removed", "id", txID)
+		if txObj != nil {
+			p.goes.Go(func() {
+				p.txFeed.Send(&TxEvent{txObj.Transaction, nil, true})
+			})
+		}
+		return true
+	}
+	return false
+}
+
+// AddBundle adds a bundle of txs into pool.
+// Txs in the bundle are kept apart from the other txs in pool.
+func (p *TxPool) AddBundle(b *Bundle) error {
+	switch {
+	case len(b.Txs) == 0:
+		return badTxError{"empty bundle"}
+	case len(b.Txs) > maxBundleTxs:
+		return txRejectedError{"too many txs in bundle"}
+	case b.MinBlock > b.MaxBlock:
+		return badTxError{"invalid block range"}
+	case b.MaxBlock-b.MinBlock >= maxBundleBlockRange:
+		return txRejectedError{"block range too large"}
+	}
+
+	headBlock := p.repo.BestBlock().Header()
+	switch {
+	case b.MaxBlock <= headBlock.Number():
+		return txRejectedError{"bundle expired"}
+	case b.MinBlock > headBlock.Number()+uint32(5*60/luckyshare.BlockInterval):
+		// reject deferred bundle which will be applied after 5mins
+		return txRejectedError{"block range out of schedule"}
+	case b.Gas() > headBlock.GasLimit():
+		return txRejectedError{"gas too large"}
+	}
+
+	chain := p.repo.NewChain(headBlock.ID())
+	policies := p.Policies()
+	origins := make([]luckyshare.Address, 0, len(b.Txs))
+	resolvedTxs := make([]*runtime.ResolvedTransaction, 0, len(b.Txs))
+	seen := make(map[luckyshare.Bytes32]bool)
+	admitted := false
+	defer func() {
+		if !admitted {
+			// release quotas reserved by txs passed policies
+			for i, trx := range b.Txs[:len(origins)] {
+				policies.Release(trx, origins[i])
+			}
+		}
+	}()
+	for i, trx := range b.Txs {
+		if seen[trx.ID()] {
+			return badTxError{fmt.Sprintf("tx #%v: duplicated", i)}
+		}
+		seen[trx.ID()] = true
+
+		switch {
+		case trx.ChainTag() != p.repo.ChainTag():
+			return badTxError{fmt.Sprintf("tx #%v: chain tag mismatch", i)}
+		case trx.Size() > maxTxSize:
+			return txRejectedError{fmt.Sprintf("tx #%v: size too large", i)}
+		}
+		if err := trx.TestFeatures(headBlock.TxsFeatures()); err != nil {
+			return txRejectedError{fmt.Sprintf("tx #%v: %v", i, err)}
+		}
+
+		resolved, err := runtime.ResolveTransaction(trx)
+		if err != nil {
+			return badTxError{fmt.Sprintf("tx #%v: %v", i, err)}
+		}
+		if luckyshare.IsOriginBlocked(resolved.Origin) || p.blocklist.Contains(resolved.Origin) {
+			return txRejectedError{fmt.Sprintf("tx #%v: origin blocked", i)}
+		}
+		if err := policies.Check(trx, resolved.Origin); err != nil {
+			return txRejectedError{fmt.Sprintf("tx #%v: %v", i, err)}
+		}
+		origins = append(origins, resolved.Origin)
+		resolvedTxs = append(resolvedTxs, resolved)
+
+		if _, err := chain.GetTransactionMeta(trx.ID()); err != nil {
+			if !chain.IsNotFound(err) {
+				return err
+			}
+		} else {
+			return txRejectedError{fmt.Sprintf("tx #%v: known tx", i)}
+		}
+	}
+
+	// payers should afford gas of all txs in the bundle
+	state, err := p.nextState(headBlock)
+	if err != nil {
+		return err
+	}
+	for i, resolved := range resolvedTxs {
+		if _, _, _, _, err := resolved.BuyGas(state, headBlock.Timestamp()+luckyshare.BlockInterval); err != nil {
+			return txRejectedError{fmt.Sprintf("tx #%v: %v", i, err)}
+		}
+	}
+
+	b.origins = nil
+	distinct := make(map[luckyshare.Address]bool)
+	for _, origin := range origins {
+		if !distinct[origin] {
+			distinct[origin] = true
+			b.origins = append(b.origins, origin)
+		}
+	}
+	if err := p.bundles.Add(b); err != nil {
+		return txRejectedError{err.Error()}
+	}
+	admitted = true
+	log.Debug("bundle added", "id", b.ID(), "txs", len(b.Txs))
+	return nil
+}
+
+// GetBundle get pooled bundle by id.
+func (p *TxPool) GetBundle(id luckyshare.Bytes32) *Bundle {
+	return p.bundles.Get(id)
+}
+
+// RemoveBundle removes bundle from pool by its ID.
+func (p *TxPool) RemoveBundle(id luckyshare.Bytes32) bool {
+	if p.bundles.Remove(id) {
+		log.Debug("bundle removed", "id", id)
 		return true
 	}
 	return false
 }
 
+// FailBundle records a failed adoption of the bundle, which is dropped after failed several times.
+// It returns true if the bundle is dropped.
+func (p *TxPool) FailBundle(id luckyshare.Bytes32) bool {
+	if p.bundles.Fail(id) {
+		log.Debug("bundle dropped after failures", "id", id)
+		return true
+	}
+	return false
+}
+
+// Bundles returns bundles can be packed in the block of given number, in the order they were added.
+func (p *TxPool) Bundles(blockNum uint32) []*Bundle {
+	var bundles []*Bundle
+	for _, b := range p.bundles.ToBundles() {
+		if blockNum >= b.MinBlock && blockNum <= b.MaxBlock {
+			bundles = append(bundles, b)
+		}
+	}
+	return bundles
+}
+
+// washBundles removes bundles that are expired or have txs already packed.
+func (p *TxPool) washBundles(headBlock *block.Header) (removed int) {
+	chain := p.repo.NewChain(headBlock.ID())
+	for _, b := range p.bundles.ToBundles() {
+		drop := b.MaxBlock <= headBlock.Number()
+		for _, trx := range b.Txs {
+			if drop {
+				break
+			}
+			if _, err := chain.GetTransactionMeta(trx.ID()); err == nil {
+				drop = true
+			}
+		}
+		if drop && p.bundles.Remove(b.ID()) {
+			removed++
+		}
+	}
+	return
+}
+
 // Executables returns executable txs.
 func (p *TxPool) Executables() tx.Transactions {
 	if sorted := p.executables.Load(); sorted != nil {
@@ -335,6 +551,16 @@ func (p *TxPool) Dump() tx.Transactions {
 	return p.all.ToTxs()
 }
 
+// nextState returns the state after head block with the base gas price of the next block applied,
+// since txs in pool are going to be packed into the next block.
+func (p *TxPool) nextState(headBlock *block.Header) (*state.State, error) {
+	state := p.stater.NewState(headBlock.StateRoot())
+	if err := runtime.UpdateBaseGasPrice(state, headBlock, p.options.ForkConfig); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
 // wash to evict txs that are over limit, out of lifetime, out of energy, settled, expired or dep broken.
 // this method should only be called in housekeeping go routine
 func (p *TxPool) wash(headBlock *block.Header) (executables tx.Transactions, removed int, err error) {
@@ -343,23 +569,33 @@ func (p *TxPool) wash(headBlock *block.Header) (executables tx.Transactions, rem
 	defer func() {
 		if err != nil {
 			// in case of error, simply cut pool size to limit
+			toRemove = nil
 			for i, txObj := range all {
 				if len(all)-i <= p.options.Limit {
 					break
 				}
-				removed++
-				p.all.RemoveByHash(txObj.Hash())
-			}
-		} else {
-			for _, txObj := range toRemove {
-				p.all.RemoveByHash(txObj.Hash())
+				toRemove = append(toRemove, txObj)
 			}
-			removed = len(toRemove)
+		}
+		for _, txObj := range toRemove {
+			p.all.RemoveByHash(txObj.Hash())
+		}
+		removed = len(toRemove)
+
+		if len(toRemove) > 0 {
+			p.goes.Go(func() {
+				for _, txObj := range toRemove {
+					p.txFeed.Send(&TxEvent{txObj.Transaction, nil, true})
+				}
+			})
 		}
 	}()
 
-	state := p.stater.NewState(headBlock.StateRoot())
-	baseGasPrice, err := sharer.Params.Native(state).Get(luckyshare.KeyBaseGasPrice)
+	state, err := p.nextState(headBlock)
+	if err != nil {
+		return nil, 0, err
+	}
+	baseGasPrice, err := runtime.BaseGasPrice(state)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -458,7 +694,7 @@ func (p *TxPool) wash(headBlock *block.Header) (executables tx.Transactions, rem
 	p.goes.Go(func() {
 		for _, tx := range toBroadcast {
 			executable := true
-			p.txFeed.Send(&TxEvent{tx, &executable})
+			p.txFeed.Send(&TxEvent{tx, &executable, false})
 		}
 	})
 	return executables, 0, nil
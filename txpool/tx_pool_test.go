@@ -65,7 +65,10 @@ func TestSubscribeNewTx(t *testing.T) {
 	assert.Nil(t, pool.Add(tx))
 
 	v := true
-	assert.Equal(t, &TxEvent{tx, &v}, <-txCh)
+	assert.Equal(t, &TxEvent{tx, &v, false}, <-txCh)
+
+	assert.True(t, pool.Remove(tx.Hash(), tx.ID()))
+	assert.Equal(t, &TxEvent{tx, nil, true}, <-txCh)
 }
 
 func TestWashTxs(t *testing.T) {
@@ -0,0 +1,90 @@
+// Copyright (c) 2021 The VeChainThor developers
+
+// Distributed under the GNU Lesser General Public License v3.0 software license, see the accompanying
+// file LICENSE or <https://www.gnu.org/licenses/lgpl-3.0.html>
+
+package vm
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ChainConfig extends eth ChainConfig with forks not known by the eth dependency.
+type ChainConfig struct {
+	params.ChainConfig
+	IstanbulBlock *big.Int `json:"istanbulBlock,omitempty"` // Istanbul switch block (nil = no fork, 0 = already on istanbul)
+	BerlinBlock   *big.Int `json:"berlinBlock,omitempty"`   // Berlin switch block (nil = no fork, 0 = already on berlin)
+	ShanghaiBlock *big.Int `json:"shanghaiBlock,omitempty"` // Shanghai switch block (nil = no fork, 0 = already on shanghai)
+	PragueBlock   *big.Int `json:"pragueBlock,omitempty"`   // Prague switch block (nil = no fork, 0 = already on prague)
+}
+
+// IsIstanbul returns whether num is either equal to the Istanbul fork block or greater.
+func (c *ChainConfig) IsIstanbul(num *big.Int) bool {
+	return isForked(c.IstanbulBlock, num)
+}
+
+// IsBerlin returns whether num is either equal to the Berlin fork block or greater.
+func (c *ChainConfig) IsBerlin(num *big.Int) bool {
+	return isForked(c.BerlinBlock, num)
+}
+
+// IsShanghai returns whether num is either equal to the Shanghai fork block or greater.
+func (c *ChainConfig) IsShanghai(num *big.Int) bool {
+	return isForked(c.ShanghaiBlock, num)
+}
+
+// IsPrague returns whether num is either equal to the Prague fork block or greater.
+func (c *ChainConfig) IsPrague(num *big.Int) bool {
+	return isForked(c.PragueBlock, num)
+}
+
+// GasTable returns the gas table corresponding to the current phase.
+func (c *ChainConfig) GasTable(num *big.Int) params.GasTable {
+	gt := c.ChainConfig.GasTable(num)
+	switch {
+	case c.IsBerlin(num):
+		// EIP-2929: the warm access costs, cold access surcharges are applied by gas funcs
+		gt.SLoad = warmStorageReadCostEIP2929
+		gt.Balance = warmStorageReadCostEIP2929
+		gt.ExtcodeSize = warmStorageReadCostEIP2929
+		gt.ExtcodeCopy = warmStorageReadCostEIP2929
+		gt.ExtcodeHash = warmStorageReadCostEIP2929
+		gt.Calls = warmStorageReadCostEIP2929
+	case c.IsIstanbul(num):
+		// EIP-1884
+		gt.SLoad = sloadGasEIP1884
+		gt.Balance = balanceGasEIP1884
+		gt.ExtcodeHash = extcodeHashGasEIP1884
+	}
+	return gt
+}
+
+// Rules wraps eth Rules with extended forks.
+type Rules struct {
+	params.Rules
+	IsIstanbul bool
+	IsBerlin   bool
+	IsShanghai bool
+	IsPrague   bool
+}
+
+// Rules ensures c's ChainID is not nil.
+func (c *ChainConfig) Rules(num *big.Int) Rules {
+	return Rules{
+		Rules:      c.ChainConfig.Rules(num),
+		IsIstanbul: c.IsIstanbul(num),
+		IsBerlin:   c.IsBerlin(num),
+		IsShanghai: c.IsShanghai(num),
+		IsPrague:   c.IsPrague(num),
+	}
+}
+
+// isForked returns whether a fork scheduled at block s is active at the given head block.
+func isForked(s, head *big.Int) bool {
+	if s == nil || head == nil {
+		return false
+	}
+	return s.Cmp(head) <= 0
+}
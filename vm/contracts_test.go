@@ -17,7 +17,9 @@
 package vm
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"testing"
 
@@ -32,6 +34,14 @@ type precompiledTest struct {
 	noBenchmark     bool // Benchmark primarily the worst-cases
 }
 
+// precompiledFailureTest defines the input/error pairs for precompiled
+// contract failure tests.
+type precompiledFailureTest struct {
+	input         string
+	expectedError string
+	name          string
+}
+
 // modexpTests are the test and benchmark data for the modexp precompiled contract.
 var modexpTests = []precompiledTest{
 	{
@@ -336,12 +346,40 @@ var bn256PairingTests = []precompiledTest{
 	},
 }
 
+// blake2FTests are the test data for the blake2F precompiled contract, taken from EIP-152.
+var blake2FTests = []precompiledTest{
+	{
+		input:    "0000000048c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		expected: "08c9bcf367e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d282e6ad7f520e511f6c3e2b8c68059b9442be0454267ce079217e1319cde05b",
+		name:     "vector 4",
+	}, {
+		input:    "0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		expected: "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+		name:     "vector 5",
+	}, {
+		input:    "0000000c48c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000000",
+		expected: "75ab69d3190a562c51aef8d88f1c2775876944407270c42c9844252c26d2875298743e7f6d5ea2f2d3e8d226039cd31b4e426ac4f2d3d666a610c2116fde4735",
+		name:     "vector 6",
+	}, {
+		input:    "0000000148c9bdf267e6096a3ba7ca8485ae67bb2bf894fe72f36e3cf1361d5f3af54fa5d182e6ad7f520e511f6c3e2b8c68059b6bbd41fbabd9831f79217e1319cde05b61626300000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000300000000000000000000000000000001",
+		expected: "b63a380cb2897d521994a85234ee2c181b5f844d2c624c002677e9703449d2fba551b3a8333bcdf5f2f7e08993d53923de3d64fcc68c034e717b9293fed7a421",
+		name:     "vector 7",
+	},
+}
+
 func testPrecompiled(addr string, test precompiledTest, t *testing.T) {
-	p := PrecompiledContractsByzantium[common.HexToAddress(addr)]
+	testPrecompiledIn(PrecompiledContractsByzantium, addr, test, t)
+}
+
+func testPrecompiledIn(contracts map[common.Address]PrecompiledContract, addr string, test precompiledTest, t *testing.T) {
+	p := contracts[common.HexToAddress(addr)]
 	in := common.Hex2Bytes(test.input)
 	contract := NewContract(AccountRef(common.HexToAddress("1337")),
 		nil, new(big.Int), p.RequiredGas(in))
 	t.Run(fmt.Sprintf("%s-Gas=%d", test.name, contract.Gas), func(t *testing.T) {
+		if test.gas != 0 && contract.Gas != test.gas {
+			t.Errorf("Expected gas %v, got %v", test.gas, contract.Gas)
+		}
 		if res, err := RunPrecompiledContract(p, in, contract); err != nil {
 			t.Error(err)
 		} else if common.Bytes2Hex(res) != test.expected {
@@ -350,6 +388,89 @@ func testPrecompiled(addr string, test precompiledTest, t *testing.T) {
 	})
 }
 
+func testPrecompiledFailure(contracts map[common.Address]PrecompiledContract, addr string, test precompiledFailureTest, t *testing.T) {
+	p := contracts[common.HexToAddress(addr)]
+	in := common.Hex2Bytes(test.input)
+	contract := NewContract(AccountRef(common.HexToAddress("1337")),
+		nil, new(big.Int), p.RequiredGas(in))
+	t.Run(test.name, func(t *testing.T) {
+		// error messages depend on the curve library, only the failure matters
+		if _, err := RunPrecompiledContract(p, in, contract); err == nil {
+			t.Errorf("Expected error [%v], got nil", test.expectedError)
+		}
+	})
+}
+
+// loadJSON loads precompiled contract test vectors from testdata/precompiles.
+func loadJSON(name string) ([]precompiledTest, error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("testdata/precompiles/%v.json", name))
+	if err != nil {
+		return nil, err
+	}
+	var vectors []struct {
+		Input, Expected, Name string
+		Gas                   uint64
+		NoBenchmark           bool
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		return nil, err
+	}
+	tests := make([]precompiledTest, 0, len(vectors))
+	for _, v := range vectors {
+		tests = append(tests, precompiledTest{
+			input:       v.Input,
+			expected:    v.Expected,
+			gas:         v.Gas,
+			name:        v.Name,
+			noBenchmark: v.NoBenchmark,
+		})
+	}
+	return tests, nil
+}
+
+// loadJSONFail loads precompiled contract failure test vectors from testdata/precompiles.
+func loadJSONFail(name string) ([]precompiledFailureTest, error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("testdata/precompiles/fail-%v.json", name))
+	if err != nil {
+		return nil, err
+	}
+	var vectors []struct {
+		Input, ExpectedError, Name string
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		return nil, err
+	}
+	tests := make([]precompiledFailureTest, 0, len(vectors))
+	for _, v := range vectors {
+		tests = append(tests, precompiledFailureTest{
+			input:         v.Input,
+			expectedError: v.ExpectedError,
+			name:          v.Name,
+		})
+	}
+	return tests, nil
+}
+
+func testJSON(contracts map[common.Address]PrecompiledContract, name, addr string, t *testing.T) {
+	tests, err := loadJSON(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		testPrecompiledIn(contracts, addr, test, t)
+	}
+}
+
+func testJSONFail(contracts map[common.Address]PrecompiledContract, name, addr string, t *testing.T) {
+	tests, err := loadJSONFail(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range tests {
+		testPrecompiledFailure(contracts, addr, test, t)
+	}
+}
+
 func benchmarkPrecompiled(addr string, test precompiledTest, bench *testing.B) {
 	if test.noBenchmark {
 		return
@@ -440,6 +561,46 @@ func BenchmarkPrecompiledModExp(bench *testing.B) {
 	}
 }
 
+// Tests the repriced gas of ModExp from EIP 2565.
+func TestPrecompiledModExpEIP2565(t *testing.T) {
+	expected := map[string]uint64{
+		"eip_example1":          1360,
+		"eip_example2":          1360,
+		"nagydani-1-square":     200,
+		"nagydani-1-qube":       200,
+		"nagydani-1-pow0x10001": 341,
+		"nagydani-2-square":     200,
+	}
+	p := PrecompiledContractsBerlin[common.BytesToAddress([]byte{5})]
+	for _, test := range modexpTests {
+		if gas, ok := expected[test.name]; ok {
+			if got := p.RequiredGas(common.Hex2Bytes(test.input)); got != gas {
+				t.Errorf("%v: expected gas %v, got %v", test.name, gas, got)
+			}
+		}
+		testPrecompiledIn(PrecompiledContractsBerlin, "05", test, t)
+	}
+}
+
+// Tests the sample inputs from the BLAKE2b compression function EIP 152.
+func TestPrecompiledBlake2F(t *testing.T) {
+	for _, test := range blake2FTests {
+		testPrecompiledIn(PrecompiledContractsIstanbul, "09", test, t)
+	}
+
+	p := PrecompiledContractsIstanbul[common.BytesToAddress([]byte{9})]
+	// malformed inputs
+	for _, in := range []string{
+		"",
+		blake2FTests[0].input[:len(blake2FTests[0].input)-2],
+		blake2FTests[0].input[:len(blake2FTests[0].input)-2] + "02",
+	} {
+		if _, err := p.Run(common.Hex2Bytes(in)); err == nil {
+			t.Errorf("expected error for input %q", in)
+		}
+	}
+}
+
 // Tests the sample inputs from the elliptic curve addition EIP 213.
 func TestPrecompiledBn256Add(t *testing.T) {
 	for _, test := range bn256AddTests {
@@ -481,3 +642,62 @@ func BenchmarkPrecompiledBn256Pairing(bench *testing.B) {
 		benchmarkPrecompiled("08", test, bench)
 	}
 }
+
+// Tests the sample inputs of BLS12-381 curve operations from EIP 2537.
+func TestPrecompiledBLS12381G1Add(t *testing.T) {
+	testJSON(PrecompiledContractsPrague, "blsG1Add", "0b", t)
+}
+
+func TestPrecompiledBLS12381G1MultiExp(t *testing.T) {
+	testJSON(PrecompiledContractsPrague, "blsG1MultiExp", "0c", t)
+}
+
+func TestPrecompiledBLS12381G2Add(t *testing.T) {
+	testJSON(PrecompiledContractsPrague, "blsG2Add", "0d", t)
+}
+
+func TestPrecompiledBLS12381G2MultiExp(t *testing.T) {
+	testJSON(PrecompiledContractsPrague, "blsG2MultiExp", "0e", t)
+}
+
+func TestPrecompiledBLS12381Pairing(t *testing.T) {
+	testJSON(PrecompiledContractsPrague, "blsPairing", "0f", t)
+}
+
+func TestPrecompiledBLS12381MapG1(t *testing.T) {
+	testJSON(PrecompiledContractsPrague, "blsMapG1", "10", t)
+}
+
+func TestPrecompiledBLS12381MapG2(t *testing.T) {
+	testJSON(PrecompiledContractsPrague, "blsMapG2", "11", t)
+}
+
+// Tests the malformed inputs of BLS12-381 curve operations from EIP 2537.
+func TestPrecompiledBLS12381Failure(t *testing.T) {
+	for name, addr := range map[string]string{
+		"blsG1Add":      "0b",
+		"blsG1MultiExp": "0c",
+		"blsG2Add":      "0d",
+		"blsG2MultiExp": "0e",
+		"blsPairing":    "0f",
+		"blsMapG1":      "10",
+		"blsMapG2":      "11",
+	} {
+		testJSONFail(PrecompiledContractsPrague, name, addr, t)
+	}
+}
+
+// Tests the secp256r1 signature verification vectors of RIP 7212.
+func TestPrecompiledP256Verify(t *testing.T) {
+	testJSON(PrecompiledContractsPrague, "p256Verify", "0100", t)
+}
+
+func TestActivePrecompilesPrague(t *testing.T) {
+	bls := common.BytesToAddress([]byte{0x0b})
+	if _, ok := ActivePrecompiles(Rules{IsBerlin: true})[bls]; ok {
+		t.Error("BLS12-381 precompiles should not be active before Prague")
+	}
+	if _, ok := ActivePrecompiles(Rules{IsBerlin: true, IsPrague: true})[bls]; !ok {
+		t.Error("BLS12-381 precompiles should be active after Prague")
+	}
+}
@@ -19,9 +19,11 @@ package vm
 import (
 	"bytes"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
 )
@@ -34,7 +36,7 @@ type twoOperandTest struct {
 
 func testTwoOperandOp(t *testing.T, tests []twoOperandTest, opFn func(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error)) {
 	var (
-		env   = NewEVM(Context{}, nil, params.TestChainConfig, Config{})
+		env   = NewEVM(Context{}, nil, &ChainConfig{ChainConfig: *params.TestChainConfig}, Config{})
 		stack = newstack()
 		pc    = uint64(0)
 	)
@@ -70,7 +72,7 @@ func testTwoOperandOp(t *testing.T, tests []twoOperandTest, opFn func(pc *uint64
 
 func TestByteOp(t *testing.T) {
 	var (
-		env   = NewEVM(Context{}, nil, params.TestChainConfig, Config{})
+		env   = NewEVM(Context{}, nil, &ChainConfig{ChainConfig: *params.TestChainConfig}, Config{})
 		stack = newstack()
 	)
 	tests := []struct {
@@ -198,9 +200,225 @@ func TestSLT(t *testing.T) {
 	testTwoOperandOp(t, tests, opSlt)
 }
 
+func TestPush0(t *testing.T) {
+	var (
+		env   = NewEVM(Context{}, nil, &ChainConfig{ChainConfig: *params.TestChainConfig}, Config{})
+		stack = newstack()
+		pc    = uint64(0)
+	)
+	opPush0(&pc, env, nil, nil, stack)
+	if stack.len() != 1 {
+		t.Fatalf("stack length mismatch: have %d, want 1", stack.len())
+	}
+	if actual := stack.pop(); actual.Sign() != 0 {
+		t.Errorf("expected 0, got %v", actual)
+	}
+}
+
+// transientStateDB only implements transient storage of StateDB.
+type transientStateDB struct {
+	StateDB
+	storage map[common.Address]map[common.Hash]common.Hash
+}
+
+func (db *transientStateDB) GetTransientState(addr common.Address, key common.Hash) common.Hash {
+	return db.storage[addr][key]
+}
+
+func (db *transientStateDB) SetTransientState(addr common.Address, key, value common.Hash) {
+	if db.storage[addr] == nil {
+		db.storage[addr] = make(map[common.Hash]common.Hash)
+	}
+	db.storage[addr][key] = value
+}
+
+func TestTransientStorage(t *testing.T) {
+	var (
+		statedb  = &transientStateDB{storage: make(map[common.Address]map[common.Hash]common.Hash)}
+		env      = NewEVM(Context{}, statedb, &ChainConfig{ChainConfig: *params.TestChainConfig}, Config{})
+		stack    = newstack()
+		pc       = uint64(0)
+		addr     = common.BytesToAddress([]byte("contract"))
+		other    = common.BytesToAddress([]byte("other"))
+		contract = NewContract(AccountRef(addr), AccountRef(addr), new(big.Int), 0)
+		value    = common.Hex2Bytes("abcdef00000000000000abba000000000deaf000000c0de00100000000133700")
+	)
+	// unset slot reads zero
+	stack.push(big.NewInt(1))
+	opTload(&pc, env, contract, nil, stack)
+	if actual := stack.pop(); actual.Sign() != 0 {
+		t.Errorf("expected 0, got %x", actual)
+	}
+
+	// TSTORE 1 value
+	stack.push(new(big.Int).SetBytes(value))
+	stack.push(big.NewInt(1))
+	opTstore(&pc, env, contract, nil, stack)
+	if stack.len() != 0 {
+		t.Fatalf("stack length mismatch: have %d, want 0", stack.len())
+	}
+
+	stack.push(big.NewInt(1))
+	opTload(&pc, env, contract, nil, stack)
+	if actual := stack.pop(); !bytes.Equal(common.BigToHash(actual).Bytes(), value) {
+		t.Errorf("expected %x, got %x", value, actual)
+	}
+
+	// slots are scoped by contract address
+	if actual := statedb.GetTransientState(other, common.BigToHash(big.NewInt(1))); actual != (common.Hash{}) {
+		t.Errorf("expected empty slot of other address, got %x", actual)
+	}
+
+	if !shanghaiInstructionSet[TSTORE].writes {
+		t.Error("TSTORE should be forbidden in static calls")
+	}
+	if shanghaiInstructionSet[TLOAD].writes {
+		t.Error("TLOAD should be allowed in static calls")
+	}
+}
+
+func TestOpMCopy(t *testing.T) {
+	// Test cases from https://eips.ethereum.org/EIPS/eip-5656#test-cases
+	tests := []struct {
+		dst, src, len string
+		pre           string
+		want          string
+		wantGas       uint64
+	}{
+		{ // MCOPY 0 32 32 - copy 32 bytes from offset 32 to offset 0.
+			dst: "0x0", src: "0x20", len: "0x20",
+			pre:     "0000000000000000000000000000000000000000000000000000000000000000000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			want:    "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			wantGas: 6,
+		},
+		{ // MCOPY 0 0 32 - copy 32 bytes from offset 0 to offset 0.
+			dst: "0x0", src: "0x0", len: "0x20",
+			pre:     "0101010101010101010101010101010101010101010101010101010101010101",
+			want:    "0101010101010101010101010101010101010101010101010101010101010101",
+			wantGas: 6,
+		},
+		{ // MCOPY 0 1 8 - copy 8 bytes from offset 0 to offset 1.
+			dst: "0x1", src: "0x0", len: "0x8",
+			pre:     "000102030405060708000000000000000000000000000000000000000000000000",
+			want:    "000001020304050607000000000000000000000000000000000000000000000000",
+			wantGas: 6,
+		},
+		{ // MCOPY 1 0 8 - copy 8 bytes from offset 1 to offset 0.
+			dst: "0x0", src: "0x1", len: "0x8",
+			pre:     "000102030405060708000000000000000000000000000000000000000000000000",
+			want:    "010203040506070808000000000000000000000000000000000000000000000000",
+			wantGas: 6,
+		},
+		// Tests below are not in the EIP, but maybe should be added
+		{ // MCOPY 0xFFFFFFFFFFFF 0xFFFFFFFFFFFF 0 - copy zero bytes from out-of-bounds index(overlapping).
+			dst: "0xFFFFFFFFFFFF", src: "0xFFFFFFFFFFFF", len: "0x0",
+			pre:     "11",
+			want:    "11",
+			wantGas: 3,
+		},
+		{ // MCOPY 0xFFFFFFFFFFFF 0 0 - copy zero bytes from start of mem to out-of-bounds.
+			dst: "0xFFFFFFFFFFFF", src: "0x0", len: "0x0",
+			pre:     "11",
+			want:    "11",
+			wantGas: 3,
+		},
+		{ // MCOPY 0 0xFFFFFFFFFFFF 0 - copy zero bytes from out-of-bounds to start of mem
+			dst: "0x0", src: "0xFFFFFFFFFFFF", len: "0x0",
+			pre:     "11",
+			want:    "11",
+			wantGas: 3,
+		},
+		{ // MCOPY - copy 1 from space outside of uint64 space
+			dst: "0x0", src: "0x10000000000000000", len: "0x1",
+			pre: "0",
+		},
+		{ // MCOPY - copy 1 from 0 to space outside of uint64
+			dst: "0x10000000000000000", src: "0x0", len: "0x1",
+			pre: "0",
+		},
+		{ // MCOPY - copy nothing from 0 to space outside of uint64
+			dst: "0x10000000000000000", src: "0x0", len: "0x0",
+			pre:     "",
+			want:    "",
+			wantGas: 3,
+		},
+		{ // MCOPY - copy 1 from 0x20 to 0x10, with no prior allocated mem
+			dst: "0x10", src: "0x20", len: "0x1",
+			pre: "",
+			// 64 bytes
+			want:    "0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+			wantGas: 12,
+		},
+		{ // MCOPY - copy 1 from 0x19 to 0x10, with no prior allocated mem
+			dst: "0x10", src: "0x19", len: "0x1",
+			pre: "",
+			// 32 bytes
+			want:    "0x0000000000000000000000000000000000000000000000000000000000000000",
+			wantGas: 9,
+		},
+	}
+	for i, tc := range tests {
+		var (
+			env   = NewEVM(Context{}, nil, &ChainConfig{ChainConfig: *params.TestChainConfig}, Config{})
+			stack = newstack()
+			pc    = uint64(0)
+			mem   = NewMemory()
+		)
+		data := common.FromHex(strings.ReplaceAll(tc.pre, " ", ""))
+		// Set pre
+		mem.Resize(uint64(len(data)))
+		mem.Set(0, uint64(len(data)), data)
+		// Push stack args
+		stack.push(math.MustParseBig256(tc.len))
+		stack.push(math.MustParseBig256(tc.src))
+		stack.push(math.MustParseBig256(tc.dst))
+		wantErr := (tc.wantGas == 0)
+		// Calc mem expansion
+		var memorySize uint64
+		if memSize := memoryMcopy(stack); memSize.BitLen() > 64 {
+			if wantErr {
+				continue
+			}
+			t.Errorf("test %d: overflow", i)
+			continue
+		} else {
+			var overflow bool
+			if memorySize, overflow = math.SafeMul(toWordSize(memSize.Uint64()), 32); overflow {
+				t.Errorf("test %d: overflow", i)
+				continue
+			}
+		}
+		// and the dynamic cost
+		haveGas, err := gasMcopy(params.GasTable{}, env, nil, stack, mem, memorySize)
+		if err != nil && !wantErr {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if err == nil && wantErr {
+			t.Errorf("test %d: expected error", i)
+			continue
+		}
+		if wantErr {
+			continue
+		}
+		if haveGas != tc.wantGas {
+			t.Errorf("test %d: gas mismatch: have %v, want %v", i, haveGas, tc.wantGas)
+		}
+		if memorySize > 0 {
+			mem.Resize(memorySize)
+		}
+		// Do the copy
+		opMcopy(&pc, env, nil, mem, stack)
+		want := common.FromHex(strings.ReplaceAll(tc.want, " ", ""))
+		if have := mem.Data(); !bytes.Equal(want, have) {
+			t.Errorf("test %d: want %x, have %x", i, want, have)
+		}
+	}
+}
+
 func opBenchmark(bench *testing.B, op func(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error), args ...string) {
 	var (
-		env   = NewEVM(Context{}, nil, params.TestChainConfig, Config{})
+		env   = NewEVM(Context{}, nil, &ChainConfig{ChainConfig: *params.TestChainConfig}, Config{})
 		stack = newstack()
 	)
 	// convert args
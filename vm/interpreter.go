@@ -62,6 +62,12 @@ func NewInterpreter(evm *EVM, cfg Config) *Interpreter {
 	// we'll set the default jump table.
 	if !cfg.JumpTable[STOP].valid {
 		switch {
+		case evm.chainRules.IsShanghai:
+			cfg.JumpTable = shanghaiInstructionSet
+		case evm.chainRules.IsBerlin:
+			cfg.JumpTable = berlinInstructionSet
+		case evm.chainRules.IsIstanbul:
+			cfg.JumpTable = istanbulInstructionSet
 		case evm.ChainConfig().IsConstantinople(evm.BlockNumber):
 			cfg.JumpTable = constantinopleInstructionSet
 		case evm.ChainConfig().IsByzantium(evm.BlockNumber):
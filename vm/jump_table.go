@@ -55,8 +55,93 @@ var (
 	homesteadInstructionSet      = NewHomesteadInstructionSet()
 	byzantiumInstructionSet      = NewByzantiumInstructionSet()
 	constantinopleInstructionSet = NewConstantinopleInstructionSet()
+	istanbulInstructionSet       = NewIstanbulInstructionSet()
+	berlinInstructionSet         = NewBerlinInstructionSet()
+	shanghaiInstructionSet       = NewShanghaiInstructionSet()
 )
 
+// NewShanghaiInstructionSet returns the frontier, homestead, byzantium,
+// contantinople, istanbul, berlin and shanghai instructions.
+func NewShanghaiInstructionSet() [256]operation {
+	instructionSet := NewBerlinInstructionSet()
+
+	// EIP-3855: PUSH0 instruction
+	instructionSet[PUSH0] = operation{
+		execute:       opPush0,
+		gasCost:       constGasFunc(GasQuickStep),
+		validateStack: makeStackFunc(0, 1),
+		valid:         true,
+	}
+	// EIP-1153: transient storage opcodes
+	instructionSet[TLOAD] = operation{
+		execute:       opTload,
+		gasCost:       constGasFunc(warmStorageReadCostEIP2929),
+		validateStack: makeStackFunc(1, 1),
+		valid:         true,
+	}
+	instructionSet[TSTORE] = operation{
+		execute:       opTstore,
+		gasCost:       constGasFunc(warmStorageReadCostEIP2929),
+		validateStack: makeStackFunc(2, 0),
+		valid:         true,
+		writes:        true,
+	}
+	// EIP-5656: MCOPY instruction
+	instructionSet[MCOPY] = operation{
+		execute:       opMcopy,
+		gasCost:       gasMcopy,
+		validateStack: makeStackFunc(3, 0),
+		memorySize:    memoryMcopy,
+		valid:         true,
+	}
+	return instructionSet
+}
+
+// NewBerlinInstructionSet returns the frontier, homestead, byzantium,
+// contantinople, istanbul and berlin instructions.
+func NewBerlinInstructionSet() [256]operation {
+	instructionSet := NewIstanbulInstructionSet()
+
+	// EIP-2929: gas cost increases for state access opcodes
+	instructionSet[SLOAD].gasCost = gasSLoadEIP2929
+	instructionSet[SSTORE].gasCost = gasSStoreEIP2929
+	instructionSet[BALANCE].gasCost = makeGasAccountCheckEIP2929(gasBalance, 0)
+	instructionSet[EXTCODESIZE].gasCost = makeGasAccountCheckEIP2929(gasExtCodeSize, 0)
+	instructionSet[EXTCODECOPY].gasCost = makeGasAccountCheckEIP2929(gasExtCodeCopy, 0)
+	instructionSet[EXTCODEHASH].gasCost = makeGasAccountCheckEIP2929(gasExtCodeHash, 0)
+	instructionSet[CALL].gasCost = makeCallVariantGasCallEIP2929(gasCall)
+	instructionSet[CALLCODE].gasCost = makeCallVariantGasCallEIP2929(gasCallCode)
+	instructionSet[DELEGATECALL].gasCost = makeCallVariantGasCallEIP2929(gasDelegateCall)
+	instructionSet[STATICCALL].gasCost = makeCallVariantGasCallEIP2929(gasStaticCall)
+	instructionSet[SELFDESTRUCT].gasCost = gasSuicideEIP2929
+	return instructionSet
+}
+
+// NewIstanbulInstructionSet returns the frontier, homestead, byzantium,
+// contantinople and istanbul instructions.
+func NewIstanbulInstructionSet() [256]operation {
+	instructionSet := NewConstantinopleInstructionSet()
+
+	// EIP-1344: ChainID opcode
+	instructionSet[CHAINID] = operation{
+		execute:       opChainID,
+		gasCost:       constGasFunc(GasQuickStep),
+		validateStack: makeStackFunc(0, 1),
+		valid:         true,
+	}
+	// EIP-1884: repricing for trie-size-dependent opcodes,
+	// the new prices of SLOAD, BALANCE and EXTCODEHASH are defined in the gas table.
+	instructionSet[SELFBALANCE] = operation{
+		execute:       opSelfBalance,
+		gasCost:       constGasFunc(GasFastStep),
+		validateStack: makeStackFunc(0, 1),
+		valid:         true,
+	}
+	// EIP-2200: rebalance net-metered SSTORE
+	instructionSet[SSTORE].gasCost = gasSStoreEIP2200
+	return instructionSet
+}
+
 // NewConstantinopleInstructionSet returns the frontier, homestead
 // byzantium and contantinople instructions.
 func NewConstantinopleInstructionSet() [256]operation {
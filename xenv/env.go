@@ -43,13 +43,14 @@ type TransactionContext struct {
 
 // Environment an env to execute native method.
 type Environment struct {
-	abi      *abi.Method
-	chain    *chain.Chain
-	state    *state.State
-	blockCtx *BlockContext
-	txCtx    *TransactionContext
-	evm      *vm.EVM
-	contract *vm.Contract
+	abi        *abi.Method
+	chain      *chain.Chain
+	state      *state.State
+	blockCtx   *BlockContext
+	txCtx      *TransactionContext
+	evm        *vm.EVM
+	contract   *vm.Contract
+	forkConfig *luckyshare.ForkConfig
 }
 
 // New create a new env.
@@ -61,15 +62,17 @@ func New(
 	txCtx *TransactionContext,
 	evm *vm.EVM,
 	contract *vm.Contract,
+	forkConfig *luckyshare.ForkConfig,
 ) *Environment {
 	return &Environment{
-		abi:      abi,
-		chain:    chain,
-		state:    state,
-		blockCtx: blockCtx,
-		txCtx:    txCtx,
-		evm:      evm,
-		contract: contract,
+		abi:        abi,
+		chain:      chain,
+		state:      state,
+		blockCtx:   blockCtx,
+		txCtx:      txCtx,
+		evm:        evm,
+		contract:   contract,
+		forkConfig: forkConfig,
 	}
 }
 
@@ -77,8 +80,10 @@ func (env *Environment) Chain() *chain.Chain                     { return env.ch
 func (env *Environment) State() *state.State                     { return env.state }
 func (env *Environment) TransactionContext() *TransactionContext { return env.txCtx }
 func (env *Environment) BlockContext() *BlockContext             { return env.blockCtx }
+func (env *Environment) ForkConfig() *luckyshare.ForkConfig      { return env.forkConfig }
 func (env *Environment) Caller() luckyshare.Address              { return luckyshare.Address(env.contract.Caller()) }
 func (env *Environment) To() luckyshare.Address                  { return luckyshare.Address(env.contract.Address()) }
+func (env *Environment) Value() *big.Int                         { return env.contract.Value() }
 
 func (env *Environment) UseGas(gas uint64) {
 	if !env.contract.UseGas(gas) {
@@ -86,6 +91,11 @@ func (env *Environment) UseGas(gas uint64) {
 	}
 }
 
+// Revert aborts the native method, the state changes are reverted and the gas left is kept.
+func (env *Environment) Revert() {
+	panic(vm.ErrExecutionReverted)
+}
+
 func (env *Environment) ParseArgs(val interface{}) {
 	if err := env.abi.DecodeInput(env.contract.Input, val); err != nil {
 		// as vm error
@@ -112,11 +122,16 @@ func (env *Environment) Log(abi *abi.Event, address luckyshare.Address, topics [
 	})
 }
 
+// Transfer transfers amount of token from sender to recipient, and records the transfer.
+func (env *Environment) Transfer(sender, recipient luckyshare.Address, amount *big.Int) {
+	env.evm.Transfer(env.evm.StateDB, common.Address(sender), common.Address(recipient), amount)
+}
+
 func (env *Environment) Call(proc func(env *Environment) []interface{}) (output []byte, err error) {
 	defer func() {
 		if e := recover(); e != nil {
-			if e == vm.ErrOutOfGas {
-				err = vm.ErrOutOfGas
+			if e == vm.ErrOutOfGas || e == vm.ErrExecutionReverted {
+				err = e.(error)
 			} else {
 				panic(e)
 			}